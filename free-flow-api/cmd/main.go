@@ -2,7 +2,10 @@ package main
 
 import (
 	"free-flow-api/config"
+	"free-flow-api/jobs"
+	"free-flow-api/middleware"
 	"free-flow-api/routes"
+	"free-flow-api/utils"
 	"log"
 	"time"
 
@@ -14,6 +17,15 @@ import (
 func init() {
 	config.LoadEnv()
 	config.ConnectDB()
+
+	// every create, update and delete is written to the audit log
+	if err := utils.RegisterAuditCallbacks(config.DB); err != nil {
+		log.Fatalf("Failed to register audit callbacks: %v", err)
+	}
+
+	if err := utils.InitFileStorage(); err != nil {
+		log.Fatalf("Failed to set up file storage: %v", err)
+	}
 }
 
 func main() {
@@ -25,11 +37,13 @@ func main() {
 			"http://localhost:3410",
 		},
 		AllowMethods:  []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:  []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders: []string{"Content-Length"},
+		AllowHeaders:  []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Request-ID"},
+		ExposeHeaders: []string{"Content-Length", "X-Request-ID"},
 		MaxAge:        12 * time.Hour,
 	}))
 
+	r.Use(middleware.RequestContext())
+
 	r.GET("/", func(c *gin.Context) {
 		c.JSON(200, gin.H{
 			"message": "Welcome to free-flow api",
@@ -40,6 +54,7 @@ func main() {
 	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
 
 	api := r.Group("/api/v1")
+	api.Use(middleware.TrackActivity())
 	{
 		routes.RegisterUserRouter(api)
 		routes.RegisterEntityRouter(api)
@@ -56,7 +71,22 @@ func main() {
 		routes.RegisterContractRouter(api)
 		routes.RegisterOnboardingRouter(api)
 		routes.RegisterInviteRouter(api)
+		routes.RegisterEscrowRouter(api)
+		routes.RegisterQuoteRouter(api)
+		routes.RegisterMarketplaceRouter(api)
+		routes.RegisterReviewRouter(api)
+		routes.RegisterVerificationRouter(api)
+		routes.RegisterAdminRouter(api)
+		routes.RegisterWorkspaceRouter(api)
+		routes.RegisterTimeRouter(api)
+		routes.RegisterBillingRouter(api)
+		routes.RegisterCommentRouter(api)
+		routes.RegisterAttachmentRouter(api)
+		routes.RegisterAuditRouter(api)
 	}
+	// background housekeeping
+	jobs.StartInviteJobs(15 * time.Minute)
+
 	log.Println("Server is up and runnig")
 	r.Run()
 }
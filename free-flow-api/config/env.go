@@ -20,3 +20,10 @@ func GetEnv(key string) string {
 	}
 	return v
 }
+
+func GetEnvOrDefault(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
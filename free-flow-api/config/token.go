@@ -7,7 +7,8 @@ import (
 )
 
 type JWTCustomClaims struct {
-	UserID string `json:"uid"`
+	UserID         string `json:"uid"`
+	ImpersonatorID string `json:"imp,omitempty"` // admin acting as UserID
 	jwt.RegisteredClaims
 }
 
@@ -19,6 +20,24 @@ type InviteClaims struct {
 	jwt.RegisteredClaims
 }
 
+type QuoteClaims struct {
+	QuoteID string `json:"quote_id"`
+	jwt.RegisteredClaims
+}
+
+// FileClaims back a signed download link for a stored attachment
+type FileClaims struct {
+	AttachmentID string `json:"attachment_id"`
+	jwt.RegisteredClaims
+}
+
+// ClientClaims back a client's link into a project's shared discussion
+type ClientClaims struct {
+	ProjectID string `json:"project_id"`
+	EntityID  string `json:"entity_id"`
+	jwt.RegisteredClaims
+}
+
 func GenerateToken(userID string, ttl time.Duration) (string, error) {
 	secret := []byte(GetEnv("JWT_SECRET"))
 	claims := &JWTCustomClaims{
@@ -33,7 +52,24 @@ func GenerateToken(userID string, ttl time.Duration) (string, error) {
 	return token.SignedString(secret)
 }
 
-func GenerateInviteToken(inviteID, associateID, contractID, taskID string, ttl time.Duration) (string, error) {
+// GenerateImpersonationToken signs a short-lived token that lets an admin act as another principal
+func GenerateImpersonationToken(userID, adminID string, ttl time.Duration) (string, error) {
+	secret := []byte(GetEnv("JWT_SECRET"))
+	claims := &JWTCustomClaims{
+		UserID:         userID,
+		ImpersonatorID: adminID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Subject:   userID,
+		},
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(secret)
+}
+
+// GenerateInviteToken signs an invite link; tokenID is checked against the invite so reissued links replace older ones
+func GenerateInviteToken(inviteID, associateID, contractID, taskID, tokenID string, expiresAt time.Time) (string, error) {
 	secret := []byte(GetEnv("JWT_SECRET"))
 
 	claims := &InviteClaims{
@@ -42,7 +78,8 @@ func GenerateInviteToken(inviteID, associateID, contractID, taskID string, ttl t
 		ContractID:  contractID,
 		TaskID:      taskID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+			ID:        tokenID,
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Subject:   "invite",
 		},
@@ -51,3 +88,54 @@ func GenerateInviteToken(inviteID, associateID, contractID, taskID string, ttl t
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString(secret)
 }
+
+func GenerateQuoteToken(quoteID string, expiresAt time.Time) (string, error) {
+	secret := []byte(GetEnv("JWT_SECRET"))
+
+	claims := &QuoteClaims{
+		QuoteID: quoteID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Subject:   "quote",
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(secret)
+}
+
+// GenerateClientToken signs a client link; linkID is checked against the project so links can be replaced or revoked
+func GenerateClientToken(projectID, entityID, linkID string, expiresAt time.Time) (string, error) {
+	secret := []byte(GetEnv("JWT_SECRET"))
+
+	claims := &ClientClaims{
+		ProjectID: projectID,
+		EntityID:  entityID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        linkID,
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Subject:   "client",
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(secret)
+}
+
+func GenerateFileToken(attachmentID string, expiresAt time.Time) (string, error) {
+	secret := []byte(GetEnv("JWT_SECRET"))
+
+	claims := &FileClaims{
+		AttachmentID: attachmentID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Subject:   "file",
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(secret)
+}
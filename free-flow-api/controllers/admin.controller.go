@@ -0,0 +1,588 @@
+package controllers
+
+import (
+	"free-flow-api/config"
+	"free-flow-api/models"
+	"free-flow-api/utils"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const impersonationTTL = time.Hour
+
+type SuspendInput struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+type ImpersonateInput struct {
+	PrincipalType string    `json:"principal_type" binding:"required,oneof=user associate"`
+	PrincipalID   uuid.UUID `json:"principal_id" binding:"required"`
+	Reason        string    `json:"reason" binding:"required"`
+}
+
+type statusCount struct {
+	Status string
+	Count  int64
+}
+
+// recordAdminAction appends an entry to the admin audit trail for the calling admin
+func recordAdminAction(tx *gorm.DB, c *gin.Context, action, targetType string, targetID uuid.UUID, reason, details string) error {
+	adminID, err := uuid.Parse(c.GetString("userID"))
+	if err != nil {
+		return err
+	}
+	return tx.Create(&models.AdminAction{
+		AdminID:    adminID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Reason:     reason,
+		Details:    details,
+		IPAddress:  c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+	}).Error
+}
+
+// pageParams reads ?limit= and ?offset=, capping the limit at 100
+func pageParams(c *gin.Context) (int, int) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "25"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit <= 0 || limit > 100 {
+		limit = 25
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
+
+func countByStatus(model interface{}, column string) (map[string]int64, error) {
+	var rows []statusCount
+	if err := config.DB.Model(model).
+		Select(column + " AS status, COUNT(*) AS count").
+		Group(column).
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, r := range rows {
+		counts[r.Status] = r.Count
+	}
+	return counts, nil
+}
+
+// SearchUsers finds freelancer accounts by name or email
+func SearchUsers(c *gin.Context) {
+	limit, offset := pageParams(c)
+
+	query := config.DB.Model(&models.User{})
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		like := "%" + strings.ToLower(q) + "%"
+		query = query.Where("(LOWER(email) LIKE ? OR LOWER(first_name || ' ' || last_name) LIKE ?)", like, like)
+	}
+	switch c.Query("suspended") {
+	case "true":
+		query = query.Where("suspended_at IS NOT NULL")
+	case "false":
+		query = query.Where("suspended_at IS NULL")
+	}
+	if c.Query("admin") == "true" {
+		query = query.Where("is_admin = ?", true)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to count users")
+		return
+	}
+
+	var users []models.User
+	if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&users).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch users")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"total":  total,
+		"limit":  limit,
+		"offset": offset,
+		"users":  users,
+	})
+}
+
+func GetAdminUser(c *gin.Context) {
+	var user models.User
+	if err := config.DB.First(&user, "id = ?", c.Param("id")).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "user not found")
+		return
+	}
+
+	var projects, associates, invoices int64
+	config.DB.Model(&models.Project{}).Where("user_id = ?", user.ID).Count(&projects)
+	config.DB.Model(&models.Associate{}).Where("user_id = ?", user.ID).Count(&associates)
+	config.DB.Model(&models.Invoice{}).Where("user_id = ?", user.ID).Count(&invoices)
+
+	var actions []models.AdminAction
+	config.DB.Where("target_id = ?", user.ID).Order("created_at DESC").Limit(20).Find(&actions)
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"user":          user,
+		"projects":      projects,
+		"associates":    associates,
+		"invoices":      invoices,
+		"admin_actions": actions,
+	})
+}
+
+func SuspendUser(c *gin.Context) {
+	var input SuspendInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var user models.User
+	if err := config.DB.First(&user, "id = ?", c.Param("id")).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "user not found")
+		return
+	}
+	if user.ID.String() == c.GetString("userID") {
+		utils.SendErrorResponse(c, http.StatusConflict, "admins cannot suspend themselves")
+		return
+	}
+	if user.SuspendedAt != nil {
+		utils.SendErrorResponse(c, http.StatusConflict, "user is already suspended")
+		return
+	}
+
+	now := time.Now()
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"suspended_at":     now,
+			"suspended_reason": input.Reason,
+		}).Error; err != nil {
+			return err
+		}
+		return recordAdminAction(tx, c, models.AdminActionSuspend, models.PrincipalUser, user.ID, input.Reason, user.Email)
+	})
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to suspend user")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"message":      "User suspended",
+		"suspended_at": now,
+	})
+}
+
+func UnsuspendUser(c *gin.Context) {
+	var input SuspendInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var user models.User
+	if err := config.DB.First(&user, "id = ?", c.Param("id")).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "user not found")
+		return
+	}
+	if user.SuspendedAt == nil {
+		utils.SendErrorResponse(c, http.StatusConflict, "user is not suspended")
+		return
+	}
+
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"suspended_at":     nil,
+			"suspended_reason": nil,
+		}).Error; err != nil {
+			return err
+		}
+		return recordAdminAction(tx, c, models.AdminActionUnsuspend, models.PrincipalUser, user.ID, input.Reason, user.Email)
+	})
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to lift suspension")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"message": "User suspension lifted",
+	})
+}
+
+// SearchAssociates finds associates across all freelancers, with their profile state
+func SearchAssociates(c *gin.Context) {
+	limit, offset := pageParams(c)
+
+	query := config.DB.Model(&models.Associate{}).
+		Joins("LEFT JOIN associate_profiles ON associate_profiles.associate_id = associates.id AND associate_profiles.deleted_at IS NULL")
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		like := "%" + strings.ToLower(q) + "%"
+		query = query.Where("(LOWER(associates.email) LIKE ? OR LOWER(associates.name) LIKE ?)", like, like)
+	}
+	if status := c.Query("marketplace_status"); status != "" {
+		query = query.Where("associate_profiles.marketplace_status = ?", status)
+	}
+	if level := c.Query("verification_level"); level != "" {
+		query = query.Where("associate_profiles.verification_level = ? AND associate_profiles.is_verified = ?", level, true)
+	}
+	switch c.Query("suspended") {
+	case "true":
+		query = query.Where("associate_profiles.suspended_at IS NOT NULL")
+	case "false":
+		query = query.Where("associate_profiles.suspended_at IS NULL")
+	}
+	if c.Query("onboarded") == "false" {
+		query = query.Where("associate_profiles.id IS NULL")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to count associates")
+		return
+	}
+
+	var associates []models.Associate
+	if err := query.Preload("Profile").Order("associates.created_at DESC").Limit(limit).Offset(offset).Find(&associates).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch associates")
+		return
+	}
+
+	results := make([]gin.H, 0, len(associates))
+	for _, a := range associates {
+		row := gin.H{
+			"id":         a.ID,
+			"name":       a.Name,
+			"email":      a.Email,
+			"user_id":    a.UserID,
+			"status":     a.Status,
+			"created_at": a.CreatedAt,
+			"onboarded":  a.Profile != nil,
+		}
+		if a.Profile != nil {
+			row["marketplace_status"] = a.Profile.MarketplaceStatus
+			row["verification_level"] = a.Profile.VerificationLevel
+			row["is_verified"] = a.Profile.IsVerified
+			row["suspended_at"] = a.Profile.SuspendedAt
+			row["last_login_at"] = a.Profile.LastLoginAt
+		}
+		results = append(results, row)
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"total":      total,
+		"limit":      limit,
+		"offset":     offset,
+		"associates": results,
+	})
+}
+
+func GetAdminAssociate(c *gin.Context) {
+	var associate models.Associate
+	if err := config.DB.Preload("Profile").Preload("User").First(&associate, "id = ?", c.Param("id")).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "associate not found")
+		return
+	}
+
+	var invites []models.Invite
+	config.DB.Where("associate_id = ?", associate.ID).Order("created_at DESC").Limit(20).Find(&invites)
+
+	var verifications []models.VerificationRequest
+	config.DB.Preload("Documents").Where("associate_id = ?", associate.ID).Order("created_at DESC").Find(&verifications)
+
+	var actions []models.AdminAction
+	config.DB.Where("target_id = ?", associate.ID).Order("created_at DESC").Limit(20).Find(&actions)
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"associate":     associate,
+		"owner":         associate.User,
+		"invites":       invites,
+		"verifications": verifications,
+		"admin_actions": actions,
+	})
+}
+
+func SuspendAssociate(c *gin.Context) {
+	var input SuspendInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var profile models.AssociateProfile
+	if err := config.DB.First(&profile, "associate_id = ?", c.Param("id")).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "associate profile not found")
+		return
+	}
+	if profile.SuspendedAt != nil {
+		utils.SendErrorResponse(c, http.StatusConflict, "associate is already suspended")
+		return
+	}
+
+	now := time.Now()
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&profile).Updates(map[string]interface{}{
+			"suspended_at":     now,
+			"suspended_reason": input.Reason,
+		}).Error; err != nil {
+			return err
+		}
+		// a suspended associate also loses marketplace access
+		if profile.MarketplaceStatus != nil && *profile.MarketplaceStatus == models.MarketplaceApproved {
+			if err := setMarketplaceStatus(tx, &profile, models.MarketplaceSuspended, &input.Reason); err != nil {
+				return err
+			}
+		}
+		return recordAdminAction(tx, c, models.AdminActionSuspend, models.PrincipalAssociate, profile.AssociateID, input.Reason, "")
+	})
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to suspend associate")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"message":      "Associate suspended",
+		"suspended_at": now,
+	})
+}
+
+func UnsuspendAssociate(c *gin.Context) {
+	var input SuspendInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var profile models.AssociateProfile
+	if err := config.DB.First(&profile, "associate_id = ?", c.Param("id")).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "associate profile not found")
+		return
+	}
+	if profile.SuspendedAt == nil {
+		utils.SendErrorResponse(c, http.StatusConflict, "associate is not suspended")
+		return
+	}
+
+	// marketplace access is not restored automatically; that stays a separate decision
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&profile).Updates(map[string]interface{}{
+			"suspended_at":     nil,
+			"suspended_reason": nil,
+		}).Error; err != nil {
+			return err
+		}
+		return recordAdminAction(tx, c, models.AdminActionUnsuspend, models.PrincipalAssociate, profile.AssociateID, input.Reason, "")
+	})
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to lift suspension")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"message": "Associate suspension lifted",
+	})
+}
+
+// GetAdminInvite shows an invite with everything needed to work out why it is stuck
+func GetAdminInvite(c *gin.Context) {
+	var invite models.Invite
+	if err := config.DB.
+		Preload("Project").
+		Preload("Task").
+		Preload("Associate").
+		Preload("Contract").
+		First(&invite, "id = ?", c.Param("id")).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "invite not found")
+		return
+	}
+
+	var signature *models.ContractSignature
+	var sig models.ContractSignature
+	if err := config.DB.First(&sig, "invite_id = ?", invite.ID).Error; err == nil {
+		signature = &sig
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"invite":                   invite,
+		"expired":                  invite.IsExpired(),
+		"contract_current_version": invite.Contract.CurrentVersion,
+		"stale_version":            invite.ContractVersion != invite.Contract.CurrentVersion,
+		"signature":                signature,
+	})
+}
+
+// Impersonate issues a short-lived token to act as a user or associate; every write made with it is audited
+func Impersonate(c *gin.Context) {
+	var input ImpersonateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	switch input.PrincipalType {
+	case models.PrincipalUser:
+		var user models.User
+		if err := config.DB.First(&user, "id = ?", input.PrincipalID).Error; err != nil {
+			utils.SendErrorResponse(c, http.StatusNotFound, "user not found")
+			return
+		}
+		if user.IsAdmin {
+			utils.SendErrorResponse(c, http.StatusForbidden, "admins cannot be impersonated")
+			return
+		}
+	case models.PrincipalAssociate:
+		var profile models.AssociateProfile
+		if err := config.DB.First(&profile, "associate_id = ?", input.PrincipalID).Error; err != nil {
+			utils.SendErrorResponse(c, http.StatusNotFound, "associate profile not found")
+			return
+		}
+	}
+
+	token, err := config.GenerateImpersonationToken(input.PrincipalID.String(), c.GetString("userID"), impersonationTTL)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to create token")
+		return
+	}
+
+	if err := recordAdminAction(config.DB.WithContext(c), c, models.AdminActionImpersonate, input.PrincipalType, input.PrincipalID, input.Reason, ""); err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to record impersonation")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"token":      token,
+		"expires_at": time.Now().Add(impersonationTTL),
+	})
+}
+
+// GetAdminAuditLog lists admin actions, filterable by admin, target and action
+func GetAdminAuditLog(c *gin.Context) {
+	limit, offset := pageParams(c)
+
+	query := config.DB.Model(&models.AdminAction{})
+	if id := c.Query("admin_id"); id != "" {
+		query = query.Where("admin_id = ?", id)
+	}
+	if id := c.Query("target_id"); id != "" {
+		query = query.Where("target_id = ?", id)
+	}
+	if action := c.Query("action"); action != "" {
+		query = query.Where("action = ?", action)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to count audit log")
+		return
+	}
+
+	var actions []models.AdminAction
+	if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&actions).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch audit log")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"total":   total,
+		"limit":   limit,
+		"offset":  offset,
+		"actions": actions,
+	})
+}
+
+// GetSystemMetrics reports platform-wide counts and money totals
+func GetSystemMetrics(c *gin.Context) {
+	since := time.Now().AddDate(0, 0, -30)
+
+	var users, admins, suspendedUsers, newUsers int64
+	var associates, onboarded, suspendedAssociates, newAssociates int64
+	var pendingVerifications int64
+	config.DB.Model(&models.User{}).Count(&users)
+	config.DB.Model(&models.User{}).Where("is_admin = ?", true).Count(&admins)
+	config.DB.Model(&models.User{}).Where("suspended_at IS NOT NULL").Count(&suspendedUsers)
+	config.DB.Model(&models.User{}).Where("created_at >= ?", since).Count(&newUsers)
+	config.DB.Model(&models.Associate{}).Count(&associates)
+	config.DB.Model(&models.AssociateProfile{}).Count(&onboarded)
+	config.DB.Model(&models.AssociateProfile{}).Where("suspended_at IS NOT NULL").Count(&suspendedAssociates)
+	config.DB.Model(&models.Associate{}).Where("created_at >= ?", since).Count(&newAssociates)
+	config.DB.Model(&models.VerificationRequest{}).Where("status = ?", "pending").Count(&pendingVerifications)
+
+	marketplace, err := countByStatus(&models.AssociateProfile{}, "marketplace_status")
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch metrics")
+		return
+	}
+	projects, err := countByStatus(&models.Project{}, "status")
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch metrics")
+		return
+	}
+	tasks, err := countByStatus(&models.Task{}, "status")
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch metrics")
+		return
+	}
+	invites, err := countByStatus(&models.Invite{}, "status")
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch metrics")
+		return
+	}
+	invoices, err := countByStatus(&models.Invoice{}, "status")
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch metrics")
+		return
+	}
+
+	var money struct {
+		Invoiced   float64
+		Collected  float64
+		Expected   int64
+		Settled    int64
+		EscrowHeld float64
+	}
+	config.DB.Model(&models.Invoice{}).Where("status <> ?", "cancelled").Select("COALESCE(SUM(amount), 0)").Scan(&money.Invoiced)
+	config.DB.Model(&models.Payment{}).Select("COALESCE(SUM(amount), 0)").Scan(&money.Collected)
+	config.DB.Model(&models.AssociateSettlement{}).Select("COALESCE(SUM(expected_amount), 0)").Scan(&money.Expected)
+	config.DB.Model(&models.AssociateSettlement{}).Select("COALESCE(SUM(settled_amount), 0)").Scan(&money.Settled)
+	config.DB.Model(&models.EscrowTransaction{}).
+		Select("COALESCE(SUM(CASE WHEN type = ? THEN amount ELSE -amount END), 0)", models.EscrowFund).
+		Scan(&money.EscrowHeld)
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"users": gin.H{
+			"total":        users,
+			"admins":       admins,
+			"suspended":    suspendedUsers,
+			"last_30_days": newUsers,
+		},
+		"associates": gin.H{
+			"total":                 associates,
+			"onboarded":             onboarded,
+			"suspended":             suspendedAssociates,
+			"last_30_days":          newAssociates,
+			"marketplace":           marketplace,
+			"pending_verifications": pendingVerifications,
+		},
+		"projects": projects,
+		"tasks":    tasks,
+		"invites":  invites,
+		"invoices": invoices,
+		"money": gin.H{
+			"invoiced":                money.Invoiced,
+			"collected":               money.Collected,
+			"settlements_expected":    money.Expected,
+			"settlements_paid":        money.Settled,
+			"settlements_outstanding": money.Expected - money.Settled,
+			"escrow_held":             money.EscrowHeld,
+		},
+		"generated_at": time.Now(),
+	})
+}
@@ -0,0 +1,354 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"free-flow-api/config"
+	"free-flow-api/utils"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type EarningsSettlement struct {
+	ID             uuid.UUID  `json:"id"`
+	UserID         uuid.UUID  `json:"user_id"`
+	FreelancerName string     `json:"freelancer_name"`
+	ProjectID      uuid.UUID  `json:"project_id"`
+	ProjectName    string     `json:"project_name"`
+	TaskID         uuid.UUID  `json:"task_id"`
+	TaskTitle      string     `json:"task_title"`
+	PercentageCut  float64    `json:"percentage_cut"`
+	ExpectedAmount int64      `json:"expected_amount"`
+	SettledAmount  int64      `json:"settled_amount"`
+	Outstanding    int64      `json:"outstanding"`
+	Status         string     `json:"status"`
+	Method         string     `json:"method"`
+	TransactionRef string     `json:"transaction_ref"`
+	CreatedAt      time.Time  `json:"created_at"`
+	SettledAt      *time.Time `json:"settled_at"`
+	PaidAt         *time.Time `json:"paid_at"` // last payment
+}
+
+// EarningsStatementLine is a settlement as it stood for the statement's year
+type EarningsStatementLine struct {
+	EarningsSettlement
+	PaidInYear         int64 `json:"paid_in_year"`
+	OutstandingAtClose int64 `json:"outstanding_at_close"` // unpaid at the end of the year
+}
+
+type EarningsBucket struct {
+	Count       int64 `json:"count"`
+	Expected    int64 `json:"expected"`
+	Settled     int64 `json:"settled"`
+	Outstanding int64 `json:"outstanding"`
+}
+
+type EarningsByFreelancer struct {
+	UserID         uuid.UUID `json:"user_id"`
+	FreelancerName string    `json:"freelancer_name"`
+	EarningsBucket
+}
+
+type EarningsMonth struct {
+	Month  string `json:"month"` // YYYY-MM
+	Earned int64  `json:"earned"`
+	Paid   int64  `json:"paid"`
+}
+
+// associateSettlements starts a query over the associate's settlements with freelancer, project and task names
+func associateSettlements(associateID uuid.UUID) *gorm.DB {
+	return config.DB.
+		Table("associate_settlements AS s").
+		Joins("LEFT JOIN users AS u ON u.id = s.user_id").
+		Joins("LEFT JOIN projects AS p ON p.id = s.project_id").
+		Joins("LEFT JOIN tasks AS t ON t.id = s.task_id").
+		Select(`
+			s.id,
+			s.user_id,
+			TRIM(COALESCE(u.first_name, '') || ' ' || COALESCE(u.last_name, '')) AS freelancer_name,
+			s.project_id,
+			COALESCE(p.name, '') AS project_name,
+			s.task_id,
+			COALESCE(t.title, '') AS task_title,
+			s.percentage_cut,
+			s.expected_amount,
+			s.settled_amount,
+			GREATEST(s.expected_amount - s.settled_amount, 0) AS outstanding,
+			s.status,
+			s.method,
+			s.transaction_ref,
+			s.created_at,
+			s.settled_at,
+			(SELECT MAX(sp.paid_at) FROM settlement_payments AS sp WHERE sp.settlement_id = s.id) AS paid_at
+		`).
+		Where("s.associate_id = ? AND s.deleted_at IS NULL", associateID)
+}
+
+// GetMyEarnings summarises what the associate is owed and has been paid, across every freelancer
+func GetMyEarnings(c *gin.Context) {
+	associate, _, ok := loadSelfAssociate(c)
+	if !ok {
+		return
+	}
+
+	var rows []EarningsSettlement
+	if err := associateSettlements(associate.ID).Order("s.created_at DESC").Scan(&rows).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch settlements")
+		return
+	}
+
+	var total EarningsBucket
+	byStatus := map[string]*EarningsBucket{
+		"pending":           {},
+		"partially_settled": {},
+		"settled":           {},
+	}
+	byFreelancer := make(map[uuid.UUID]*EarningsByFreelancer)
+	order := []uuid.UUID{}
+	for _, r := range rows {
+		add := func(b *EarningsBucket) {
+			b.Count++
+			b.Expected += r.ExpectedAmount
+			b.Settled += r.SettledAmount
+			b.Outstanding += r.Outstanding
+		}
+		add(&total)
+		if b, ok := byStatus[r.Status]; ok {
+			add(b)
+		}
+		f, ok := byFreelancer[r.UserID]
+		if !ok {
+			f = &EarningsByFreelancer{UserID: r.UserID, FreelancerName: r.FreelancerName}
+			byFreelancer[r.UserID] = f
+			order = append(order, r.UserID)
+		}
+		add(&f.EarningsBucket)
+	}
+
+	freelancers := make([]EarningsByFreelancer, 0, len(order))
+	for _, id := range order {
+		freelancers = append(freelancers, *byFreelancer[id])
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"total":         total,
+		"by_status":     byStatus,
+		"by_freelancer": freelancers,
+	})
+}
+
+// GetMyEarningsSettlements lists the associate's settlements, optionally filtered by ?status= and ?user_id=
+func GetMyEarningsSettlements(c *gin.Context) {
+	associate, _, ok := loadSelfAssociate(c)
+	if !ok {
+		return
+	}
+
+	query := associateSettlements(associate.ID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("s.status = ?", status)
+	}
+	if userID := c.Query("user_id"); userID != "" {
+		query = query.Where("s.user_id = ?", userID)
+	}
+
+	var rows []EarningsSettlement
+	if err := query.Order("s.created_at DESC").Scan(&rows).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch settlements")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, rows)
+}
+
+// GetMyEarningsTrends returns earned (settlements created) and paid amounts per month for the last ?months= (default 12)
+func GetMyEarningsTrends(c *gin.Context) {
+	associate, _, ok := loadSelfAssociate(c)
+	if !ok {
+		return
+	}
+
+	months, _ := strconv.Atoi(c.DefaultQuery("months", "12"))
+	if months <= 0 || months > 36 {
+		months = 12
+	}
+
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()).AddDate(0, -(months - 1), 0)
+
+	type monthTotal struct {
+		Month  string
+		Amount int64
+	}
+	var earned, paid []monthTotal
+	if err := config.DB.
+		Table("associate_settlements AS s").
+		Select("TO_CHAR(s.created_at, 'YYYY-MM') AS month, COALESCE(SUM(s.expected_amount), 0) AS amount").
+		Where("s.associate_id = ? AND s.deleted_at IS NULL AND s.created_at >= ?", associate.ID, start).
+		Group("month").
+		Scan(&earned).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch earnings trend")
+		return
+	}
+	// paid is counted per payment, in the month each part was paid
+	if err := config.DB.
+		Table("settlement_payments AS sp").
+		Joins("JOIN associate_settlements AS s ON s.id = sp.settlement_id AND s.deleted_at IS NULL").
+		Select("TO_CHAR(sp.paid_at, 'YYYY-MM') AS month, COALESCE(SUM(sp.amount), 0) AS amount").
+		Where("sp.associate_id = ? AND sp.paid_at >= ?", associate.ID, start).
+		Group("month").
+		Scan(&paid).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch earnings trend")
+		return
+	}
+
+	series := make([]EarningsMonth, months)
+	index := make(map[string]int, months)
+	for i := 0; i < months; i++ {
+		key := start.AddDate(0, i, 0).Format("2006-01")
+		series[i].Month = key
+		index[key] = i
+	}
+	for _, e := range earned {
+		if i, ok := index[e.Month]; ok {
+			series[i].Earned = e.Amount
+		}
+	}
+	for _, p := range paid {
+		if i, ok := index[p.Month]; ok {
+			series[i].Paid = p.Amount
+		}
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, series)
+}
+
+// GetMyEarningsStatement builds the annual statement for ?year= (default this year) as CSV, or JSON with ?format=json
+func GetMyEarningsStatement(c *gin.Context) {
+	associate, _, ok := loadSelfAssociate(c)
+	if !ok {
+		return
+	}
+
+	year := time.Now().Year()
+	if y := c.Query("year"); y != "" {
+		parsed, err := strconv.Atoi(y)
+		if err != nil || parsed < 2000 || parsed > year {
+			utils.SendErrorResponse(c, http.StatusBadRequest, "invalid year")
+			return
+		}
+		year = parsed
+	}
+	from := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(1, 0, 0)
+
+	// everything earned or paid during the year
+	var settlements []EarningsSettlement
+	if err := associateSettlements(associate.ID).
+		Where("((s.created_at >= ? AND s.created_at < ?) OR s.id IN (?))", from, to,
+			config.DB.Table("settlement_payments").Select("settlement_id").Where("paid_at >= ? AND paid_at < ?", from, to)).
+		Order("s.created_at ASC").
+		Scan(&settlements).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch settlements")
+		return
+	}
+
+	// what was paid on each during the year, and in total by its end
+	type paidTotals struct {
+		SettlementID uuid.UUID
+		InYear       int64
+		ByClose      int64
+	}
+	ids := make([]uuid.UUID, 0, len(settlements))
+	for _, s := range settlements {
+		ids = append(ids, s.ID)
+	}
+	var totals []paidTotals
+	if len(ids) > 0 {
+		if err := config.DB.
+			Table("settlement_payments").
+			Select(`settlement_id,
+				COALESCE(SUM(CASE WHEN paid_at >= ? THEN amount ELSE 0 END), 0) AS in_year,
+				COALESCE(SUM(amount), 0) AS by_close`, from).
+			Where("settlement_id IN ? AND paid_at < ?", ids, to).
+			Group("settlement_id").
+			Scan(&totals).Error; err != nil {
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch settlement payments")
+			return
+		}
+	}
+	paidBy := make(map[uuid.UUID]paidTotals, len(totals))
+	for _, t := range totals {
+		paidBy[t.SettlementID] = t
+	}
+
+	// earned and outstanding cover work from this year; paid covers payments made in it
+	var earned, paid, outstanding int64
+	rows := make([]EarningsStatementLine, 0, len(settlements))
+	for _, s := range settlements {
+		line := EarningsStatementLine{EarningsSettlement: s, PaidInYear: paidBy[s.ID].InYear}
+		if !s.CreatedAt.Before(from) && s.CreatedAt.Before(to) {
+			line.OutstandingAtClose = max(s.ExpectedAmount-paidBy[s.ID].ByClose, 0)
+			earned += s.ExpectedAmount
+			outstanding += line.OutstandingAtClose
+		}
+		paid += line.PaidInYear
+		rows = append(rows, line)
+	}
+
+	if c.Query("format") == "json" {
+		utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+			"associate_id": associate.ID,
+			"name":         associate.Name,
+			"year":         year,
+			"earned":       earned,
+			"paid":         paid,
+			"outstanding":  outstanding,
+			"settlements":  rows,
+		})
+		return
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"Earnings statement", associate.Name, associate.Email, strconv.Itoa(year)})
+	w.Write([]string{})
+	w.Write([]string{"date", "freelancer", "project", "task", "status", "percentage_cut", "expected", "paid_in_year", "outstanding_at_year_end", "last_paid_at", "transaction_ref"})
+	for _, r := range rows {
+		paidAt := ""
+		if r.PaidAt != nil {
+			paidAt = r.PaidAt.Format("2006-01-02")
+		}
+		w.Write([]string{
+			r.CreatedAt.Format("2006-01-02"),
+			r.FreelancerName,
+			r.ProjectName,
+			r.TaskTitle,
+			r.Status,
+			strconv.FormatFloat(r.PercentageCut, 'f', 2, 64),
+			strconv.FormatInt(r.ExpectedAmount, 10),
+			strconv.FormatInt(r.PaidInYear, 10),
+			strconv.FormatInt(r.OutstandingAtClose, 10),
+			paidAt,
+			r.TransactionRef,
+		})
+	}
+	w.Write([]string{})
+	w.Write([]string{"earned", strconv.FormatInt(earned, 10)})
+	w.Write([]string{"paid", strconv.FormatInt(paid, 10)})
+	w.Write([]string{"outstanding", strconv.FormatInt(outstanding, 10)})
+	w.Flush()
+	if err := w.Error(); err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to build statement")
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"earnings-%d.csv\"", year))
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
@@ -0,0 +1,317 @@
+package controllers
+
+import (
+	"fmt"
+	"free-flow-api/config"
+	"free-flow-api/models"
+	"free-flow-api/utils"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type AssociateMatchInput struct {
+	TaskID             *uuid.UUID `json:"task_id"`
+	Title              string     `json:"title"`
+	Description        string     `json:"description"`
+	Skills             []string   `json:"skills"`
+	Budget             *float64   `json:"budget"`
+	DueDate            *time.Time `json:"due_date"`
+	EstimatedHours     *float64   `json:"estimated_hours"`
+	IncludeMarketplace bool       `json:"include_marketplace"`
+	Limit              int        `json:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+// MatchFactor is one weighted component of a match score; factors without data are left out of the total
+type MatchFactor struct {
+	Name   string   `json:"name"`
+	Weight float64  `json:"weight"`
+	Value  *float64 `json:"value"` // 0..1, nil when there is no data
+	Detail string   `json:"detail"`
+}
+
+type AssociateMatch struct {
+	AssociateID   uuid.UUID     `json:"associate_id"`
+	Name          string        `json:"name"`
+	Email         string        `json:"email,omitempty"` // only for the freelancer's own associates
+	Skills        []string      `json:"skills"`
+	MatchedSkills []string      `json:"matched_skills"`
+	Marketplace   bool          `json:"marketplace"`
+	Score         float64       `json:"score"` // 0..100
+	Factors       []MatchFactor `json:"factors"`
+}
+
+type associateHistory struct {
+	AssignedToAssociate uuid.UUID
+	Assigned            int64
+	Completed           int64
+	WithDueDate         int64
+	OnTime              int64
+	Active              int64
+	ActiveHours         float64
+	AvgTaskValue        *float64
+}
+
+const (
+	matchWeightSkills     = 0.35
+	matchWeightOnTime     = 0.15
+	matchWeightCompletion = 0.10
+	matchWeightWorkload   = 0.10
+	matchWeightCapacity   = 0.10
+	matchWeightBudget     = 0.10
+	matchWeightRating     = 0.10
+
+	// working hours assumed per day when checking capacity against a due date
+	matchHoursPerDay = 6
+	// effort assumed for a task without an estimate
+	matchDefaultHours = 8
+)
+
+// matchScore combines the available factors into a 0..100 score, re-weighting over those with data
+func matchScore(factors []MatchFactor) float64 {
+	var total, weights float64
+	for _, f := range factors {
+		if f.Value == nil {
+			continue
+		}
+		total += f.Weight * *f.Value
+		weights += f.Weight
+	}
+	if weights == 0 {
+		return 0
+	}
+	return math.Round(total/weights*1000) / 10
+}
+
+func ratio(n, d int64) float64 {
+	return float64(n) / float64(d)
+}
+
+// MatchAssociates ranks associates for a task by skills, delivery record, completion rate, workload,
+// capacity before the due date, fit with the budget and rating
+func MatchAssociates(c *gin.Context) {
+	userID := c.GetString("userID")
+	if !utils.IsAuthenticated(userID) {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid user token")
+		c.Abort()
+		return
+	}
+
+	var input AssociateMatchInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if input.Limit == 0 {
+		input.Limit = 10
+	}
+
+	// fill the brief from the task and any job posting for it
+	if input.TaskID != nil {
+		var task models.Task
+		if err := config.DB.
+			Joins("JOIN projects ON projects.id = tasks.project_id").
+			Where("tasks.id = ? AND projects.user_id = ?", *input.TaskID, userID).
+			First(&task).Error; err != nil {
+			utils.SendErrorResponse(c, http.StatusNotFound, "task not found")
+			return
+		}
+		if input.Title == "" {
+			input.Title = task.Title
+		}
+		if input.Description == "" {
+			input.Description = task.Description
+		}
+		if input.Budget == nil {
+			input.Budget = task.TaskValue
+		}
+		if input.DueDate == nil {
+			input.DueDate = task.DueDate
+		}
+		if input.EstimatedHours == nil && task.EstimatedHours > 0 {
+			input.EstimatedHours = &task.EstimatedHours
+		}
+		if len(input.Skills) == 0 {
+			var posting models.JobPosting
+			if err := config.DB.Where("task_id = ?", task.ID).Order("created_at DESC").First(&posting).Error; err == nil {
+				input.Skills = posting.Skills
+			}
+		}
+	}
+	required := normalizeSkills(input.Skills)
+	brief := strings.ToLower(input.Title + " " + input.Description)
+
+	// candidates: the freelancer's associates, optionally approved marketplace associates
+	query := config.DB.Preload("Profile")
+	if input.IncludeMarketplace {
+		query = query.
+			Joins("LEFT JOIN associate_profiles ON associate_profiles.associate_id = associates.id").
+			Where("associates.user_id = ? OR (associate_profiles.has_marketplace_access = ? AND associate_profiles.marketplace_status = ?)",
+				userID, true, models.MarketplaceApproved)
+	} else {
+		query = query.Where("associates.user_id = ?", userID)
+	}
+
+	var associates []models.Associate
+	if err := query.Find(&associates).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch associates")
+		return
+	}
+	if len(associates) == 0 {
+		utils.SendSuccessResponse(c, http.StatusOK, gin.H{"required_skills": required, "matches": []AssociateMatch{}})
+		return
+	}
+
+	ids := make([]uuid.UUID, 0, len(associates))
+	for _, a := range associates {
+		ids = append(ids, a.ID)
+	}
+
+	var rows []associateHistory
+	if err := config.DB.Model(&models.Task{}).
+		Select(`assigned_to_associate,
+			COUNT(*) AS assigned,
+			COUNT(*) FILTER (WHERE status IN ('done', 'paid')) AS completed,
+			COUNT(*) FILTER (WHERE status IN ('done', 'paid') AND due_date IS NOT NULL AND completed_at IS NOT NULL) AS with_due_date,
+			COUNT(*) FILTER (WHERE status IN ('done', 'paid') AND due_date IS NOT NULL AND completed_at <= due_date) AS on_time,
+			COUNT(*) FILTER (WHERE status NOT IN ('done', 'paid')) AS active,
+			COALESCE(SUM(estimated_hours) FILTER (WHERE status NOT IN ('done', 'paid')), 0) AS active_hours,
+			AVG(task_value) FILTER (WHERE task_value > 0) AS avg_task_value`).
+		Where("assigned_to_associate IN ?", ids).
+		Group("assigned_to_associate").
+		Scan(&rows).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch task history")
+		return
+	}
+	history := make(map[uuid.UUID]associateHistory, len(rows))
+	for _, r := range rows {
+		history[r.AssignedToAssociate] = r
+	}
+
+	matches := make([]AssociateMatch, 0, len(associates))
+	for _, a := range associates {
+		skills := []string(a.Skills)
+		if a.Profile != nil {
+			skills = append(skills, a.Profile.Skills...)
+		}
+		skills = normalizeSkills(skills)
+
+		var matched []string
+		skillFactor := MatchFactor{Name: "skill_overlap", Weight: matchWeightSkills}
+		if len(required) > 0 {
+			for _, s := range skills {
+				for _, r := range required {
+					if s == r {
+						matched = append(matched, s)
+					}
+				}
+			}
+			v := float64(len(matched)) / float64(len(required))
+			skillFactor.Value = &v
+			skillFactor.Detail = strings.Join(matched, ", ")
+			if skillFactor.Detail == "" {
+				skillFactor.Detail = "none of the required skills"
+			}
+		} else {
+			// no explicit skills: look for the associate's skills in the task brief
+			for _, s := range skills {
+				if strings.Contains(brief, s) {
+					matched = append(matched, s)
+				}
+			}
+			v := math.Min(float64(len(matched))/3, 1)
+			skillFactor.Value = &v
+			skillFactor.Detail = "skills mentioned in the task: " + strings.Join(matched, ", ")
+		}
+
+		h := history[a.ID]
+		onTime := MatchFactor{Name: "on_time_ratio", Weight: matchWeightOnTime, Detail: "no completed tasks with due dates"}
+		if h.WithDueDate > 0 {
+			v := ratio(h.OnTime, h.WithDueDate)
+			onTime.Value = &v
+			onTime.Detail = fmt.Sprintf("%d of %d delivered on time", h.OnTime, h.WithDueDate)
+		}
+
+		completion := MatchFactor{Name: "completion_rate", Weight: matchWeightCompletion, Detail: "no assigned tasks yet"}
+		if h.Assigned > 0 {
+			v := ratio(h.Completed, h.Assigned)
+			completion.Value = &v
+			completion.Detail = fmt.Sprintf("%d of %d assigned tasks completed", h.Completed, h.Assigned)
+		}
+
+		workloadValue := 1 / (1 + float64(h.Active))
+		workload := MatchFactor{
+			Name:   "workload",
+			Weight: matchWeightWorkload,
+			Value:  &workloadValue,
+			Detail: fmt.Sprintf("%d active tasks, %.1f estimated hours", h.Active, h.ActiveHours),
+		}
+
+		// hours free before the due date, after the work already on their plate
+		capacity := MatchFactor{Name: "capacity", Weight: matchWeightCapacity, Detail: "no due date"}
+		if input.DueDate != nil {
+			need := float64(matchDefaultHours)
+			if input.EstimatedHours != nil && *input.EstimatedHours > 0 {
+				need = *input.EstimatedHours
+			}
+			days := math.Max(math.Ceil(time.Until(*input.DueDate).Hours()/24), 0)
+			free := days*matchHoursPerDay - h.ActiveHours
+			v := math.Max(math.Min(free/need, 1), 0)
+			capacity.Value = &v
+			capacity.Detail = fmt.Sprintf("about %.0f free hours before the due date for %.1f needed", math.Max(free, 0), need)
+		}
+
+		// how the budget compares with the value of tasks they usually take on
+		budget := MatchFactor{Name: "budget_fit", Weight: matchWeightBudget, Detail: "no budget or task value history"}
+		if input.Budget != nil && *input.Budget > 0 && h.AvgTaskValue != nil && *h.AvgTaskValue > 0 {
+			v := math.Min(*input.Budget / *h.AvgTaskValue, 1)
+			budget.Value = &v
+			budget.Detail = fmt.Sprintf("budget %.2f against a typical task value of %.2f", *input.Budget, *h.AvgTaskValue)
+		}
+
+		rating := MatchFactor{Name: "average_rating", Weight: matchWeightRating, Detail: "no ratings yet"}
+		if a.Profile != nil && a.Profile.RatingCount > 0 {
+			v := a.Profile.RatingAverage / 5
+			rating.Value = &v
+			rating.Detail = fmt.Sprintf("%.1f/5 from %d reviews", a.Profile.RatingAverage, a.Profile.RatingCount)
+		}
+
+		factors := []MatchFactor{skillFactor, onTime, completion, workload, capacity, budget, rating}
+		if matched == nil {
+			matched = []string{}
+		}
+		match := AssociateMatch{
+			AssociateID:   a.ID,
+			Name:          a.Name,
+			Skills:        skills,
+			MatchedSkills: matched,
+			Marketplace:   a.UserID.String() != userID,
+			Score:         matchScore(factors),
+			Factors:       factors,
+		}
+		// marketplace associates are reached through offers, not directly
+		if !match.Marketplace {
+			match.Email = a.Email
+		}
+		matches = append(matches, match)
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+	if len(matches) > input.Limit {
+		matches = matches[:input.Limit]
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"required_skills": required,
+		"budget":          input.Budget,
+		"due_date":        input.DueDate,
+		"matches":         matches,
+	})
+}
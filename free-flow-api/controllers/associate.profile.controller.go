@@ -76,7 +76,7 @@ func OnboardAssociate(c *gin.Context) {
 	}
 
 	// Run the update inside a transaction
-	err = config.DB.Transaction(func(tx *gorm.DB) error {
+	err = config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
 		// Check that the associate exists
 		var associate models.Associate
 		if err := tx.First(&associate, "id = ?", associateID).Error; err != nil {
@@ -106,6 +106,7 @@ func OnboardAssociate(c *gin.Context) {
 		profile.WebsiteURL = &input.WebsiteURL
 		profile.IsOnboarded = true
 		profile.UpdatedAt = time.Now()
+		profile.LastLoginAt = &profile.UpdatedAt
 
 		// Save the updated profile
 		if err := tx.Save(&profile).Error; err != nil {
@@ -186,6 +187,15 @@ func AssociateLogin(c *gin.Context) {
 		return
 	}
 
+	if associateProfile.SuspendedAt != nil {
+		utils.SendErrorResponse(c, http.StatusForbidden, "account is suspended")
+		return
+	}
+
+	now := time.Now()
+	associateProfile.LastLoginAt = &now
+	config.DB.WithContext(c).Model(&associateProfile).Update("last_login_at", now)
+
 	token, err := config.GenerateToken(associate.ID.String(), 24*time.Hour)
 	if err != nil {
 		utils.SendErrorResponse(c, http.StatusUnauthorized, "Failed to create jwt token")
@@ -0,0 +1,344 @@
+package controllers
+
+import (
+	"free-flow-api/config"
+	"free-flow-api/models"
+	"free-flow-api/utils"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var profilePhotoTypes = []string{"image/jpeg", "image/png", "image/webp"}
+
+// AssociateProfileInput only changes the fields that are sent
+type AssociateProfileInput struct {
+	Name         *string  `json:"name" binding:"omitempty,min=2,max=100"`
+	PhoneNumber  *string  `json:"phone_number" binding:"omitempty,max=20"`
+	Bio          *string  `json:"bio" binding:"omitempty,max=500"`
+	PortfolioURL *string  `json:"portfolio_url" binding:"omitempty,url"`
+	LinkedInURL  *string  `json:"linkedin_url" binding:"omitempty,url"`
+	WebsiteURL   *string  `json:"website_url" binding:"omitempty,url"`
+	Skills       []string `json:"skills"`
+}
+
+type ChangePasswordInput struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=8,max=64"`
+}
+
+// loadSelfAssociate resolves the calling associate and their profile
+func loadSelfAssociate(c *gin.Context) (*models.Associate, *models.AssociateProfile, bool) {
+	associateID := c.GetString("userID")
+	if !utils.IsAssociateAuthenticated(associateID) {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid associate token")
+		c.Abort()
+		return nil, nil, false
+	}
+
+	var associate models.Associate
+	if err := config.DB.Preload("Profile").First(&associate, "id = ?", associateID).Error; err != nil || associate.Profile == nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "associate not found")
+		return nil, nil, false
+	}
+
+	return &associate, associate.Profile, true
+}
+
+func associateProfileResponse(associate *models.Associate, profile *models.AssociateProfile) gin.H {
+	return gin.H{
+		"id":                 associate.ID,
+		"name":               associate.Name,
+		"email":              associate.Email,
+		"phone_number":       profile.PhoneNumber,
+		"profile_photo_url":  profile.ProfilePhotoURL,
+		"bio":                profile.Bio,
+		"portfolio_url":      profile.PortfolioURL,
+		"linkedin_url":       profile.LinkedInURL,
+		"website_url":        profile.WebsiteURL,
+		"skills":             profile.Skills,
+		"verification_level": profile.VerificationLevel,
+		"is_verified":        profile.IsVerified,
+		"marketplace_status": profile.MarketplaceStatus,
+		"rating_average":     profile.RatingAverage,
+		"rating_count":       profile.RatingCount,
+		"last_login_at":      profile.LastLoginAt,
+		"last_activity_at":   profile.LastActivityAt,
+		"created_at":         profile.CreatedAt,
+		"updated_at":         profile.UpdatedAt,
+	}
+}
+
+func GetMyAssociateProfile(c *gin.Context) {
+	associate, profile, ok := loadSelfAssociate(c)
+	if !ok {
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, associateProfileResponse(associate, profile))
+}
+
+func UpdateMyAssociateProfile(c *gin.Context) {
+	associate, profile, ok := loadSelfAssociate(c)
+	if !ok {
+		return
+	}
+
+	var input AssociateProfileInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if input.PhoneNumber != nil {
+		profile.PhoneNumber = input.PhoneNumber
+		updates["phone_number"] = *input.PhoneNumber
+	}
+	if input.Bio != nil {
+		profile.Bio = input.Bio
+		updates["bio"] = *input.Bio
+	}
+	if input.PortfolioURL != nil {
+		profile.PortfolioURL = input.PortfolioURL
+		updates["portfolio_url"] = *input.PortfolioURL
+	}
+	if input.LinkedInURL != nil {
+		profile.LinkedInURL = input.LinkedInURL
+		updates["linkedin_url"] = *input.LinkedInURL
+	}
+	if input.WebsiteURL != nil {
+		profile.WebsiteURL = input.WebsiteURL
+		updates["website_url"] = *input.WebsiteURL
+	}
+	if input.Skills != nil {
+		profile.Skills = normalizeSkills(input.Skills)
+		updates["skills"] = profile.Skills
+	}
+
+	if len(updates) > 0 {
+		if err := config.DB.WithContext(c).Model(profile).Updates(updates).Error; err != nil {
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to update profile")
+			return
+		}
+	}
+
+	// phone and name also live on the freelancer's associate record
+	associateUpdates := map[string]interface{}{}
+	if input.Name != nil {
+		associate.Name = strings.TrimSpace(*input.Name)
+		associateUpdates["name"] = associate.Name
+	}
+	if input.PhoneNumber != nil {
+		associate.Phone = *input.PhoneNumber
+		associateUpdates["phone"] = associate.Phone
+	}
+	if len(associateUpdates) > 0 {
+		if err := config.DB.WithContext(c).Model(associate).Updates(associateUpdates).Error; err != nil {
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to update associate")
+			return
+		}
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"message": "Profile updated successfully",
+		"profile": associateProfileResponse(associate, profile),
+	})
+}
+
+func ChangeAssociatePassword(c *gin.Context) {
+	_, profile, ok := loadSelfAssociate(c)
+	if !ok {
+		return
+	}
+
+	var input ChangePasswordInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(profile.PasswordHash), []byte(input.CurrentPassword)); err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "current password is incorrect")
+		return
+	}
+	if input.CurrentPassword == input.NewPassword {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "new password must differ from the current one")
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(input.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to process password")
+		return
+	}
+
+	if err := config.DB.WithContext(c).Model(profile).Update("password_hash", string(hash)).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to change password")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"message": "Password changed successfully",
+	})
+}
+
+// UploadAssociatePhoto replaces the associate's profile photo (multipart field "photo")
+func UploadAssociatePhoto(c *gin.Context) {
+	associate, profile, ok := loadSelfAssociate(c)
+	if !ok {
+		return
+	}
+
+	file, err := c.FormFile("photo")
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "photo is required")
+		return
+	}
+
+	path, _, err := utils.SaveUpload(file, filepath.Join("profiles", associate.ID.String()), profilePhotoTypes)
+	if err != nil {
+		if utils.IsUploadRejected(err) {
+			utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to store photo")
+		return
+	}
+
+	previous := profile.ProfilePhotoPath
+	url := "/api/v1/associate/photo/" + associate.ID.String()
+	if err := config.DB.WithContext(c).Model(profile).Updates(map[string]interface{}{
+		"profile_photo_path": path,
+		"profile_photo_url":  url,
+	}).Error; err != nil {
+		utils.RemoveUpload(path)
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to update photo")
+		return
+	}
+	if previous != "" {
+		utils.RemoveUpload(previous)
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"message":           "Photo uploaded successfully",
+		"profile_photo_url": url,
+	})
+}
+
+// GetAssociatePhoto serves an uploaded profile photo; it is public so it can be used in <img> tags
+func GetAssociatePhoto(c *gin.Context) {
+	var profile models.AssociateProfile
+	if err := config.DB.First(&profile, "associate_id = ?", c.Param("id")).Error; err != nil || profile.ProfilePhotoPath == "" {
+		utils.SendErrorResponse(c, http.StatusNotFound, "photo not found")
+		return
+	}
+
+	utils.ServeUpload(c, profile.ProfilePhotoPath, "", "")
+}
+
+// GetMyContracts lists the contracts the associate has been invited to, with where they stand on each
+func GetMyContracts(c *gin.Context) {
+	associate, _, ok := loadSelfAssociate(c)
+	if !ok {
+		return
+	}
+
+	var invites []models.Invite
+	if err := config.DB.
+		Preload("Contract").
+		Preload("Task").
+		Preload("Project").
+		Where("associate_id = ?", associate.ID).
+		Order("created_at DESC").
+		Find(&invites).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch contracts")
+		return
+	}
+
+	var signatures []models.ContractSignature
+	if err := config.DB.Where("associate_id = ?", associate.ID).Order("signed_at DESC").Find(&signatures).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch signatures")
+		return
+	}
+	signed := make(map[string][]models.ContractSignature)
+	for _, s := range signatures {
+		signed[s.ContractID.String()] = append(signed[s.ContractID.String()], s)
+	}
+
+	// one entry per contract, using its latest invite
+	seen := make(map[string]bool)
+	contracts := make([]gin.H, 0, len(invites))
+	for _, inv := range invites {
+		key := inv.ContractID.String()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		contracts = append(contracts, gin.H{
+			"contract":          inv.Contract,
+			"task_title":        inv.Task.Title,
+			"project_name":      inv.Project.Name,
+			"invite_id":         inv.ID,
+			"invite_status":     inv.Status,
+			"accepted":          inv.Status == "accepted",
+			"current_version":   inv.Contract.CurrentVersion,
+			"accepted_version":  inv.Contract.AcceptedVersion,
+			"amendment_pending": inv.Contract.AmendmentPending,
+			"signatures":        signed[key],
+		})
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, contracts)
+}
+
+// GetMyInvites lists the associate's invites, optionally filtered by ?status=, with links for pending ones
+func GetMyInvites(c *gin.Context) {
+	associate, _, ok := loadSelfAssociate(c)
+	if !ok {
+		return
+	}
+
+	if _, err := models.ExpireStaleInvites(config.DB.WithContext(c)); err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to refresh invites")
+		return
+	}
+
+	query := config.DB.
+		Preload("Task").
+		Preload("Project").
+		Where("associate_id = ?", associate.ID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var invites []models.Invite
+	if err := query.Order("created_at DESC").Find(&invites).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch invites")
+		return
+	}
+
+	// pending invites carry their response link; it is only ever handed to the invited associate
+	type inviteWithLink struct {
+		models.Invite
+		Token string `json:"token,omitempty"`
+	}
+	result := make([]inviteWithLink, 0, len(invites))
+	for _, invite := range invites {
+		item := inviteWithLink{Invite: invite}
+		if invite.Status == "pending" && invite.TokenID != "" {
+			token, err := utils.IssueInviteToken(invite)
+			if err != nil {
+				utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to generate invite link")
+				return
+			}
+			item.Token = token
+		}
+		result = append(result, item)
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, result)
+}
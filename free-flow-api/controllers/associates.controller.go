@@ -38,7 +38,7 @@ func NewAssociate(c *gin.Context) {
 	input.Email = strings.ToLower(strings.TrimSpace(input.Email))
 
 	// Create associate and profile in one transaction
-	err := config.DB.Transaction(func(tx *gorm.DB) error {
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
 		// Create the associate
 		associate := models.Associate{
 			Name:   input.Name,
@@ -163,7 +163,7 @@ func UpdateAssociate(c *gin.Context) {
 
 	updates.Email = strings.ToLower(strings.TrimSpace(updates.Email))
 
-	if err := config.DB.Model(&associate).Updates(updates).Error; err != nil {
+	if err := config.DB.WithContext(c).Model(&associate).Updates(updates).Error; err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to update entity")
 		return
 	}
@@ -198,7 +198,7 @@ func DeleteAssociate(c *gin.Context) {
 	}
 
 	// 4. Soft delete (sets DeletedAt, doesn’t remove row)
-	if err := config.DB.Delete(&associate).Error; err != nil {
+	if err := config.DB.WithContext(c).Delete(&associate).Error; err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to delete entity")
 		return
 	}
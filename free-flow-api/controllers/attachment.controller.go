@@ -0,0 +1,268 @@
+package controllers
+
+import (
+	"free-flow-api/config"
+	"free-flow-api/models"
+	"free-flow-api/utils"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// how long a signed download link stays valid
+const attachmentLinkTTL = 15 * time.Minute
+
+var attachmentTypes = []string{
+	"image/jpeg", "image/png", "image/gif", "image/webp",
+	"application/pdf",
+	"application/zip", // also docx, xlsx, pptx and most design bundles
+	"application/x-gzip",
+	"text/plain", // also csv and markdown
+	"video/mp4",
+}
+
+// maxAttachmentSize is ATTACHMENT_MAX_MB megabytes, 25 by default
+func maxAttachmentSize() int64 {
+	mb, err := strconv.Atoi(config.GetEnvOrDefault("ATTACHMENT_MAX_MB", "25"))
+	if err != nil || mb <= 0 {
+		mb = 25
+	}
+	return int64(mb) << 20
+}
+
+// loadAttachmentTarget resolves who may see files on a record, reusing the comment rules where they overlap
+func loadAttachmentTarget(tx *gorm.DB, targetType string, targetID uuid.UUID) (*commentTarget, error) {
+	switch targetType {
+	case models.AttachmentTask, models.AttachmentMilestone, models.AttachmentContract:
+		return loadCommentTarget(tx, targetType, targetID)
+	case models.AttachmentExpense:
+		var expense models.Expense
+		if err := tx.First(&expense, "id = ?", targetID).Error; err != nil {
+			return nil, errCommentTarget
+		}
+		return &commentTarget{
+			Type:       targetType,
+			ID:         targetID,
+			Title:      expense.Description,
+			ProjectID:  expense.ProjectID,
+			OwnerID:    expense.UserID,
+			Associates: map[uuid.UUID]bool{},
+		}, nil
+	case models.AttachmentDeliverable:
+		var deliverable models.Deliverable
+		if err := tx.First(&deliverable, "id = ?", targetID).Error; err != nil {
+			return nil, errCommentTarget
+		}
+		target, err := loadCommentTarget(tx, models.CommentTargetTask, deliverable.TaskID)
+		if err != nil {
+			return nil, err
+		}
+		target.Type, target.ID = targetType, targetID
+		target.ClientAllowed = false
+		return target, nil
+	case models.AttachmentProfile:
+		var associate models.Associate
+		if err := tx.First(&associate, "id = ?", targetID).Error; err != nil {
+			return nil, errCommentTarget
+		}
+		return &commentTarget{
+			Type:       targetType,
+			ID:         targetID,
+			Title:      associate.Name,
+			OwnerID:    associate.UserID,
+			Associates: map[uuid.UUID]bool{associate.ID: true},
+		}, nil
+	}
+	return nil, errCommentTarget
+}
+
+// loadVisibleAttachment fetches an attachment on a record the caller can access
+func loadVisibleAttachment(c *gin.Context) (*models.Attachment, *commentTarget, *commentPrincipal, bool) {
+	principalType, principalID, ok := timePrincipal(c)
+	if !ok {
+		return nil, nil, nil, false
+	}
+	p := &commentPrincipal{Type: principalType, ID: principalID}
+
+	var attachment models.Attachment
+	if err := config.DB.First(&attachment, "id = ?", c.Param("id")).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "attachment not found")
+		return nil, nil, nil, false
+	}
+	target, err := loadAttachmentTarget(config.DB, attachment.TargetType, attachment.TargetID)
+	if err != nil || !target.canAccess(p) {
+		utils.SendErrorResponse(c, http.StatusNotFound, "attachment not found")
+		return nil, nil, nil, false
+	}
+	return &attachment, target, p, true
+}
+
+// UploadAttachment stores a file (multipart field "file") against target_type and target_id
+func UploadAttachment(c *gin.Context) {
+	principalType, principalID, ok := timePrincipal(c)
+	if !ok {
+		return
+	}
+	p := &commentPrincipal{Type: principalType, ID: principalID}
+
+	targetType := c.PostForm("target_type")
+	targetID, err := uuid.Parse(c.PostForm("target_id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "invalid target_id")
+		return
+	}
+	target, err := loadAttachmentTarget(config.DB, targetType, targetID)
+	if err != nil || !target.canAccess(p) {
+		utils.SendErrorResponse(c, http.StatusNotFound, "target not found")
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "file is required")
+		return
+	}
+
+	stored, err := utils.StoreUpload(file, path.Join("attachments", targetType, targetID.String()), attachmentTypes, maxAttachmentSize())
+	if err != nil {
+		if utils.IsUploadRejected(err) {
+			utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to store file")
+		return
+	}
+
+	attachment := models.Attachment{
+		TargetType:   targetType,
+		TargetID:     targetID,
+		UploaderType: principalType,
+		UploaderID:   principalID,
+		FileName:     filepath.Base(file.Filename),
+		ContentType:  stored.ContentType,
+		Size:         stored.Size,
+		Checksum:     stored.Checksum,
+		ScanStatus:   stored.ScanStatus,
+		Description:  c.PostForm("description"),
+		StorageKey:   stored.Key,
+	}
+	if target.ProjectID != uuid.Nil {
+		attachment.ProjectID = &target.ProjectID
+	}
+	if err := config.DB.WithContext(c).Create(&attachment).Error; err != nil {
+		utils.RemoveUpload(stored.Key)
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to save attachment")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusCreated, gin.H{
+		"message":    "File uploaded successfully",
+		"attachment": attachment,
+	})
+}
+
+// GetAttachments lists the files on ?target_type=&target_id=
+func GetAttachments(c *gin.Context) {
+	principalType, principalID, ok := timePrincipal(c)
+	if !ok {
+		return
+	}
+	p := &commentPrincipal{Type: principalType, ID: principalID}
+
+	targetType := c.Query("target_type")
+	targetID, err := uuid.Parse(c.Query("target_id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "invalid target_id")
+		return
+	}
+	target, err := loadAttachmentTarget(config.DB, targetType, targetID)
+	if err != nil || !target.canAccess(p) {
+		utils.SendErrorResponse(c, http.StatusNotFound, "target not found")
+		return
+	}
+
+	var attachments []models.Attachment
+	if err := config.DB.
+		Where("target_type = ? AND target_id = ?", targetType, targetID).
+		Order("created_at DESC").
+		Find(&attachments).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch attachments")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, attachments)
+}
+
+// GetAttachmentURL issues a short-lived signed download link
+func GetAttachmentURL(c *gin.Context) {
+	attachment, _, _, ok := loadVisibleAttachment(c)
+	if !ok {
+		return
+	}
+
+	expiresAt := time.Now().Add(attachmentLinkTTL)
+	token, err := config.GenerateFileToken(attachment.ID.String(), expiresAt)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to sign download link")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"url":        "/api/v1/files/" + token,
+		"expires_at": expiresAt,
+	})
+}
+
+// DownloadAttachment serves a signed link: object stores get a presigned redirect, local files are streamed
+func DownloadAttachment(c *gin.Context) {
+	claims, err := utils.VerifyFileToken(c.Param("token"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid or expired download link")
+		return
+	}
+
+	var attachment models.Attachment
+	if err := config.DB.First(&attachment, "id = ?", claims.AttachmentID).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "attachment not found")
+		return
+	}
+
+	if url, err := utils.FileStorage().PresignGet(attachment.StorageKey, time.Until(claims.ExpiresAt.Time)); err == nil && url != "" {
+		c.Redirect(http.StatusFound, url)
+		return
+	}
+
+	utils.ServeUpload(c, attachment.StorageKey, attachment.ContentType, attachment.FileName)
+}
+
+// DeleteAttachment removes a file; the uploader or the freelancer who owns the record may delete it
+func DeleteAttachment(c *gin.Context) {
+	attachment, target, p, ok := loadVisibleAttachment(c)
+	if !ok {
+		return
+	}
+
+	isUploader := attachment.UploaderType == p.Type && attachment.UploaderID == p.ID
+	isOwner := p.Type == models.PrincipalUser && p.ID == target.OwnerID
+	if !isUploader && !isOwner {
+		utils.SendErrorResponse(c, http.StatusForbidden, "only the uploader or the owner can delete this file")
+		return
+	}
+
+	if err := config.DB.WithContext(c).Delete(attachment).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to delete attachment")
+		return
+	}
+	if err := utils.RemoveUpload(attachment.StorageKey); err != nil {
+		// the record is gone; a stray object is harmless
+		c.Error(err)
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{"message": "attachment deleted"})
+}
@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"free-flow-api/config"
+	"free-flow-api/models"
+	"free-flow-api/utils"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// auditFilters narrows an audit query by ?action=, ?actor_type=, ?actor_id=, ?record_type= and ?since=/?until= (RFC 3339)
+func auditFilters(c *gin.Context, query *gorm.DB) (*gorm.DB, bool) {
+	for param, column := range map[string]string{
+		"action":      "action",
+		"actor_type":  "actor_type",
+		"record_type": "record_type",
+	} {
+		if value := c.Query(param); value != "" {
+			query = query.Where(column+" = ?", value)
+		}
+	}
+	if actorID := c.Query("actor_id"); actorID != "" {
+		id, err := uuid.Parse(actorID)
+		if err != nil {
+			utils.SendErrorResponse(c, http.StatusBadRequest, "invalid actor_id")
+			return nil, false
+		}
+		query = query.Where("actor_id = ?", id)
+	}
+	for param, op := range map[string]string{"since": ">=", "until": "<"} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		at, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			utils.SendErrorResponse(c, http.StatusBadRequest, "invalid "+param+", expected RFC 3339")
+			return nil, false
+		}
+		query = query.Where("created_at "+op+" ?", at)
+	}
+	return query, true
+}
+
+func sendAuditPage(c *gin.Context, query *gorm.DB) {
+	limit, offset := pageParams(c)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch activity")
+		return
+	}
+
+	var entries []models.AuditLog
+	if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&entries).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch activity")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"entries": entries,
+		"total":   total,
+	})
+}
+
+// GetProjectActivity is the project's activity feed: every recorded change to the project and the
+// records under it, newest first
+func GetProjectActivity(c *gin.Context) {
+	userID := c.GetString("userID")
+	if !utils.IsAuthenticated(userID) {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid user token")
+		c.Abort()
+		return
+	}
+
+	project, ok := loadBillingProject(c, userID, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	query, ok := auditFilters(c, config.DB.Model(&models.AuditLog{}).Where("project_id = ?", project.ID))
+	if !ok {
+		return
+	}
+	sendAuditPage(c, query)
+}
+
+// GetRecordHistory lists the changes to one record, e.g. /audit/tasks/:id. Freelancers see the
+// history of records on their projects and of changes they made themselves.
+func GetRecordHistory(c *gin.Context) {
+	userID := c.GetString("userID")
+	if !utils.IsAuthenticated(userID) {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid user token")
+		c.Abort()
+		return
+	}
+
+	recordID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "invalid record id")
+		return
+	}
+
+	// deleted projects keep their history
+	ownProjects := config.DB.Unscoped().Model(&models.Project{}).Select("id").Where("user_id = ?", userID)
+	query := config.DB.Model(&models.AuditLog{}).
+		Where("record_type = ? AND record_id = ?", c.Param("recordType"), recordID).
+		Where("project_id IN (?) OR actor_id = ?", ownProjects, userID)
+	query, ok := auditFilters(c, query)
+	if !ok {
+		return
+	}
+	sendAuditPage(c, query)
+}
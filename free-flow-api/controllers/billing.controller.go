@@ -0,0 +1,571 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"free-flow-api/config"
+	"free-flow-api/models"
+	"free-flow-api/utils"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BillingRateInput struct {
+	ProjectID  *uuid.UUID `json:"project_id"`
+	OwnerType  *string    `json:"owner_type" binding:"omitempty,oneof=user associate"`
+	OwnerID    *uuid.UUID `json:"owner_id"`
+	HourlyRate float64    `json:"hourly_rate" binding:"required,gt=0"`
+}
+
+// BillingInvoiceInput drafts an invoice from unbilled work; the id lists narrow it to a subset
+type BillingInvoiceInput struct {
+	ProjectID       uuid.UUID   `json:"project_id" binding:"required"`
+	From            string      `json:"from"`
+	To              string      `json:"to"`
+	GroupBy         string      `json:"group_by" binding:"omitempty,oneof=task person entry"`
+	IncludeExpenses *bool       `json:"include_expenses"`
+	TimeEntryIDs    []uuid.UUID `json:"time_entry_ids"`
+	ExpenseIDs      []uuid.UUID `json:"expense_ids"`
+	DueDate         *time.Time  `json:"due_date"`
+	Description     string      `json:"description"`
+	Notes           string      `json:"notes"`
+}
+
+type BillableTime struct {
+	EntryID    uuid.UUID  `json:"entry_id"`
+	TaskID     uuid.UUID  `json:"task_id"`
+	TaskTitle  string     `json:"task_title"`
+	OwnerType  string     `json:"owner_type"`
+	OwnerID    uuid.UUID  `json:"owner_id"`
+	OwnerName  string     `json:"owner_name"`
+	StartedAt  time.Time  `json:"started_at"`
+	EndedAt    *time.Time `json:"ended_at"`
+	Hours      float64    `json:"hours"`
+	Notes      string     `json:"notes"`
+	HourlyRate *float64   `json:"hourly_rate"` // nil when no rate applies
+	Amount     float64    `json:"amount"`
+}
+
+type UnbilledWork struct {
+	Time             []BillableTime   `json:"time"`
+	Expenses         []models.Expense `json:"expenses"`
+	SkippedExpenses  []models.Expense `json:"skipped_expenses"` // billed in another currency
+	TotalHours       float64          `json:"total_hours"`
+	TimeAmount       float64          `json:"time_amount"`
+	ExpenseAmount    float64          `json:"expense_amount"`
+	Total            float64          `json:"total"`
+	Currency         string           `json:"currency"`
+	MissingRateCount int              `json:"missing_rate_count"`
+}
+
+var errNothingToBill = errors.New("there is no unbilled work to invoice")
+var errMissingRates = errors.New("some time entries have no hourly rate; add a rate first")
+var errAlreadyBilled = errors.New("some of the selected work was billed in the meantime")
+
+func roundMoney(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// billingRange parses optional YYYY-MM-DD bounds; to is inclusive
+func billingRange(from, to string) (*time.Time, *time.Time, error) {
+	var start, end *time.Time
+	if from != "" {
+		t, err := time.Parse("2006-01-02", from)
+		if err != nil {
+			return nil, nil, errors.New("invalid from date")
+		}
+		start = &t
+	}
+	if to != "" {
+		t, err := time.Parse("2006-01-02", to)
+		if err != nil {
+			return nil, nil, errors.New("invalid to date")
+		}
+		t = t.AddDate(0, 0, 1)
+		end = &t
+	}
+	return start, end, nil
+}
+
+// ownerNames maps time-entry owners to display names
+func ownerNames(tx *gorm.DB, entries []models.TimeEntry) map[uuid.UUID]string {
+	var userIDs, associateIDs []uuid.UUID
+	for _, e := range entries {
+		if e.OwnerType == models.PrincipalAssociate {
+			associateIDs = append(associateIDs, e.OwnerID)
+		} else {
+			userIDs = append(userIDs, e.OwnerID)
+		}
+	}
+
+	names := make(map[uuid.UUID]string)
+	if len(userIDs) > 0 {
+		var users []models.User
+		tx.Where("id IN ?", userIDs).Find(&users)
+		for _, u := range users {
+			names[u.ID] = strings.TrimSpace(u.FirstName + " " + u.LastName)
+		}
+	}
+	if len(associateIDs) > 0 {
+		var associates []models.Associate
+		tx.Where("id IN ?", associateIDs).Find(&associates)
+		for _, a := range associates {
+			names[a.ID] = a.Name
+		}
+	}
+	return names
+}
+
+// collectUnbilledWork gathers finished, billable, uninvoiced time and expenses on a project.
+// When lock is set the source rows are locked for the rest of the transaction.
+func collectUnbilledWork(tx *gorm.DB, project models.Project, start, end *time.Time, entryIDs, expenseIDs []uuid.UUID, includeExpenses, lock bool) (*UnbilledWork, []models.TimeEntry, error) {
+	timeQuery := tx.Preload("Task").
+		Where("project_id = ? AND billable = ? AND ended_at IS NOT NULL AND invoice_id IS NULL", project.ID, true)
+	if start != nil {
+		timeQuery = timeQuery.Where("started_at >= ?", *start)
+	}
+	if end != nil {
+		timeQuery = timeQuery.Where("started_at < ?", *end)
+	}
+	if len(entryIDs) > 0 {
+		timeQuery = timeQuery.Where("id IN ?", entryIDs)
+	}
+	if lock {
+		timeQuery = timeQuery.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+
+	var entries []models.TimeEntry
+	if err := timeQuery.Order("started_at ASC").Find(&entries).Error; err != nil {
+		return nil, nil, err
+	}
+
+	var rates []models.BillingRate
+	if err := tx.Where("user_id = ?", project.UserID).Find(&rates).Error; err != nil {
+		return nil, nil, err
+	}
+	names := ownerNames(tx, entries)
+
+	work := &UnbilledWork{
+		Time:            []BillableTime{},
+		Expenses:        []models.Expense{},
+		SkippedExpenses: []models.Expense{},
+		Currency:        project.Currency,
+	}
+	for _, e := range entries {
+		item := BillableTime{
+			EntryID:   e.ID,
+			TaskID:    e.TaskID,
+			OwnerType: e.OwnerType,
+			OwnerID:   e.OwnerID,
+			OwnerName: names[e.OwnerID],
+			StartedAt: e.StartedAt,
+			EndedAt:   e.EndedAt,
+			Hours:     math.Round(e.Hours*100) / 100,
+			Notes:     e.Notes,
+		}
+		if e.Task != nil {
+			item.TaskTitle = e.Task.Title
+		}
+		if rate := models.ResolveRate(rates, project.ID, e.OwnerType, e.OwnerID); rate != nil {
+			r := rate.HourlyRate
+			item.HourlyRate = &r
+			item.Amount = roundMoney(item.Hours * r)
+		} else {
+			work.MissingRateCount++
+		}
+		work.TotalHours += item.Hours
+		work.TimeAmount += item.Amount
+		work.Time = append(work.Time, item)
+	}
+
+	if includeExpenses {
+		expenseQuery := tx.Where("project_id = ? AND billable = ? AND invoice_id IS NULL", project.ID, true)
+		if start != nil {
+			expenseQuery = expenseQuery.Where("date >= ?", *start)
+		}
+		if end != nil {
+			expenseQuery = expenseQuery.Where("date < ?", *end)
+		}
+		if len(expenseIDs) > 0 {
+			expenseQuery = expenseQuery.Where("id IN ?", expenseIDs)
+		}
+		if lock {
+			expenseQuery = expenseQuery.Clauses(clause.Locking{Strength: "UPDATE"})
+		}
+
+		var expenses []models.Expense
+		if err := expenseQuery.Order("date ASC").Find(&expenses).Error; err != nil {
+			return nil, nil, err
+		}
+		for _, x := range expenses {
+			if x.Currency != "" && project.Currency != "" && x.Currency != project.Currency {
+				work.SkippedExpenses = append(work.SkippedExpenses, x)
+				continue
+			}
+			work.ExpenseAmount += x.Amount
+			work.Expenses = append(work.Expenses, x)
+		}
+	}
+
+	work.TotalHours = math.Round(work.TotalHours*100) / 100
+	work.TimeAmount = roundMoney(work.TimeAmount)
+	work.ExpenseAmount = roundMoney(work.ExpenseAmount)
+	work.Total = roundMoney(work.TimeAmount + work.ExpenseAmount)
+	return work, entries, nil
+}
+
+// billingLineItems groups billable time into invoice lines, followed by one line per expense
+func billingLineItems(work *UnbilledWork, groupBy string) []models.InvoiceLineItem {
+	type group struct {
+		description string
+		hours       float64
+		rate        float64
+	}
+	groups := map[string]*group{}
+	order := []string{}
+	for _, t := range work.Time {
+		rate := *t.HourlyRate
+		var key, description string
+		switch groupBy {
+		case "person":
+			key = fmt.Sprintf("%s|%v", t.OwnerID, rate)
+			description = "Time: " + t.OwnerName
+		case "entry":
+			key = t.EntryID.String()
+			description = fmt.Sprintf("%s (%s)", t.TaskTitle, t.StartedAt.Format("2006-01-02"))
+			if t.Notes != "" {
+				description += " - " + t.Notes
+			}
+		default:
+			key = fmt.Sprintf("%s|%v", t.TaskID, rate)
+			description = "Time: " + t.TaskTitle
+		}
+		g, ok := groups[key]
+		if !ok {
+			g = &group{description: description, rate: rate}
+			groups[key] = g
+			order = append(order, key)
+		}
+		g.hours += t.Hours
+	}
+
+	items := make([]models.InvoiceLineItem, 0, len(order)+len(work.Expenses))
+	for _, key := range order {
+		g := groups[key]
+		hours := math.Round(g.hours*100) / 100
+		items = append(items, models.InvoiceLineItem{
+			Kind:        "time",
+			Description: g.description,
+			Quantity:    hours,
+			UnitPrice:   g.rate,
+			Amount:      roundMoney(hours * g.rate),
+		})
+	}
+
+	expenses := append([]models.Expense{}, work.Expenses...)
+	sort.SliceStable(expenses, func(i, j int) bool { return expenses[i].Date.Before(expenses[j].Date) })
+	for _, x := range expenses {
+		description := "Expense: " + x.Description
+		if x.Vendor != "" {
+			description += " (" + x.Vendor + ")"
+		}
+		items = append(items, models.InvoiceLineItem{
+			Kind:        "expense",
+			Description: description,
+			Quantity:    1,
+			UnitPrice:   x.Amount,
+			Amount:      x.Amount,
+		})
+	}
+	return items
+}
+
+// loadBillingProject loads a project owned by the freelancer
+func loadBillingProject(c *gin.Context, userID string, projectID string) (*models.Project, bool) {
+	var project models.Project
+	if err := config.DB.First(&project, "id = ? AND user_id = ?", projectID, userID).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "project not found")
+		return nil, false
+	}
+	return &project, true
+}
+
+func GetBillingRates(c *gin.Context) {
+	userID := c.GetString("userID")
+	if !utils.IsAuthenticated(userID) {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid user token")
+		c.Abort()
+		return
+	}
+
+	query := config.DB.Where("user_id = ?", userID)
+	if projectID := c.Query("project_id"); projectID != "" {
+		query = query.Where("project_id = ? OR project_id IS NULL", projectID)
+	}
+
+	var rates []models.BillingRate
+	if err := query.Order("created_at ASC").Find(&rates).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch rates")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, rates)
+}
+
+// SetBillingRate creates or replaces the rate for a project/person combination
+func SetBillingRate(c *gin.Context) {
+	userID := c.GetString("userID")
+	if !utils.IsAuthenticated(userID) {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid user token")
+		c.Abort()
+		return
+	}
+
+	var input BillingRateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if (input.OwnerID == nil) != (input.OwnerType == nil) {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "owner_type and owner_id go together")
+		return
+	}
+
+	if input.ProjectID != nil {
+		if _, ok := loadBillingProject(c, userID, input.ProjectID.String()); !ok {
+			return
+		}
+	}
+	if input.OwnerID != nil {
+		if *input.OwnerType == models.PrincipalUser && input.OwnerID.String() != userID {
+			utils.SendErrorResponse(c, http.StatusBadRequest, "user rates can only be set for yourself")
+			return
+		}
+		if *input.OwnerType == models.PrincipalAssociate {
+			var count int64
+			config.DB.Model(&models.Associate{}).Where("id = ? AND user_id = ?", *input.OwnerID, userID).Count(&count)
+			if count == 0 {
+				utils.SendErrorResponse(c, http.StatusNotFound, "associate not found")
+				return
+			}
+		}
+	}
+
+	query := config.DB.Where("user_id = ?", userID)
+	if input.ProjectID != nil {
+		query = query.Where("project_id = ?", *input.ProjectID)
+	} else {
+		query = query.Where("project_id IS NULL")
+	}
+	if input.OwnerID != nil {
+		query = query.Where("owner_type = ? AND owner_id = ?", *input.OwnerType, *input.OwnerID)
+	} else {
+		query = query.Where("owner_id IS NULL")
+	}
+
+	var rate models.BillingRate
+	err := query.First(&rate).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch rate")
+		return
+	}
+
+	rate.UserID = uuid.MustParse(userID)
+	rate.ProjectID = input.ProjectID
+	rate.OwnerType = input.OwnerType
+	rate.OwnerID = input.OwnerID
+	rate.HourlyRate = input.HourlyRate
+	if err := config.DB.WithContext(c).Save(&rate).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to save rate")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"message": "Rate saved successfully",
+		"rate":    rate,
+	})
+}
+
+func DeleteBillingRate(c *gin.Context) {
+	userID := c.GetString("userID")
+	if !utils.IsAuthenticated(userID) {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid user token")
+		c.Abort()
+		return
+	}
+
+	result := config.DB.WithContext(c).Where("id = ? AND user_id = ?", c.Param("id"), userID).Delete(&models.BillingRate{})
+	if result.Error != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to delete rate")
+		return
+	}
+	if result.RowsAffected == 0 {
+		utils.SendErrorResponse(c, http.StatusNotFound, "rate not found")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{"message": "rate deleted"})
+}
+
+// GetUnbilledWork lists unbilled time and billable expenses for ?project_id= within ?from= and ?to=
+func GetUnbilledWork(c *gin.Context) {
+	userID := c.GetString("userID")
+	if !utils.IsAuthenticated(userID) {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid user token")
+		c.Abort()
+		return
+	}
+
+	project, ok := loadBillingProject(c, userID, c.Query("project_id"))
+	if !ok {
+		return
+	}
+
+	start, end, err := billingRange(c.Query("from"), c.Query("to"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	work, _, err := collectUnbilledWork(config.DB, *project, start, end, nil, nil, true, false)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch unbilled work")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, work)
+}
+
+// GenerateBillingInvoice drafts an invoice from unbilled work and marks that work as billed
+func GenerateBillingInvoice(c *gin.Context) {
+	userID := c.GetString("userID")
+	if !utils.IsAuthenticated(userID) {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid user token")
+		c.Abort()
+		return
+	}
+
+	var input BillingInvoiceInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	project, ok := loadBillingProject(c, userID, input.ProjectID.String())
+	if !ok {
+		return
+	}
+
+	start, end, err := billingRange(input.From, input.To)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	includeExpenses := input.IncludeExpenses == nil || *input.IncludeExpenses
+
+	var invoice models.Invoice
+	var work *UnbilledWork
+	err = config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		var entries []models.TimeEntry
+		var err error
+		work, entries, err = collectUnbilledWork(tx, *project, start, end, input.TimeEntryIDs, input.ExpenseIDs, includeExpenses, true)
+		if err != nil {
+			return err
+		}
+		if len(work.Time) == 0 && len(work.Expenses) == 0 {
+			return errNothingToBill
+		}
+		if work.MissingRateCount > 0 {
+			return errMissingRates
+		}
+		if len(input.TimeEntryIDs) > 0 && len(entries) != len(input.TimeEntryIDs) {
+			return errAlreadyBilled
+		}
+		if len(input.ExpenseIDs) > 0 && len(work.Expenses) != len(input.ExpenseIDs) {
+			return errAlreadyBilled
+		}
+
+		now := time.Now()
+		dueDate := now.AddDate(0, 0, 14)
+		if input.DueDate != nil {
+			dueDate = *input.DueDate
+		}
+		description := input.Description
+		if description == "" {
+			description = "Hourly work: " + project.Name
+		}
+
+		invoice = models.Invoice{
+			ProjectID:     project.ID,
+			Amount:        work.Total,
+			Currency:      project.Currency,
+			Status:        "draft",
+			IssueDate:     now,
+			DueDate:       dueDate,
+			Description:   description,
+			Notes:         input.Notes,
+			InvoiceNumber: utils.GenerateInvoiceNumber(),
+			UserID:        project.UserID,
+			LineItems:     billingLineItems(work, input.GroupBy),
+		}
+		if err := tx.Create(&invoice).Error; err != nil {
+			return err
+		}
+
+		// mark the sources billed; the invoice_id guard stops a second invoice picking them up
+		billed := map[string]interface{}{"invoice_id": invoice.ID, "billed_at": now}
+		if len(entries) > 0 {
+			ids := make([]uuid.UUID, 0, len(entries))
+			for _, e := range entries {
+				ids = append(ids, e.ID)
+			}
+			result := tx.Model(&models.TimeEntry{}).Where("id IN ? AND invoice_id IS NULL", ids).UpdateColumns(billed)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected != int64(len(ids)) {
+				return errAlreadyBilled
+			}
+		}
+		if len(work.Expenses) > 0 {
+			ids := make([]uuid.UUID, 0, len(work.Expenses))
+			for _, x := range work.Expenses {
+				ids = append(ids, x.ID)
+			}
+			result := tx.Model(&models.Expense{}).Where("id IN ? AND invoice_id IS NULL", ids).UpdateColumns(billed)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected != int64(len(ids)) {
+				return errAlreadyBilled
+			}
+		}
+
+		return ApplyProjectDeposits(tx, &invoice)
+	})
+	switch {
+	case errors.Is(err, errNothingToBill), errors.Is(err, errMissingRates), errors.Is(err, errAlreadyBilled):
+		utils.SendErrorResponse(c, http.StatusConflict, err.Error())
+		return
+	case err != nil:
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to generate invoice")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusCreated, gin.H{
+		"message":          "Invoice drafted successfully",
+		"invoice":          invoice,
+		"billed_hours":     work.TotalHours,
+		"time_entries":     len(work.Time),
+		"expenses":         len(work.Expenses),
+		"skipped_expenses": work.SkippedExpenses,
+	})
+}
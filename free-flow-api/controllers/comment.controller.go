@@ -0,0 +1,669 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"free-flow-api/config"
+	"free-flow-api/models"
+	"free-flow-api/utils"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type MentionInput struct {
+	Type string    `json:"type" binding:"required,oneof=user associate"`
+	ID   uuid.UUID `json:"id" binding:"required"`
+}
+
+type CommentInput struct {
+	TargetType string         `json:"target_type" binding:"required,oneof=task milestone contract invoice"`
+	TargetID   uuid.UUID      `json:"target_id" binding:"required"`
+	ParentID   *uuid.UUID     `json:"parent_id"`
+	Body       string         `json:"body" binding:"required,max=5000"`
+	Visibility string         `json:"visibility" binding:"omitempty,oneof=internal associate client"`
+	Mentions   []MentionInput `json:"mentions" binding:"dive"`
+}
+
+type CommentUpdateInput struct {
+	Body       *string        `json:"body" binding:"omitempty,max=5000"`
+	Visibility *string        `json:"visibility" binding:"omitempty,oneof=internal associate client"`
+	Mentions   []MentionInput `json:"mentions" binding:"dive"`
+}
+
+type ClientLinkInput struct {
+	Days int `json:"days" binding:"omitempty,min=1,max=365"`
+}
+
+// commentPrincipal is whoever is reading or writing comments
+type commentPrincipal struct {
+	Type      string
+	ID        uuid.UUID
+	Name      string
+	ProjectID uuid.UUID // clients are limited to the project their link was issued for
+}
+
+// commentTarget is a record being discussed and who may see its threads
+type commentTarget struct {
+	Type          string
+	ID            uuid.UUID
+	Title         string
+	ProjectID     uuid.UUID
+	OwnerID       uuid.UUID
+	EntityID      *uuid.UUID // the project's client
+	Associates    map[uuid.UUID]bool
+	ClientAllowed bool
+}
+
+var errCommentTarget = errors.New("comment target not found")
+
+// resolveCommentPrincipal works for both the token-authenticated routes and the client link routes
+func resolveCommentPrincipal(c *gin.Context) (*commentPrincipal, bool) {
+	if entityID := c.GetString("entity_id"); entityID != "" {
+		var entity models.Entity
+		if err := config.DB.First(&entity, "id = ?", entityID).Error; err != nil {
+			utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid client link")
+			c.Abort()
+			return nil, false
+		}
+		name := entity.Contact
+		if name == "" {
+			name = entity.CompanyName
+		}
+		return &commentPrincipal{
+			Type:      models.PrincipalClient,
+			ID:        entity.ID,
+			Name:      name,
+			ProjectID: uuid.MustParse(c.GetString("project_id")),
+		}, true
+	}
+
+	principalType, principalID, ok := timePrincipal(c)
+	if !ok {
+		return nil, false
+	}
+	p := &commentPrincipal{Type: principalType, ID: principalID}
+	if principalType == models.PrincipalUser {
+		var user models.User
+		config.DB.Select("id", "first_name", "last_name").First(&user, "id = ?", principalID)
+		p.Name = strings.TrimSpace(user.FirstName + " " + user.LastName)
+	} else {
+		var associate models.Associate
+		config.DB.Select("id", "name").First(&associate, "id = ?", principalID)
+		p.Name = associate.Name
+	}
+	return p, true
+}
+
+func loadCommentTarget(tx *gorm.DB, targetType string, targetID uuid.UUID) (*commentTarget, error) {
+	target := &commentTarget{Type: targetType, ID: targetID, Associates: map[uuid.UUID]bool{}}
+
+	switch targetType {
+	case models.CommentTargetTask:
+		var task models.Task
+		if err := tx.First(&task, "id = ?", targetID).Error; err != nil {
+			return nil, errCommentTarget
+		}
+		target.Title = task.Title
+		target.ProjectID = task.ProjectID
+		target.ClientAllowed = true
+		if task.AssignedToAssociate != nil {
+			target.Associates[*task.AssignedToAssociate] = true
+		}
+	case models.CommentTargetMilestone:
+		var milestone models.Milestone
+		if err := tx.First(&milestone, "id = ?", targetID).Error; err != nil {
+			return nil, errCommentTarget
+		}
+		target.Title = milestone.Title
+		target.ProjectID = milestone.ProjectID
+		target.ClientAllowed = milestone.ClientVisible
+		var assigned []uuid.UUID
+		tx.Model(&models.Task{}).
+			Where("milestone_id = ? AND assigned_to_associate IS NOT NULL", milestone.ID).
+			Distinct().
+			Pluck("assigned_to_associate", &assigned)
+		for _, id := range assigned {
+			target.Associates[id] = true
+		}
+	case models.CommentTargetContract:
+		// contracts are between the freelancer and associates; clients never see them
+		var contract models.Contract
+		if err := tx.First(&contract, "id = ?", targetID).Error; err != nil {
+			return nil, errCommentTarget
+		}
+		target.Title = contract.Role
+		target.ProjectID = contract.ProjectID
+		var invited []uuid.UUID
+		tx.Model(&models.Invite{}).Where("contract_id = ?", contract.ID).Distinct().Pluck("associate_id", &invited)
+		for _, id := range invited {
+			target.Associates[id] = true
+		}
+	case models.CommentTargetInvoice:
+		var invoice models.Invoice
+		if err := tx.First(&invoice, "id = ?", targetID).Error; err != nil {
+			return nil, errCommentTarget
+		}
+		target.Title = invoice.InvoiceNumber
+		target.ProjectID = invoice.ProjectID
+		target.ClientAllowed = true
+	default:
+		return nil, errCommentTarget
+	}
+
+	var project models.Project
+	if err := tx.Select("id", "user_id", "entity_id").First(&project, "id = ?", target.ProjectID).Error; err != nil {
+		return nil, errCommentTarget
+	}
+	target.OwnerID = project.UserID
+	target.EntityID = project.EntityID
+	return target, nil
+}
+
+func (t *commentTarget) canAccess(p *commentPrincipal) bool {
+	switch p.Type {
+	case models.PrincipalUser:
+		return p.ID == t.OwnerID
+	case models.PrincipalAssociate:
+		return t.Associates[p.ID]
+	case models.PrincipalClient:
+		return t.ClientAllowed && p.ProjectID == t.ProjectID && t.EntityID != nil && *t.EntityID == p.ID
+	}
+	return false
+}
+
+// visibilityFor is the only thread audience a principal other than the freelancer can see
+func visibilityFor(p *commentPrincipal) string {
+	switch p.Type {
+	case models.PrincipalAssociate:
+		return models.CommentAssociate
+	case models.PrincipalClient:
+		return models.CommentClient
+	}
+	return ""
+}
+
+func (t *commentTarget) canSee(p *commentPrincipal, visibility string) bool {
+	if !t.canAccess(p) {
+		return false
+	}
+	return p.Type == models.PrincipalUser || visibility == visibilityFor(p)
+}
+
+// loadTargetFor resolves a target and checks the principal may discuss it, writing the error response if not
+func loadTargetFor(c *gin.Context, p *commentPrincipal, targetType string, targetID uuid.UUID) (*commentTarget, bool) {
+	target, err := loadCommentTarget(config.DB, targetType, targetID)
+	if err != nil || !target.canAccess(p) {
+		utils.SendErrorResponse(c, http.StatusNotFound, "target not found")
+		return nil, false
+	}
+	return target, true
+}
+
+// loadVisibleComment fetches a comment the principal can see, along with its target
+func loadVisibleComment(c *gin.Context, p *commentPrincipal, id string) (*models.Comment, *commentTarget, bool) {
+	var comment models.Comment
+	if err := config.DB.First(&comment, "id = ?", id).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "comment not found")
+		return nil, nil, false
+	}
+	target, err := loadCommentTarget(config.DB, comment.TargetType, comment.TargetID)
+	if err != nil || !target.canSee(p, comment.Visibility) {
+		utils.SendErrorResponse(c, http.StatusNotFound, "comment not found")
+		return nil, nil, false
+	}
+	return &comment, target, true
+}
+
+// validateMentions checks every mentioned person can read the thread
+func validateMentions(target *commentTarget, visibility string, mentions []MentionInput) error {
+	for _, m := range mentions {
+		switch m.Type {
+		case models.PrincipalUser:
+			if m.ID != target.OwnerID {
+				return fmt.Errorf("user %s cannot see this thread", m.ID)
+			}
+		case models.PrincipalAssociate:
+			if visibility != models.CommentAssociate || !target.Associates[m.ID] {
+				return fmt.Errorf("associate %s cannot see this thread", m.ID)
+			}
+		}
+	}
+	return nil
+}
+
+// saveMentions records new mentions and notifies each person mentioned, once
+func saveMentions(tx *gorm.DB, comment *models.Comment, target *commentTarget, mentions []MentionInput) (map[uuid.UUID]bool, error) {
+	var existing []models.CommentMention
+	if err := tx.Where("comment_id = ?", comment.ID).Find(&existing).Error; err != nil {
+		return nil, err
+	}
+	notified := map[uuid.UUID]bool{}
+	for _, m := range existing {
+		notified[m.PrincipalID] = true
+	}
+
+	for _, m := range mentions {
+		if notified[m.ID] {
+			continue
+		}
+		notified[m.ID] = true
+
+		mention := models.CommentMention{CommentID: comment.ID, PrincipalType: m.Type, PrincipalID: m.ID}
+		if err := tx.Create(&mention).Error; err != nil {
+			return nil, err
+		}
+		if m.ID == comment.AuthorID {
+			continue
+		}
+		if err := notifyComment(tx, m.Type, m.ID, models.NotificationMention,
+			fmt.Sprintf("%s mentioned you on %s %s", comment.AuthorName, target.Type, target.Title), comment); err != nil {
+			return nil, err
+		}
+	}
+	return notified, nil
+}
+
+func notifyComment(tx *gorm.DB, recipientType string, recipientID uuid.UUID, kind, title string, comment *models.Comment) error {
+	targetID := comment.TargetID
+	commentID := comment.ID
+	message := comment.Body
+	if len(message) > 200 {
+		message = message[:200] + "..."
+	}
+	return tx.Create(&models.Notification{
+		RecipientType: recipientType,
+		RecipientID:   recipientID,
+		Kind:          kind,
+		Title:         title,
+		Message:       message,
+		TargetType:    comment.TargetType,
+		TargetID:      &targetID,
+		CommentID:     &commentID,
+	}).Error
+}
+
+// GetComments lists the threads on ?target_type=&target_id= that the caller can see
+func GetComments(c *gin.Context) {
+	p, ok := resolveCommentPrincipal(c)
+	if !ok {
+		return
+	}
+
+	targetID, err := uuid.Parse(c.Query("target_id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "invalid target_id")
+		return
+	}
+	target, ok := loadTargetFor(c, p, c.Query("target_type"), targetID)
+	if !ok {
+		return
+	}
+
+	query := config.DB.
+		Preload("Mentions").
+		Preload("Replies", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at ASC")
+		}).
+		Preload("Replies.Mentions").
+		Where("target_type = ? AND target_id = ? AND parent_id IS NULL", target.Type, target.ID)
+	if p.Type != models.PrincipalUser {
+		query = query.Where("visibility = ?", visibilityFor(p))
+	}
+
+	var threads []models.Comment
+	if err := query.Order("created_at ASC").Find(&threads).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch comments")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, threads)
+}
+
+// CreateComment starts a thread or replies to one
+func CreateComment(c *gin.Context) {
+	p, ok := resolveCommentPrincipal(c)
+	if !ok {
+		return
+	}
+
+	var input CommentInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	input.Body = strings.TrimSpace(input.Body)
+	if input.Body == "" {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "comment body is required")
+		return
+	}
+
+	target, ok := loadTargetFor(c, p, input.TargetType, input.TargetID)
+	if !ok {
+		return
+	}
+
+	comment := models.Comment{
+		ProjectID:  target.ProjectID,
+		TargetType: target.Type,
+		TargetID:   target.ID,
+		AuthorType: p.Type,
+		AuthorID:   p.ID,
+		AuthorName: p.Name,
+		Body:       input.Body,
+	}
+
+	// replies join the root of the thread and inherit its audience
+	var parent *models.Comment
+	if input.ParentID != nil {
+		var root models.Comment
+		if err := config.DB.First(&root, "id = ? AND target_type = ? AND target_id = ?", *input.ParentID, target.Type, target.ID).Error; err != nil ||
+			!target.canSee(p, root.Visibility) {
+			utils.SendErrorResponse(c, http.StatusNotFound, "parent comment not found")
+			return
+		}
+		if root.ParentID != nil {
+			if err := config.DB.First(&root, "id = ?", *root.ParentID).Error; err != nil {
+				utils.SendErrorResponse(c, http.StatusNotFound, "parent comment not found")
+				return
+			}
+		}
+		parent = &root
+		comment.ParentID = &root.ID
+		comment.Visibility = root.Visibility
+	} else if p.Type == models.PrincipalUser {
+		comment.Visibility = input.Visibility
+		if comment.Visibility == "" {
+			comment.Visibility = models.CommentInternal
+		}
+		if comment.Visibility == models.CommentClient && !target.ClientAllowed {
+			utils.SendErrorResponse(c, http.StatusBadRequest, "this record is not shared with the client")
+			return
+		}
+	} else {
+		comment.Visibility = visibilityFor(p)
+	}
+
+	if err := validateMentions(target, comment.Visibility, input.Mentions); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Mentions", "Replies").Create(&comment).Error; err != nil {
+			return err
+		}
+		notified, err := saveMentions(tx, &comment, target, input.Mentions)
+		if err != nil {
+			return err
+		}
+
+		// let the thread's author know about the reply
+		if parent != nil && parent.AuthorID != p.ID && !notified[parent.AuthorID] && parent.AuthorType != models.PrincipalClient {
+			if parent.AuthorType == models.PrincipalUser || target.Associates[parent.AuthorID] {
+				return notifyComment(tx, parent.AuthorType, parent.AuthorID, models.NotificationReply,
+					fmt.Sprintf("%s replied on %s %s", p.Name, target.Type, target.Title), &comment)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to post comment")
+		return
+	}
+
+	config.DB.Where("comment_id = ?", comment.ID).Find(&comment.Mentions)
+	utils.SendSuccessResponse(c, http.StatusCreated, gin.H{
+		"message": "Comment posted successfully",
+		"comment": comment,
+	})
+}
+
+// UpdateComment lets the author edit their comment; the freelancer may also change a thread's audience
+func UpdateComment(c *gin.Context) {
+	p, ok := resolveCommentPrincipal(c)
+	if !ok {
+		return
+	}
+
+	comment, target, ok := loadVisibleComment(c, p, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var input CommentUpdateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	isAuthor := comment.AuthorType == p.Type && comment.AuthorID == p.ID
+	if (input.Body != nil || input.Mentions != nil) && !isAuthor {
+		utils.SendErrorResponse(c, http.StatusForbidden, "only the author can edit a comment")
+		return
+	}
+	if input.Visibility != nil && *input.Visibility != comment.Visibility {
+		if p.Type != models.PrincipalUser || comment.ParentID != nil {
+			utils.SendErrorResponse(c, http.StatusForbidden, "only the freelancer can change who sees a thread")
+			return
+		}
+		if *input.Visibility == models.CommentClient && !target.ClientAllowed {
+			utils.SendErrorResponse(c, http.StatusBadRequest, "this record is not shared with the client")
+			return
+		}
+	}
+
+	if input.Body != nil && strings.TrimSpace(*input.Body) == "" {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "comment body is required")
+		return
+	}
+
+	visibility := comment.Visibility
+	if input.Visibility != nil {
+		visibility = *input.Visibility
+	}
+	if err := validateMentions(target, visibility, input.Mentions); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		revision := models.CommentRevision{
+			CommentID:  comment.ID,
+			Action:     "edit",
+			Body:       comment.Body,
+			Visibility: comment.Visibility,
+			ActorType:  p.Type,
+			ActorID:    p.ID,
+		}
+		if err := tx.Create(&revision).Error; err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{}
+		if input.Body != nil {
+			comment.Body = strings.TrimSpace(*input.Body)
+			now := time.Now()
+			comment.EditedAt = &now
+			updates["body"] = comment.Body
+			updates["edited_at"] = comment.EditedAt
+		}
+		if visibility != comment.Visibility {
+			comment.Visibility = visibility
+			updates["visibility"] = visibility
+			// replies follow the thread
+			if err := tx.Model(&models.Comment{}).Where("parent_id = ?", comment.ID).Update("visibility", visibility).Error; err != nil {
+				return err
+			}
+		}
+		if len(updates) > 0 {
+			if err := tx.Model(comment).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+
+		_, err := saveMentions(tx, comment, target, input.Mentions)
+		return err
+	})
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to update comment")
+		return
+	}
+
+	config.DB.Where("comment_id = ?", comment.ID).Find(&comment.Mentions)
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"message": "Comment updated successfully",
+		"comment": comment,
+	})
+}
+
+// DeleteComment removes a comment; authors can delete their own and the freelancer can moderate any
+func DeleteComment(c *gin.Context) {
+	p, ok := resolveCommentPrincipal(c)
+	if !ok {
+		return
+	}
+
+	comment, _, ok := loadVisibleComment(c, p, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	isAuthor := comment.AuthorType == p.Type && comment.AuthorID == p.ID
+	if !isAuthor && p.Type != models.PrincipalUser {
+		utils.SendErrorResponse(c, http.StatusForbidden, "only the author can delete a comment")
+		return
+	}
+
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		revision := models.CommentRevision{
+			CommentID:  comment.ID,
+			Action:     "delete",
+			Body:       comment.Body,
+			Visibility: comment.Visibility,
+			ActorType:  p.Type,
+			ActorID:    p.ID,
+		}
+		if err := tx.Create(&revision).Error; err != nil {
+			return err
+		}
+		return tx.Delete(comment).Error
+	})
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to delete comment")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{"message": "comment deleted"})
+}
+
+// GetCommentHistory lists earlier versions of a comment; the freelancer can also see deleted ones
+func GetCommentHistory(c *gin.Context) {
+	p, ok := resolveCommentPrincipal(c)
+	if !ok {
+		return
+	}
+
+	var comment models.Comment
+	query := config.DB
+	if p.Type == models.PrincipalUser {
+		query = query.Unscoped()
+	}
+	if err := query.First(&comment, "id = ?", c.Param("id")).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "comment not found")
+		return
+	}
+	target, err := loadCommentTarget(config.DB, comment.TargetType, comment.TargetID)
+	if err != nil || !target.canSee(p, comment.Visibility) {
+		utils.SendErrorResponse(c, http.StatusNotFound, "comment not found")
+		return
+	}
+
+	var revisions []models.CommentRevision
+	if err := config.DB.Where("comment_id = ?", comment.ID).Order("created_at ASC").Find(&revisions).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch history")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"comment":   comment,
+		"deleted":   comment.DeletedAt.Valid,
+		"revisions": revisions,
+	})
+}
+
+// CreateClientLink issues a signed link the project's client uses to join shared discussions
+func CreateClientLink(c *gin.Context) {
+	userID := c.GetString("userID")
+	if !utils.IsAuthenticated(userID) {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid user token")
+		c.Abort()
+		return
+	}
+
+	var input ClientLinkInput
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if input.Days == 0 {
+		input.Days = 30
+	}
+
+	var project models.Project
+	if err := config.DB.First(&project, "id = ? AND user_id = ?", c.Param("id"), userID).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "project not found")
+		return
+	}
+	if project.EntityID == nil {
+		utils.SendErrorResponse(c, http.StatusConflict, "project has no client")
+		return
+	}
+
+	// a new link replaces any earlier one
+	linkID := uuid.NewString()
+	expiresAt := time.Now().AddDate(0, 0, input.Days)
+	token, err := config.GenerateClientToken(project.ID.String(), project.EntityID.String(), linkID, expiresAt)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to generate client link")
+		return
+	}
+	if err := config.DB.WithContext(c).Model(&project).Update("client_link_id", linkID).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to save client link")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"message":    "Client link created",
+		"token":      token,
+		"expires_at": expiresAt,
+	})
+}
+
+// RevokeClientLink stops the project's current client link from working
+func RevokeClientLink(c *gin.Context) {
+	userID := c.GetString("userID")
+	if !utils.IsAuthenticated(userID) {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid user token")
+		c.Abort()
+		return
+	}
+
+	var project models.Project
+	if err := config.DB.First(&project, "id = ? AND user_id = ?", c.Param("id"), userID).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "project not found")
+		return
+	}
+
+	if err := config.DB.WithContext(c).Model(&project).Update("client_link_id", "").Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to revoke client link")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{"message": "Client link revoked"})
+}
@@ -63,7 +63,7 @@ func CreateContract(c *gin.Context) {
 		PaymentTerms:     input.PaymentTerms,
 	}
 
-	if err := config.DB.Create(&contract).Error; err != nil {
+	if err := config.DB.WithContext(c).Create(&contract).Error; err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to create a contract")
 		return
 	}
@@ -244,7 +244,36 @@ func UpdateContract(c *gin.Context) {
 		return
 	}
 
+	// accepted terms are locked, changes must go through an amendment
+	if contract.IsAccepted() {
+		utils.SendErrorResponse(c, http.StatusConflict, "contract has been accepted, submit an amendment instead")
+		return
+	}
+
 	// Update fields if provided
+	applyContractInput(&contract, input)
+
+	// Save updated contract as a new version
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&contract).Error; err != nil {
+			return err
+		}
+		_, err := createContractVersion(tx, &contract, "updated", false)
+		return err
+	})
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to update contract")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, map[string]interface{}{
+		"message":  "Contract updated successfully",
+		"contract": contract,
+	})
+}
+
+// applyContractInput copies the non-empty fields of the input onto the contract
+func applyContractInput(contract *models.Contract, input ContractInput) {
 	if input.Role != "" {
 		contract.Role = input.Role
 	}
@@ -278,17 +307,6 @@ func UpdateContract(c *gin.Context) {
 	if !input.EndDate.IsZero() {
 		contract.EndDate = input.EndDate
 	}
-
-	// Save updated contract
-	if err := config.DB.Save(&contract).Error; err != nil {
-		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to update contract")
-		return
-	}
-
-	utils.SendSuccessResponse(c, http.StatusOK, map[string]interface{}{
-		"message":  "Contract updated successfully",
-		"contract": contract,
-	})
 }
 
 func DeleteContract(c *gin.Context) {
@@ -314,8 +332,14 @@ func DeleteContract(c *gin.Context) {
 		return
 	}
 
+	// accepted contracts are kept as proof of what was agreed
+	if contract.IsAccepted() {
+		utils.SendErrorResponse(c, http.StatusConflict, "accepted contracts cannot be deleted")
+		return
+	}
+
 	// Delete the contract
-	if err := config.DB.Delete(&contract).Error; err != nil {
+	if err := config.DB.WithContext(c).Delete(&contract).Error; err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to delete contract")
 		return
 	}
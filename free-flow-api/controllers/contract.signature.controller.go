@@ -0,0 +1,227 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"free-flow-api/config"
+	"free-flow-api/models"
+	"free-flow-api/utils"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	drawnSignaturePrefix  = "data:image/png;base64,"
+	maxDrawnSignatureSize = 512 * 1024
+)
+
+var errDocumentChanged = errors.New("the contract changed since it was displayed, please review it again")
+
+type SignatureInput struct {
+	SignerName   string `json:"signer_name" binding:"required"`
+	Type         string `json:"type" binding:"required,oneof=typed drawn"`
+	Data         string `json:"data"`                             // typed name, or a base64 png data url when drawn
+	DocumentHash string `json:"document_hash" binding:"required"` // hash of the document the signer was shown
+}
+
+// Validate checks the signature payload, defaulting typed signatures to the signer's name
+func (s *SignatureInput) Validate() error {
+	s.SignerName = strings.TrimSpace(s.SignerName)
+	if s.SignerName == "" {
+		return errors.New("signer name is required")
+	}
+
+	switch s.Type {
+	case "typed":
+		if strings.TrimSpace(s.Data) == "" {
+			s.Data = s.SignerName
+		}
+	case "drawn":
+		if !strings.HasPrefix(s.Data, drawnSignaturePrefix) {
+			return errors.New("drawn signatures must be a png data url")
+		}
+		if len(s.Data) > maxDrawnSignatureSize {
+			return errors.New("drawn signature is too large")
+		}
+		if _, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(s.Data, drawnSignaturePrefix)); err != nil {
+			return errors.New("drawn signature is not valid base64")
+		}
+	}
+
+	return nil
+}
+
+// signContractVersion records the signer's evidence against the exact version offered by the invite
+func signContractVersion(tx *gorm.DB, c *gin.Context, invite models.Invite, input SignatureInput) (*models.ContractSignature, error) {
+	var version models.ContractVersion
+	if err := tx.First(&version, "contract_id = ? AND version = ?", invite.ContractID, invite.ContractVersion).Error; err != nil {
+		return nil, err
+	}
+
+	document := version.Document()
+	hash := version.DocumentHash()
+	if !strings.EqualFold(input.DocumentHash, hash) {
+		return nil, errDocumentChanged
+	}
+
+	signature := models.ContractSignature{
+		ContractID:      invite.ContractID,
+		ContractVersion: version.Version,
+		InviteID:        invite.ID,
+		AssociateID:     invite.AssociateID,
+		SignerName:      input.SignerName,
+		SignatureType:   input.Type,
+		SignatureData:   input.Data,
+		Document:        document,
+		DocumentHash:    hash,
+		IPAddress:       c.ClientIP(),
+		UserAgent:       c.Request.UserAgent(),
+		SignedAt:        time.Now().UTC(),
+	}
+	if err := tx.Create(&signature).Error; err != nil {
+		return nil, err
+	}
+
+	return &signature, nil
+}
+
+// verifySignature checks the signed document kept on the signature against its recorded hash, and
+// reports whether the contract version it came from still renders the same text
+func verifySignature(c *gin.Context, signature models.ContractSignature) (valid bool, currentHash string) {
+	var version models.ContractVersion
+	if err := config.DB.First(&version, "contract_id = ? AND version = ?", signature.ContractID, signature.ContractVersion).Error; err == nil {
+		currentHash = version.DocumentHash()
+	}
+	return signature.Intact(), currentHash
+}
+
+func GetContractSignatures(c *gin.Context) {
+	contract, ok := loadContractForVersions(c)
+	if !ok {
+		return
+	}
+
+	var signatures []models.ContractSignature
+	if err := config.DB.
+		Where("contract_id = ?", contract.ID).
+		Order("signed_at ASC").
+		Find(&signatures).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch contract signatures")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, signatures)
+}
+
+// VerifyContractSignature proves the signed document is unchanged. It is public, so it only
+// returns hashes and who signed when; the certificate with the full evidence needs a login.
+// An optional ?hash= lets holders of a copy check it against the signed original.
+func VerifyContractSignature(c *gin.Context) {
+	var signature models.ContractSignature
+	if err := config.DB.First(&signature, "id = ?", c.Param("id")).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "signature not found")
+		return
+	}
+
+	valid, currentHash := verifySignature(c, signature)
+
+	response := gin.H{
+		"signature_id":     signature.ID,
+		"contract_id":      signature.ContractID,
+		"contract_version": signature.ContractVersion,
+		"signer_name":      signature.SignerName,
+		"signed_at":        signature.SignedAt,
+		"document_hash":    signature.DocumentHash,
+		"current_hash":     currentHash,
+		"valid":            valid,
+	}
+
+	if hash := c.Query("hash"); hash != "" {
+		response["hash_matches"] = strings.EqualFold(hash, signature.DocumentHash)
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, response)
+}
+
+var signatureCertificate = template.Must(template.New("certificate").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Signature certificate {{.Signature.ID}}</title>
+<style>
+body { font-family: sans-serif; max-width: 760px; margin: 2em auto; color: #222; }
+pre { white-space: pre-wrap; background: #f6f6f6; padding: 1em; border: 1px solid #ddd; }
+table { border-collapse: collapse; width: 100%; }
+td { padding: .3em .6em; border-bottom: 1px solid #eee; vertical-align: top; }
+td:first-child { color: #666; width: 30%; }
+.valid { color: #1a7f37; } .invalid { color: #cf222e; }
+</style>
+</head>
+<body>
+<h1>Certificate of signature</h1>
+<p class="{{if .Valid}}valid{{else}}invalid{{end}}">
+{{if .Valid}}The document below is the exact text that was signed.{{else}}The signed document could not be verified against its recorded hash.{{end}}
+</p>
+<table>
+<tr><td>Signature ID</td><td>{{.Signature.ID}}</td></tr>
+<tr><td>Contract</td><td>{{.Signature.ContractID}} (version {{.Signature.ContractVersion}})</td></tr>
+<tr><td>Signer</td><td>{{.Signature.SignerName}}</td></tr>
+<tr><td>Signed at (UTC)</td><td>{{.Signature.SignedAt.Format "2006-01-02 15:04:05"}}</td></tr>
+<tr><td>IP address</td><td>{{.Signature.IPAddress}}</td></tr>
+<tr><td>User agent</td><td>{{.Signature.UserAgent}}</td></tr>
+<tr><td>SHA-256</td><td><code>{{.Signature.DocumentHash}}</code></td></tr>
+<tr><td>Signature</td><td>{{if .Image}}<img src="{{.Image}}" alt="signature" height="80">{{else}}<em>{{.Signature.SignatureData}}</em>{{end}}</td></tr>
+</table>
+<h2>Signed document</h2>
+<pre>{{.Document}}</pre>
+</body>
+</html>
+`))
+
+// GetSignatureCertificate renders a printable certificate for a signature on one of the caller's contracts
+func GetSignatureCertificate(c *gin.Context) {
+	// Validate JWT
+	userID := c.GetString("userID")
+	if !utils.IsAuthenticated(userID) {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid user token")
+		c.Abort()
+		return
+	}
+
+	var signature models.ContractSignature
+	if err := config.DB.
+		Joins("JOIN contracts ON contracts.id = contract_signatures.contract_id").
+		Joins("JOIN projects ON projects.id = contracts.project_id").
+		Where("contract_signatures.id = ? AND projects.user_id = ?", c.Param("id"), userID).
+		First(&signature).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "signature not found")
+		return
+	}
+
+	valid, _ := verifySignature(c, signature)
+
+	data := gin.H{
+		"Signature": signature,
+		"Document":  signature.Document,
+		"Valid":     valid,
+		"Image":     template.URL(""),
+	}
+	// drawn signatures were validated as png data urls when signed
+	if signature.SignatureType == "drawn" && strings.HasPrefix(signature.SignatureData, drawnSignaturePrefix) {
+		data["Image"] = template.URL(signature.SignatureData)
+	}
+
+	var buf bytes.Buffer
+	if err := signatureCertificate.Execute(&buf, data); err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to render certificate")
+		return
+	}
+
+	c.Data(http.StatusOK, "text/html; charset=utf-8", buf.Bytes())
+}
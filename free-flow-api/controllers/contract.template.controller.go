@@ -0,0 +1,390 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"free-flow-api/config"
+	"free-flow-api/models"
+	"free-flow-api/utils"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const templateDateFormat = "02 Jan 2006"
+
+// ContractTemplatePlaceholders documents every placeholder a template may use
+var ContractTemplatePlaceholders = map[string]string{
+	"task.title":            "Task title",
+	"task.description":      "Task description",
+	"task.due_date":         "Task due date",
+	"task.estimated_hours":  "Estimated hours for the task",
+	"task.value":            "Task value",
+	"project.name":          "Project name",
+	"project.category":      "Project category",
+	"project.deadline":      "Project deadline",
+	"project.currency":      "Project currency",
+	"associate.name":        "Associate full name",
+	"associate.email":       "Associate email",
+	"associate.cut_percent": "Associate share of the task value, in percent",
+	"associate.amount":      "Associate payout for the task",
+	"entity.company_name":   "Client company name",
+	"entity.contact":        "Client contact person",
+	"freelancer.name":       "Your full name",
+	"freelancer.email":      "Your email",
+	"contract.start_date":   "Contract start date",
+	"contract.end_date":     "Contract end date",
+	"today":                 "Date the contract is generated",
+}
+
+type ContractTemplateInput struct {
+	Name             string   `json:"name" binding:"required"`
+	Role             string   `json:"role"`
+	Description      string   `json:"description"`
+	Confidentiality  string   `json:"confidentiality"`
+	Ownership        string   `json:"ownership"`
+	Responsibilities []string `json:"responsibilities"`
+	Deliverables     []string `json:"deliverables"`
+	Effort           string   `json:"effort"`
+	TimelineNotes    string   `json:"timeline_notes"`
+	PaymentTerms     string   `json:"payment_terms"`
+}
+
+type InstantiateContractInput struct {
+	TaskID      uuid.UUID  `json:"task_id" binding:"required"`
+	AssociateID *uuid.UUID `json:"associate_id"`
+	StartDate   time.Time  `json:"start_date"`
+	EndDate     time.Time  `json:"end_date"`
+}
+
+func formatTemplateDate(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.Format(templateDateFormat)
+}
+
+// contractTemplateVars resolves placeholder values from the task and everything around it
+func contractTemplateVars(task models.Task, project models.Project, associate *models.Associate, start, end time.Time) (map[string]string, error) {
+	now := time.Now()
+
+	vars := map[string]string{
+		"task.title":           task.Title,
+		"task.description":     task.Description,
+		"task.due_date":        formatTemplateDate(task.DueDate),
+		"task.estimated_hours": fmt.Sprintf("%.1f", task.EstimatedHours),
+		"task.value":           "",
+		"project.name":         project.Name,
+		"project.category":     project.Category,
+		"project.deadline":     formatTemplateDate(project.Deadline),
+		"project.currency":     project.Currency,
+		"associate.name":       "",
+		"associate.email":      "",
+		"entity.company_name":  "",
+		"entity.contact":       "",
+		"contract.start_date":  formatTemplateDate(&start),
+		"contract.end_date":    formatTemplateDate(&end),
+		"today":                now.Format(templateDateFormat),
+	}
+
+	cut := 100 - project.YourCutPercent
+	vars["associate.cut_percent"] = fmt.Sprintf("%.0f%%", cut)
+	vars["associate.amount"] = ""
+
+	if task.TaskValue != nil {
+		vars["task.value"] = fmt.Sprintf("%s %.2f", project.Currency, *task.TaskValue)
+		vars["associate.amount"] = fmt.Sprintf("%s %.2f", project.Currency, *task.TaskValue*(cut/100.0))
+	}
+
+	if associate != nil {
+		vars["associate.name"] = associate.Name
+		vars["associate.email"] = associate.Email
+	}
+
+	if project.EntityID != nil {
+		var entity models.Entity
+		if err := config.DB.First(&entity, "id = ?", *project.EntityID).Error; err == nil {
+			vars["entity.company_name"] = entity.CompanyName
+			vars["entity.contact"] = entity.Contact
+		}
+	}
+
+	var user models.User
+	if err := config.DB.First(&user, "id = ?", project.UserID).Error; err != nil {
+		return nil, err
+	}
+	vars["freelancer.name"] = user.FirstName + " " + user.LastName
+	vars["freelancer.email"] = user.Email
+
+	return vars, nil
+}
+
+func CreateContractTemplate(c *gin.Context) {
+	//validate jwt
+	userID := c.GetString("userID")
+	if !utils.IsAuthenticated(userID) {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid user token")
+		c.Abort()
+		return
+	}
+
+	var input ContractTemplateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	template := models.ContractTemplate{
+		UserID:           uuid.MustParse(userID),
+		Name:             input.Name,
+		Role:             input.Role,
+		Description:      input.Description,
+		Confidentiality:  input.Confidentiality,
+		Ownership:        input.Ownership,
+		Responsibilities: input.Responsibilities,
+		Deliverables:     input.Deliverables,
+		Effort:           input.Effort,
+		TimelineNotes:    input.TimelineNotes,
+		PaymentTerms:     input.PaymentTerms,
+	}
+
+	if err := config.DB.WithContext(c).Create(&template).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to create contract template")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusCreated, gin.H{
+		"message":  "Contract template created successfully",
+		"template": template,
+	})
+}
+
+func GetContractTemplates(c *gin.Context) {
+	// Validate JWT
+	userID := c.GetString("userID")
+	if !utils.IsAuthenticated(userID) {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid user token")
+		c.Abort()
+		return
+	}
+
+	var templates []models.ContractTemplate
+	if err := config.DB.
+		Where("user_id = ?", userID).
+		Order("name ASC").
+		Find(&templates).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch contract templates")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, templates)
+}
+
+func GetContractTemplatePlaceholders(c *gin.Context) {
+	utils.SendSuccessResponse(c, http.StatusOK, ContractTemplatePlaceholders)
+}
+
+func GetContractTemplateByID(c *gin.Context) {
+	// Validate JWT
+	userID := c.GetString("userID")
+	if !utils.IsAuthenticated(userID) {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid user token")
+		c.Abort()
+		return
+	}
+
+	var template models.ContractTemplate
+	if err := config.DB.First(&template, "id = ? AND user_id = ?", c.Param("id"), userID).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "contract template not found")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, template)
+}
+
+func UpdateContractTemplate(c *gin.Context) {
+	// Validate JWT
+	userID := c.GetString("userID")
+	if !utils.IsAuthenticated(userID) {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid user token")
+		c.Abort()
+		return
+	}
+
+	var input ContractTemplateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var template models.ContractTemplate
+	if err := config.DB.First(&template, "id = ? AND user_id = ?", c.Param("id"), userID).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "contract template not found")
+		return
+	}
+
+	template.Name = input.Name
+	template.Role = input.Role
+	template.Description = input.Description
+	template.Confidentiality = input.Confidentiality
+	template.Ownership = input.Ownership
+	template.Responsibilities = input.Responsibilities
+	template.Deliverables = input.Deliverables
+	template.Effort = input.Effort
+	template.TimelineNotes = input.TimelineNotes
+	template.PaymentTerms = input.PaymentTerms
+
+	if err := config.DB.WithContext(c).Save(&template).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to update contract template")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"message":  "Contract template updated successfully",
+		"template": template,
+	})
+}
+
+func DeleteContractTemplate(c *gin.Context) {
+	// Validate JWT
+	userID := c.GetString("userID")
+	if !utils.IsAuthenticated(userID) {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid user token")
+		c.Abort()
+		return
+	}
+
+	if err := config.DB.WithContext(c).Delete(&models.ContractTemplate{}, "id = ? AND user_id = ?", c.Param("id"), userID).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to delete contract template")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{"message": "Contract template deleted successfully"})
+}
+
+// InstantiateContractTemplate renders a template against a task and stores the result as the task's contract
+func InstantiateContractTemplate(c *gin.Context) {
+	// Validate JWT
+	userID := c.GetString("userID")
+	if !utils.IsAuthenticated(userID) {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid user token")
+		c.Abort()
+		return
+	}
+
+	var input InstantiateContractInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var template models.ContractTemplate
+	if err := config.DB.First(&template, "id = ? AND user_id = ?", c.Param("id"), userID).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "contract template not found")
+		return
+	}
+
+	var task models.Task
+	if err := config.DB.First(&task, "id = ?", input.TaskID).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "task not found")
+		return
+	}
+
+	var project models.Project
+	if err := config.DB.First(&project, "id = ? AND user_id = ?", task.ProjectID, userID).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "project not found")
+		return
+	}
+
+	var existing models.Contract
+	err := config.DB.First(&existing, "task_id = ?", task.ID).Error
+	if err == nil {
+		utils.SendErrorResponse(c, http.StatusConflict, "task already has a contract")
+		return
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch contract")
+		return
+	}
+
+	var associate *models.Associate
+	associateID := input.AssociateID
+	if associateID == nil {
+		associateID = task.AssignedToAssociate
+	}
+	if associateID != nil {
+		var a models.Associate
+		if err := config.DB.First(&a, "id = ?", *associateID).Error; err != nil {
+			utils.SendErrorResponse(c, http.StatusNotFound, "associate not found")
+			return
+		}
+		associate = &a
+	}
+
+	start := input.StartDate
+	if start.IsZero() {
+		start = time.Now()
+	}
+	end := input.EndDate
+	if end.IsZero() && task.DueDate != nil {
+		end = *task.DueDate
+	}
+
+	vars, err := contractTemplateVars(task, project, associate, start, end)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to resolve template placeholders")
+		return
+	}
+
+	unresolved := map[string]bool{}
+	render := func(text string) string {
+		out, missing := utils.RenderTemplate(text, vars)
+		for _, m := range missing {
+			unresolved[m] = true
+		}
+		return out
+	}
+	renderAll := func(lines []string) []string {
+		out := make([]string, len(lines))
+		for i, l := range lines {
+			out[i] = render(l)
+		}
+		return out
+	}
+
+	contract := models.Contract{
+		TaskID:           task.ID,
+		ProjectID:        project.ID,
+		Role:             render(template.Role),
+		Description:      render(template.Description),
+		Confidentiality:  render(template.Confidentiality),
+		Ownership:        render(template.Ownership),
+		Responsibilities: renderAll(template.Responsibilities),
+		Deliverables:     renderAll(template.Deliverables),
+		Effort:           render(template.Effort),
+		TimelineNotes:    render(template.TimelineNotes),
+		PaymentTerms:     render(template.PaymentTerms),
+		StartDate:        start,
+		EndDate:          end,
+		Timestamp:        time.Now(),
+	}
+
+	if err := config.DB.WithContext(c).Create(&contract).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to create a contract")
+		return
+	}
+
+	missing := make([]string, 0, len(unresolved))
+	for k := range unresolved {
+		missing = append(missing, k)
+	}
+
+	utils.SendSuccessResponse(c, http.StatusCreated, gin.H{
+		"message":                 "Contract created successfully",
+		"contract":                contract,
+		"unresolved_placeholders": missing,
+	})
+}
@@ -0,0 +1,314 @@
+package controllers
+
+import (
+	"errors"
+	"free-flow-api/config"
+	"free-flow-api/models"
+	"free-flow-api/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errAmendmentPending   = errors.New("an amendment is already awaiting acceptance")
+	errAmendmentUnchanged = errors.New("amendment does not change any terms")
+)
+
+type AmendContractInput struct {
+	ContractInput
+	Reason string `json:"reason" binding:"required"`
+}
+
+// createContractVersion snapshots the contract as its next version and points the contract at it.
+// Pending invites are moved to the new version so the associate always reviews the latest terms.
+func createContractVersion(tx *gorm.DB, contract *models.Contract, note string, amendment bool) (*models.ContractVersion, error) {
+	var latest int
+	if err := tx.Model(&models.ContractVersion{}).
+		Where("contract_id = ?", contract.ID).
+		Select("COALESCE(MAX(version), 0)").
+		Scan(&latest).Error; err != nil {
+		return nil, err
+	}
+
+	version := models.NewContractVersion(*contract, latest+1)
+	version.ChangeNote = note
+	version.IsAmendment = amendment
+	if err := tx.Create(&version).Error; err != nil {
+		return nil, err
+	}
+
+	contract.CurrentVersion = version.Version
+	if err := tx.Model(contract).Update("current_version", version.Version).Error; err != nil {
+		return nil, err
+	}
+
+	if err := tx.Model(&models.Invite{}).
+		Where("contract_id = ? AND status = ?", contract.ID, "pending").
+		Update("contract_version", version.Version).Error; err != nil {
+		return nil, err
+	}
+
+	return &version, nil
+}
+
+// acceptContractVersion locks the accepted version and records it on the contract
+func acceptContractVersion(tx *gorm.DB, contractID uuid.UUID, versionNumber int, associateID uuid.UUID) error {
+	var version models.ContractVersion
+	if err := tx.First(&version, "contract_id = ? AND version = ?", contractID, versionNumber).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Model(&version).Updates(map[string]interface{}{
+		"accepted_at": gorm.Expr("NOW()"),
+		"accepted_by": associateID,
+	}).Error; err != nil {
+		return err
+	}
+
+	return tx.Model(&models.Contract{}).
+		Where("id = ?", contractID).
+		Updates(map[string]interface{}{
+			"accepted_version":  versionNumber,
+			"current_version":   versionNumber,
+			"amendment_pending": false,
+		}).Error
+}
+
+// rejectContractAmendment restores the last accepted terms after an associate declines an amendment
+func rejectContractAmendment(tx *gorm.DB, contractID uuid.UUID) error {
+	var contract models.Contract
+	if err := tx.First(&contract, "id = ?", contractID).Error; err != nil {
+		return err
+	}
+	if !contract.AmendmentPending {
+		return nil
+	}
+
+	var accepted models.ContractVersion
+	if err := tx.First(&accepted, "contract_id = ? AND version = ?", contract.ID, contract.AcceptedVersion).Error; err != nil {
+		return err
+	}
+
+	accepted.ApplyTo(&contract)
+	contract.CurrentVersion = accepted.Version
+	contract.AmendmentPending = false
+	return tx.Save(&contract).Error
+}
+
+func loadContractForVersions(c *gin.Context) (*models.Contract, bool) {
+	// Validate JWT
+	userID := c.GetString("userID")
+	if !utils.IsAuthenticated(userID) {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid user token")
+		c.Abort()
+		return nil, false
+	}
+
+	// only the freelancer who owns the project can see or amend its contracts
+	var contract models.Contract
+	if err := config.DB.
+		Joins("JOIN projects ON projects.id = contracts.project_id").
+		Where("contracts.id = ? AND projects.user_id = ?", c.Param("id"), userID).
+		First(&contract).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "contract not found")
+		return nil, false
+	}
+
+	return &contract, true
+}
+
+func GetContractVersions(c *gin.Context) {
+	contract, ok := loadContractForVersions(c)
+	if !ok {
+		return
+	}
+
+	var versions []models.ContractVersion
+	if err := config.DB.
+		Where("contract_id = ?", contract.ID).
+		Order("version ASC").
+		Find(&versions).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch contract versions")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"current_version":   contract.CurrentVersion,
+		"accepted_version":  contract.AcceptedVersion,
+		"amendment_pending": contract.AmendmentPending,
+		"versions":          versions,
+	})
+}
+
+func GetContractVersion(c *gin.Context) {
+	contract, ok := loadContractForVersions(c)
+	if !ok {
+		return
+	}
+
+	number, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "invalid version number")
+		return
+	}
+
+	var version models.ContractVersion
+	if err := config.DB.First(&version, "contract_id = ? AND version = ?", contract.ID, number).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "contract version not found")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, version)
+}
+
+// DiffContractVersions compares two versions, defaulting to the accepted version against the current one
+func DiffContractVersions(c *gin.Context) {
+	contract, ok := loadContractForVersions(c)
+	if !ok {
+		return
+	}
+
+	from := contract.AcceptedVersion
+	if from == 0 {
+		from = 1
+	}
+	to := contract.CurrentVersion
+
+	if v := c.Query("from"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			utils.SendErrorResponse(c, http.StatusBadRequest, "invalid from version")
+			return
+		}
+		from = n
+	}
+	if v := c.Query("to"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			utils.SendErrorResponse(c, http.StatusBadRequest, "invalid to version")
+			return
+		}
+		to = n
+	}
+
+	var versions []models.ContractVersion
+	if err := config.DB.
+		Where("contract_id = ? AND version IN ?", contract.ID, []int{from, to}).
+		Find(&versions).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch contract versions")
+		return
+	}
+
+	var a, b *models.ContractVersion
+	for i := range versions {
+		if versions[i].Version == from {
+			a = &versions[i]
+		}
+		if versions[i].Version == to {
+			b = &versions[i]
+		}
+	}
+	if a == nil || b == nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "contract version not found")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"from":    from,
+		"to":      to,
+		"changes": models.DiffContractVersions(*a, *b),
+	})
+}
+
+// AmendContract proposes new terms for an accepted contract and re-invites the associate to accept them
+func AmendContract(c *gin.Context) {
+	contract, ok := loadContractForVersions(c)
+	if !ok {
+		return
+	}
+
+	var input AmendContractInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if !contract.IsAccepted() {
+		utils.SendErrorResponse(c, http.StatusConflict, "contract has not been accepted yet, update it directly")
+		return
+	}
+	if contract.AmendmentPending {
+		utils.SendErrorResponse(c, http.StatusConflict, errAmendmentPending.Error())
+		return
+	}
+
+	var accepted models.ContractVersion
+	if err := config.DB.First(&accepted, "contract_id = ? AND version = ?", contract.ID, contract.AcceptedVersion).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch accepted version")
+		return
+	}
+	if accepted.AcceptedBy == nil {
+		utils.SendErrorResponse(c, http.StatusConflict, "accepted version has no signer")
+		return
+	}
+
+	var task models.Task
+	if err := config.DB.First(&task, "id = ?", contract.TaskID).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "task not found")
+		return
+	}
+
+	applyContractInput(contract, input.ContractInput)
+	contract.AmendmentPending = true
+
+	var version *models.ContractVersion
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		// a concurrent amendment may have been sent since the contract was loaded
+		var pending []bool
+		if err := tx.Model(&models.Contract{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", contract.ID).Pluck("amendment_pending", &pending).Error; err != nil {
+			return err
+		}
+		if len(pending) > 0 && pending[0] {
+			return errAmendmentPending
+		}
+
+		if err := tx.Save(contract).Error; err != nil {
+			return err
+		}
+
+		v, err := createContractVersion(tx, contract, input.Reason, true)
+		if err != nil {
+			return err
+		}
+		version = v
+
+		changes := models.DiffContractVersions(accepted, *version)
+		if len(changes) == 0 {
+			return errAmendmentUnchanged
+		}
+
+		return InviteAssociate(tx, task, *accepted.AcceptedBy, contract.ID)
+	})
+	switch {
+	case errors.Is(err, errAmendmentUnchanged):
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	case errors.Is(err, errAmendmentPending), errors.Is(err, models.ErrContractVersionLocked):
+		utils.SendErrorResponse(c, http.StatusConflict, err.Error())
+		return
+	case err != nil:
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to amend contract")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"message": "Amendment sent for acceptance",
+		"version": version,
+	})
+}
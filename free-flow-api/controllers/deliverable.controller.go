@@ -0,0 +1,381 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"free-flow-api/config"
+	"free-flow-api/models"
+	"free-flow-api/utils"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DeliverableInput struct {
+	Notes string   `json:"notes" form:"notes" binding:"max=5000"`
+	Links []string `json:"links" form:"links" binding:"max=20,dive,url"`
+}
+
+type DeliverableReviewInput struct {
+	Feedback string `json:"feedback" binding:"max=5000"`
+}
+
+var (
+	errDeliverableState = errors.New("deliverable is no longer awaiting review")
+	errSubmissionClosed = errors.New("task is not open for submissions")
+)
+
+// submittable reports whether an associate can hand in work on a task in its current status
+func submittable(task models.Task) bool {
+	return task.Status == models.TaskStatusTodo || task.Status == models.TaskStatusInProgress
+}
+
+// withDeliverableFiles attaches each deliverable's uploaded files
+func withDeliverableFiles(deliverables []models.Deliverable) error {
+	if len(deliverables) == 0 {
+		return nil
+	}
+	ids := make([]uuid.UUID, 0, len(deliverables))
+	for _, d := range deliverables {
+		ids = append(ids, d.ID)
+	}
+
+	var files []models.Attachment
+	if err := config.DB.
+		Where("target_type = ? AND target_id IN ?", models.AttachmentDeliverable, ids).
+		Order("created_at ASC").
+		Find(&files).Error; err != nil {
+		return err
+	}
+	byDeliverable := make(map[uuid.UUID][]models.Attachment)
+	for _, f := range files {
+		byDeliverable[f.TargetID] = append(byDeliverable[f.TargetID], f)
+	}
+	for i := range deliverables {
+		deliverables[i].Attachments = byDeliverable[deliverables[i].ID]
+		if deliverables[i].Attachments == nil {
+			deliverables[i].Attachments = []models.Attachment{}
+		}
+	}
+	return nil
+}
+
+// loadReviewableDeliverable fetches a deliverable on a task the freelancer owns
+func loadReviewableDeliverable(c *gin.Context) (*models.Task, *models.Deliverable, bool) {
+	task, ok := loadOwnedTask(c)
+	if !ok {
+		return nil, nil, false
+	}
+
+	var deliverable models.Deliverable
+	if err := config.DB.First(&deliverable, "id = ? AND task_id = ?", c.Param("deliverableId"), task.ID).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "deliverable not found")
+		return nil, nil, false
+	}
+	if deliverable.Status != models.DeliverableSubmitted || task.Status != models.TaskStatusReview {
+		utils.SendErrorResponse(c, http.StatusConflict, errDeliverableState.Error())
+		return nil, nil, false
+	}
+	return task, &deliverable, true
+}
+
+// SubmitDeliverable hands in a round of work (notes, links and multipart "files") and puts the task up for review
+func SubmitDeliverable(c *gin.Context) {
+	associateID := c.GetString("userID")
+	if !utils.IsAssociateAuthenticated(associateID) {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid associate token")
+		c.Abort()
+		return
+	}
+
+	var task models.Task
+	if err := config.DB.First(&task, "id = ? AND assigned_to_associate = ?", c.Param("id"), associateID).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "task not found")
+		return
+	}
+	if !submittable(task) {
+		utils.SendErrorResponse(c, http.StatusConflict, fmt.Sprintf("cannot submit work on a task that is %s", task.Status))
+		return
+	}
+	var subtasks int64
+	config.DB.Model(&models.Task{}).Where("parent_id = ?", task.ID).Count(&subtasks)
+	if subtasks > 0 {
+		utils.SendErrorResponse(c, http.StatusConflict, "submit deliverables on the subtasks instead")
+		return
+	}
+
+	var input DeliverableInput
+	if err := c.ShouldBind(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	var files []*multipart.FileHeader
+	if form, err := c.MultipartForm(); err == nil {
+		files = form.File["files"]
+	}
+	input.Notes = strings.TrimSpace(input.Notes)
+	if input.Notes == "" && len(input.Links) == 0 && len(files) == 0 {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "add notes, links or files to the deliverable")
+		return
+	}
+
+	deliverable := models.Deliverable{
+		TaskID:      task.ID,
+		ProjectID:   task.ProjectID,
+		AssociateID: uuid.MustParse(associateID),
+		Notes:       input.Notes,
+		Links:       input.Links,
+		Status:      models.DeliverableSubmitted,
+	}
+	deliverable.ID = uuid.New()
+
+	// store files first so a failed upload leaves no submission behind
+	var attachments []models.Attachment
+	cleanup := func() {
+		for _, a := range attachments {
+			utils.RemoveUpload(a.StorageKey)
+		}
+	}
+	for _, file := range files {
+		stored, err := utils.StoreUpload(file, path.Join("attachments", models.AttachmentDeliverable, deliverable.ID.String()), attachmentTypes, maxAttachmentSize())
+		if err != nil {
+			cleanup()
+			if utils.IsUploadRejected(err) {
+				utils.SendErrorResponse(c, http.StatusBadRequest, file.Filename+": "+err.Error())
+				return
+			}
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to store "+file.Filename)
+			return
+		}
+		projectID := task.ProjectID
+		attachments = append(attachments, models.Attachment{
+			ProjectID:    &projectID,
+			TargetType:   models.AttachmentDeliverable,
+			TargetID:     deliverable.ID,
+			UploaderType: models.PrincipalAssociate,
+			UploaderID:   deliverable.AssociateID,
+			FileName:     filepath.Base(file.Filename),
+			ContentType:  stored.ContentType,
+			Size:         stored.Size,
+			Checksum:     stored.Checksum,
+			ScanStatus:   stored.ScanStatus,
+			StorageKey:   stored.Key,
+		})
+	}
+
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		// lock the task so concurrent submissions queue up; the second one finds it in review
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&task, "id = ?", task.ID).Error; err != nil {
+			return err
+		}
+		if !submittable(task) {
+			return errSubmissionClosed
+		}
+
+		var rounds int
+		if err := tx.Model(&models.Deliverable{}).Where("task_id = ?", task.ID).Select("COALESCE(MAX(round), 0)").Scan(&rounds).Error; err != nil {
+			return err
+		}
+		deliverable.Round = rounds + 1
+		if err := tx.Create(&deliverable).Error; err != nil {
+			return err
+		}
+		for i := range attachments {
+			if err := tx.Create(&attachments[i]).Error; err != nil {
+				return err
+			}
+		}
+
+		actor := models.StatusActor{Type: models.PrincipalAssociate, ID: deliverable.AssociateID}
+		if err := models.TransitionTask(tx, &task, models.TaskStatusReview, actor); err != nil {
+			return err
+		}
+
+		var associate models.Associate
+		tx.Select("id", "name").First(&associate, "id = ?", deliverable.AssociateID)
+		taskID := task.ID
+		return tx.Create(&models.Notification{
+			RecipientType: models.PrincipalUser,
+			RecipientID:   task.CreatedBy,
+			Kind:          models.NotificationDeliverableSubmitted,
+			Title:         fmt.Sprintf("%s submitted round %d of %s", associate.Name, deliverable.Round, task.Title),
+			Message:       deliverable.Notes,
+			TargetType:    models.CommentTargetTask,
+			TargetID:      &taskID,
+		}).Error
+	})
+	if err != nil {
+		cleanup()
+		if errors.Is(err, errSubmissionClosed) {
+			utils.SendErrorResponse(c, http.StatusConflict, fmt.Sprintf("cannot submit work on a task that is %s", task.Status))
+			return
+		}
+		sendTransitionError(c, err, "failed to submit deliverable")
+		return
+	}
+
+	deliverable.Attachments = attachments
+	utils.SendSuccessResponse(c, http.StatusCreated, gin.H{
+		"message":     "Deliverable submitted for review",
+		"deliverable": deliverable,
+	})
+}
+
+// GetTaskDeliverables lists every submission round on a task, newest first
+func GetTaskDeliverables(c *gin.Context) {
+	principalType, principalID, ok := timePrincipal(c)
+	if !ok {
+		return
+	}
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "invalid task id")
+		return
+	}
+	task, ok := loadTrackableTask(c, principalType, principalID, taskID)
+	if !ok {
+		return
+	}
+
+	var deliverables []models.Deliverable
+	if err := config.DB.Where("task_id = ?", task.ID).Order("round DESC").Find(&deliverables).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch deliverables")
+		return
+	}
+	if err := withDeliverableFiles(deliverables); err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch deliverable files")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, deliverables)
+}
+
+// ApproveDeliverable accepts a submission: the task is done and the associate's settlement becomes payable
+func ApproveDeliverable(c *gin.Context) {
+	task, deliverable, ok := loadReviewableDeliverable(c)
+	if !ok {
+		return
+	}
+
+	var input DeliverableReviewInput
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	reviewerID := uuid.MustParse(c.GetString("userID"))
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&models.Deliverable{}).
+			Where("id = ? AND status = ?", deliverable.ID, models.DeliverableSubmitted).
+			Updates(map[string]interface{}{
+				"status":      models.DeliverableApproved,
+				"feedback":    strings.TrimSpace(input.Feedback),
+				"reviewed_at": now,
+				"reviewed_by": reviewerID,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errDeliverableState
+		}
+
+		// completing the task stops its timers and makes the settlement payable
+		if err := models.TransitionTask(tx, task, models.TaskStatusDone, models.StatusActor{Type: models.PrincipalUser, ID: reviewerID}); err != nil {
+			return err
+		}
+
+		taskID := task.ID
+		return tx.Create(&models.Notification{
+			RecipientType: models.PrincipalAssociate,
+			RecipientID:   deliverable.AssociateID,
+			Kind:          models.NotificationDeliverableApproved,
+			Title:         fmt.Sprintf("Round %d of %s was approved", deliverable.Round, task.Title),
+			Message:       strings.TrimSpace(input.Feedback),
+			TargetType:    models.CommentTargetTask,
+			TargetID:      &taskID,
+		}).Error
+	})
+	if errors.Is(err, errDeliverableState) {
+		utils.SendErrorResponse(c, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		sendTransitionError(c, err, "failed to approve deliverable")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{"message": "Deliverable approved"})
+}
+
+// RequestDeliverableChanges sends a submission back with feedback and reopens the task
+func RequestDeliverableChanges(c *gin.Context) {
+	task, deliverable, ok := loadReviewableDeliverable(c)
+	if !ok {
+		return
+	}
+
+	var input DeliverableReviewInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	input.Feedback = strings.TrimSpace(input.Feedback)
+	if input.Feedback == "" {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "feedback is required when requesting changes")
+		return
+	}
+
+	reviewerID := uuid.MustParse(c.GetString("userID"))
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Deliverable{}).
+			Where("id = ? AND status = ?", deliverable.ID, models.DeliverableSubmitted).
+			Updates(map[string]interface{}{
+				"status":      models.DeliverableChangesRequested,
+				"feedback":    input.Feedback,
+				"reviewed_at": time.Now(),
+				"reviewed_by": reviewerID,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errDeliverableState
+		}
+
+		if err := models.TransitionTask(tx, task, models.TaskStatusInProgress, models.StatusActor{Type: models.PrincipalUser, ID: reviewerID}); err != nil {
+			return err
+		}
+
+		taskID := task.ID
+		return tx.Create(&models.Notification{
+			RecipientType: models.PrincipalAssociate,
+			RecipientID:   deliverable.AssociateID,
+			Kind:          models.NotificationChangesRequested,
+			Title:         fmt.Sprintf("Changes requested on round %d of %s", deliverable.Round, task.Title),
+			Message:       input.Feedback,
+			TargetType:    models.CommentTargetTask,
+			TargetID:      &taskID,
+		}).Error
+	})
+	if errors.Is(err, errDeliverableState) {
+		utils.SendErrorResponse(c, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		sendTransitionError(c, err, "failed to request changes")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{"message": "Changes requested"})
+}
@@ -0,0 +1,255 @@
+package controllers
+
+import (
+	"fmt"
+	"free-flow-api/config"
+	"free-flow-api/models"
+	"free-flow-api/utils"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DepositInput struct {
+	Percent     *float64   `json:"percent" binding:"omitempty,gt=0,lte=100"`
+	Amount      *float64   `json:"amount" binding:"omitempty,gt=0"`
+	DueDate     *time.Time `json:"due_date"`
+	Description *string    `json:"description"`
+}
+
+type DepositBalance struct {
+	InvoiceID     uuid.UUID  `json:"invoice_id"`
+	InvoiceNumber string     `json:"invoice_number"`
+	Amount        float64    `json:"amount"`
+	Status        string     `json:"status"`
+	PaidDate      *time.Time `json:"paid_date"`
+	Applied       float64    `json:"applied"`
+	Available     float64    `json:"available"`
+}
+
+// projectDepositBalances lists a project's deposit invoices with how much of each has been applied.
+// Only paid deposits carry an available balance; credits on cancelled or deleted invoices are released.
+func projectDepositBalances(tx *gorm.DB, projectID uuid.UUID) ([]DepositBalance, error) {
+	var deposits []models.Invoice
+	if err := tx.
+		Where("project_id = ? AND type = ?", projectID, "deposit").
+		Order("paid_date ASC, created_at ASC").
+		Find(&deposits).Error; err != nil {
+		return nil, err
+	}
+
+	type appliedRow struct {
+		DepositInvoiceID uuid.UUID
+		Applied          float64
+	}
+	var rows []appliedRow
+	if err := tx.
+		Table("invoice_line_items AS l").
+		Joins("JOIN invoices AS i ON i.id = l.invoice_id").
+		Select("l.deposit_invoice_id, COALESCE(SUM(-l.amount), 0) AS applied").
+		Where("i.project_id = ? AND l.kind = ? AND i.status <> ? AND i.deleted_at IS NULL", projectID, "deposit_credit", "cancelled").
+		Group("l.deposit_invoice_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	applied := make(map[uuid.UUID]float64)
+	for _, r := range rows {
+		applied[r.DepositInvoiceID] = r.Applied
+	}
+
+	balances := make([]DepositBalance, 0, len(deposits))
+	for _, d := range deposits {
+		b := DepositBalance{
+			InvoiceID:     d.ID,
+			InvoiceNumber: d.InvoiceNumber,
+			Amount:        d.Amount,
+			Status:        d.Status,
+			PaidDate:      d.PaidDate,
+			Applied:       applied[d.ID],
+		}
+		if d.Status == "paid" {
+			b.Available = math.Max(d.Amount-b.Applied, 0)
+		}
+		balances = append(balances, b)
+	}
+
+	return balances, nil
+}
+
+// ApplyProjectDeposits deducts unearned deposit funds from a newly created invoice,
+// oldest deposit first, adding a credit line per deposit drawn from.
+func ApplyProjectDeposits(tx *gorm.DB, invoice *models.Invoice) error {
+	if invoice.Type == "deposit" || invoice.Amount <= 0 {
+		return nil
+	}
+
+	// lock the project's deposits so concurrent invoices can't draw the same balance twice
+	var locked []uuid.UUID
+	if err := tx.Model(&models.Invoice{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("project_id = ? AND type = ?", invoice.ProjectID, "deposit").
+		Order("id").
+		Pluck("id", &locked).Error; err != nil {
+		return err
+	}
+	if len(locked) == 0 {
+		return nil
+	}
+
+	balances, err := projectDepositBalances(tx, invoice.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	remaining := invoice.Amount
+	for _, b := range balances {
+		if remaining <= 0 {
+			break
+		}
+		if b.Available <= 0 {
+			continue
+		}
+
+		credit := math.Min(b.Available, remaining)
+		depositID := b.InvoiceID
+		line := models.InvoiceLineItem{
+			InvoiceID:        invoice.ID,
+			Kind:             "deposit_credit",
+			Description:      fmt.Sprintf("Deposit applied (%s)", b.InvoiceNumber),
+			Quantity:         1,
+			UnitPrice:        -credit,
+			Amount:           -credit,
+			DepositInvoiceID: &depositID,
+		}
+		if err := tx.Create(&line).Error; err != nil {
+			return err
+		}
+		remaining -= credit
+	}
+
+	if remaining == invoice.Amount {
+		return nil
+	}
+
+	invoice.Amount = remaining
+	return tx.Model(invoice).Update("amount", remaining).Error
+}
+
+func CreateProjectDeposit(c *gin.Context) {
+	userID := c.GetString("userID")
+	if !utils.IsAuthenticated(userID) {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid user token")
+		c.Abort()
+		return
+	}
+
+	var input DepositInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if input.Percent == nil && input.Amount == nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "either percent or amount is required")
+		return
+	}
+
+	var project models.Project
+	if err := config.DB.First(&project, "id = ? AND user_id = ?", c.Param("id"), userID).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "project not found")
+		return
+	}
+
+	amount := 0.0
+	if input.Amount != nil {
+		amount = *input.Amount
+	} else {
+		amount = project.EstimatedValue * (*input.Percent / 100.0)
+	}
+	if amount <= 0 {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "deposit amount must be greater than zero")
+		return
+	}
+
+	now := time.Now()
+	dueDate := now.AddDate(0, 0, 7)
+	if input.DueDate != nil {
+		dueDate = *input.DueDate
+	}
+	description := utils.StringOrDefault(input.Description, "Deposit for "+project.Name)
+
+	invoice := models.Invoice{
+		ProjectID:     project.ID,
+		Type:          "deposit",
+		Amount:        amount,
+		Currency:      project.Currency,
+		Status:        "draft",
+		IssueDate:     now,
+		DueDate:       dueDate,
+		Description:   description,
+		InvoiceNumber: utils.GenerateInvoiceNumber(),
+		UserID:        uuid.MustParse(userID),
+		LineItems: []models.InvoiceLineItem{{
+			Description: description,
+			Quantity:    1,
+			UnitPrice:   amount,
+			Amount:      amount,
+		}},
+	}
+
+	if err := config.DB.WithContext(c).Create(&invoice).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "could not create deposit invoice")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusCreated, gin.H{
+		"message": "deposit invoice created successfully",
+		"invoice": invoice,
+	})
+}
+
+func GetProjectDeposits(c *gin.Context) {
+	userID := c.GetString("userID")
+	if !utils.IsAuthenticated(userID) {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid user token")
+		c.Abort()
+		return
+	}
+
+	var project models.Project
+	if err := config.DB.First(&project, "id = ? AND user_id = ?", c.Param("id"), userID).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "project not found")
+		return
+	}
+
+	balances, err := projectDepositBalances(config.DB, project.ID)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch project deposits")
+		return
+	}
+
+	var requested, received, applied, unearned float64
+	for _, b := range balances {
+		requested += b.Amount
+		applied += b.Applied
+		if b.Status == "paid" {
+			received += b.Amount
+			unearned += b.Available
+		}
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"project_id": project.ID,
+		"currency":   project.Currency,
+		"requested":  requested,
+		"received":   received,
+		"applied":    applied,
+		"unearned":   unearned,
+		"deposits":   balances,
+	})
+}
@@ -45,7 +45,7 @@ func NewEntity(c *gin.Context) {
 		UserID:      uuid.MustParse(userID),
 	}
 
-	if err := config.DB.Create(&entity).Error; err != nil {
+	if err := config.DB.WithContext(c).Create(&entity).Error; err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to create an entity")
 		return
 	}
@@ -149,7 +149,7 @@ func UpdateEntity(c *gin.Context) {
 
 	updates.Email = strings.ToLower(strings.TrimSpace(updates.Email))
 
-	if err := config.DB.Model(&entity).Updates(updates).Error; err != nil {
+	if err := config.DB.WithContext(c).Model(&entity).Updates(updates).Error; err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to update entity")
 		return
 	}
@@ -184,7 +184,7 @@ func DeleteEntity(c *gin.Context) {
 	}
 
 	// 4. Soft delete (sets DeletedAt, doesn’t remove row)
-	if err := config.DB.Delete(&entity).Error; err != nil {
+	if err := config.DB.WithContext(c).Delete(&entity).Error; err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to delete entity")
 		return
 	}
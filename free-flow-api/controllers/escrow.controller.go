@@ -0,0 +1,379 @@
+package controllers
+
+import (
+	"errors"
+	"free-flow-api/config"
+	"free-flow-api/models"
+	"free-flow-api/utils"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type EscrowInput struct {
+	Amount         float64 `json:"amount" binding:"required,gt=0"`
+	TransactionRef string  `json:"transaction_ref"`
+	Notes          string  `json:"notes"`
+}
+
+type EscrowActionInput struct {
+	TransactionRef string `json:"transaction_ref"`
+	Notes          string `json:"notes"`
+}
+
+type EscrowSummary struct {
+	Funded   float64 `json:"funded"`
+	Released float64 `json:"released"`
+	Settled  float64 `json:"settled"`
+	Refunded float64 `json:"refunded"`
+	Held     float64 `json:"held"`
+}
+
+var (
+	errNothingHeld        = errors.New("no funds held in escrow for this milestone")
+	errEscrowNotApproved  = errors.New("milestone must be approved before escrow is released")
+	errEscrowNotCancelled = errors.New("only cancelled milestones can be refunded")
+)
+
+// loadEscrowMilestone resolves the caller (freelancer or the SafeCollab service) and the milestone they act on
+func loadEscrowMilestone(c *gin.Context) (*models.Milestone, *models.Project, string, bool) {
+	source := "user"
+	userID := c.GetString("userID")
+
+	if c.GetString("service") != "" {
+		source = c.GetString("service")
+	} else if !utils.IsAuthenticated(userID) {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid user token")
+		c.Abort()
+		return nil, nil, "", false
+	}
+
+	milestoneID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "invalid milestone id format")
+		return nil, nil, "", false
+	}
+
+	var milestone models.Milestone
+	if err := config.DB.First(&milestone, "id = ?", milestoneID).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "milestone not found")
+		return nil, nil, "", false
+	}
+
+	var project models.Project
+	if err := config.DB.First(&project, "id = ?", milestone.ProjectID).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "project not found")
+		return nil, nil, "", false
+	}
+
+	if source == "user" && project.UserID.String() != userID {
+		utils.SendErrorResponse(c, http.StatusForbidden, "access denied")
+		return nil, nil, "", false
+	}
+
+	return &milestone, &project, source, true
+}
+
+func GetMilestoneEscrow(c *gin.Context) {
+	milestone, _, _, ok := loadEscrowMilestone(c)
+	if !ok {
+		return
+	}
+
+	var entries []models.EscrowTransaction
+	if err := config.DB.
+		Where("milestone_id = ?", milestone.ID).
+		Order("created_at ASC").
+		Find(&entries).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch escrow ledger")
+		return
+	}
+
+	var summary EscrowSummary
+	for _, e := range entries {
+		switch e.Type {
+		case models.EscrowFund:
+			summary.Funded += e.Amount
+		case models.EscrowRelease:
+			summary.Released += e.Amount
+		case models.EscrowSettlement:
+			summary.Settled += e.Amount
+		case models.EscrowRefund:
+			summary.Refunded += e.Amount
+		}
+	}
+	summary.Held = summary.Funded - summary.Released - summary.Settled - summary.Refunded
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"milestone_id": milestone.ID,
+		"summary":      summary,
+		"ledger":       entries,
+	})
+}
+
+func FundMilestoneEscrow(c *gin.Context) {
+	milestone, project, source, ok := loadEscrowMilestone(c)
+	if !ok {
+		return
+	}
+
+	var input EscrowInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if milestone.Status == "cancelled" {
+		utils.SendErrorResponse(c, http.StatusConflict, "cannot fund a cancelled milestone")
+		return
+	}
+
+	entry := models.EscrowTransaction{
+		UserID:         project.UserID,
+		ProjectID:      project.ID,
+		MilestoneID:    milestone.ID,
+		Type:           models.EscrowFund,
+		Amount:         input.Amount,
+		Currency:       project.Currency,
+		TransactionRef: input.TransactionRef,
+		Source:         source,
+		Notes:          input.Notes,
+	}
+
+	if err := config.DB.WithContext(c).Create(&entry).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to record escrow funding")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusCreated, gin.H{
+		"message": "escrow funded successfully",
+		"entry":   entry,
+	})
+}
+
+// ReleaseMilestoneEscrow pays out associate settlements for the milestone's tasks first
+// and books whatever remains as revenue against the milestone invoice.
+func ReleaseMilestoneEscrow(c *gin.Context) {
+	milestone, project, source, ok := loadEscrowMilestone(c)
+	if !ok {
+		return
+	}
+
+	var input EscrowActionInput
+	_ = c.ShouldBindJSON(&input)
+
+	var entries []models.EscrowTransaction
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		// approval and the invoice link are checked on the locked row, not the one loaded above
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(milestone, "id = ?", milestone.ID).Error; err != nil {
+			return err
+		}
+		if milestone.ClientApprovedAt == nil {
+			return errEscrowNotApproved
+		}
+
+		held, err := models.EscrowHeldBalance(tx, milestone.ID)
+		if err != nil {
+			return err
+		}
+		if held <= 0 {
+			return errNothingHeld
+		}
+
+		now := time.Now()
+		base := models.EscrowTransaction{
+			UserID:         project.UserID,
+			ProjectID:      project.ID,
+			MilestoneID:    milestone.ID,
+			Currency:       project.Currency,
+			TransactionRef: input.TransactionRef,
+			Source:         source,
+			Notes:          input.Notes,
+		}
+
+		// 1. settle associates whose work on this milestone has been approved
+		var settlements []models.AssociateSettlement
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("task_id IN (?)", tx.Model(&models.Task{}).Select("id").Where("milestone_id = ?", milestone.ID)).
+			Where("status <> ? AND payable_at IS NOT NULL", "settled").
+			Order("created_at ASC").
+			Find(&settlements).Error; err != nil {
+			return err
+		}
+
+		for _, s := range settlements {
+			due := float64(s.ExpectedAmount - s.SettledAmount)
+			// settlements are kept in whole units; the ledger records exactly what was settled
+			// and any fraction left over is released with the remainder
+			pay := math.Floor(math.Min(due, held))
+			if pay <= 0 {
+				continue
+			}
+			if err := requirePayoutVerification(tx, s.AssociateID); err != nil {
+				return err
+			}
+
+			s.SettledAmount += int64(pay)
+			s.Method = "escrow"
+			s.TransactionRef = input.TransactionRef
+			if s.SettledAmount >= s.ExpectedAmount {
+				s.Status = "settled"
+				s.SettledAt = &now
+			} else {
+				s.Status = "partially_settled"
+			}
+			if err := tx.Save(&s).Error; err != nil {
+				return err
+			}
+			if err := models.RecordSettlementPayment(tx, s, int64(pay), now); err != nil {
+				return err
+			}
+
+			settlementID := s.ID
+			entry := base
+			entry.Type = models.EscrowSettlement
+			entry.Amount = pay
+			entry.SettlementID = &settlementID
+			if err := tx.Create(&entry).Error; err != nil {
+				return err
+			}
+			entries = append(entries, entry)
+			held -= pay
+		}
+
+		if held <= 0 {
+			return nil
+		}
+
+		// 2. release the remainder to revenue
+		entry := base
+		entry.Type = models.EscrowRelease
+		entry.Amount = held
+
+		if milestone.InvoiceID != nil {
+			payment, err := recordEscrowPayment(tx, *milestone.InvoiceID, held, input.TransactionRef)
+			if err != nil {
+				return err
+			}
+			entry.PaymentID = &payment.ID
+		}
+
+		if err := tx.Create(&entry).Error; err != nil {
+			return err
+		}
+		entries = append(entries, entry)
+		return nil
+	})
+
+	if errors.Is(err, errNothingHeld) || errors.Is(err, errEscrowNotApproved) || errors.Is(err, errPayoutNotVerified) {
+		utils.SendErrorResponse(c, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		sendTransitionError(c, err, "failed to release escrow")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"message": "escrow released successfully",
+		"entries": entries,
+	})
+}
+
+func RefundMilestoneEscrow(c *gin.Context) {
+	milestone, project, source, ok := loadEscrowMilestone(c)
+	if !ok {
+		return
+	}
+
+	var input EscrowActionInput
+	_ = c.ShouldBindJSON(&input)
+
+	var entry models.EscrowTransaction
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(milestone, "id = ?", milestone.ID).Error; err != nil {
+			return err
+		}
+		if milestone.Status != "cancelled" {
+			return errEscrowNotCancelled
+		}
+
+		held, err := models.EscrowHeldBalance(tx, milestone.ID)
+		if err != nil {
+			return err
+		}
+		if held <= 0 {
+			return errNothingHeld
+		}
+
+		entry = models.EscrowTransaction{
+			UserID:         project.UserID,
+			ProjectID:      project.ID,
+			MilestoneID:    milestone.ID,
+			Type:           models.EscrowRefund,
+			Amount:         held,
+			Currency:       project.Currency,
+			TransactionRef: input.TransactionRef,
+			Source:         source,
+			Notes:          input.Notes,
+		}
+		return tx.Create(&entry).Error
+	})
+
+	if errors.Is(err, errNothingHeld) || errors.Is(err, errEscrowNotCancelled) {
+		utils.SendErrorResponse(c, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to refund escrow")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"message": "escrow refunded successfully",
+		"entry":   entry,
+	})
+}
+
+// recordEscrowPayment books released escrow as a confirmed payment and marks the invoice paid once covered
+func recordEscrowPayment(tx *gorm.DB, invoiceID uuid.UUID, amount float64, ref string) (*models.Payment, error) {
+	var invoice models.Invoice
+	if err := tx.First(&invoice, "id = ?", invoiceID).Error; err != nil {
+		return nil, err
+	}
+
+	payment := models.Payment{
+		InvoiceID:      invoice.ID,
+		Amount:         amount,
+		Currency:       invoice.Currency,
+		Method:         "escrow",
+		TransactionRef: ref,
+		PaidDate:       time.Now(),
+		Status:         "confirmed",
+		UserID:         invoice.UserID,
+	}
+	if err := tx.Create(&payment).Error; err != nil {
+		return nil, err
+	}
+
+	var totalPaid float64
+	if err := tx.Model(&models.Payment{}).
+		Where("invoice_id = ? AND status != ?", invoice.ID, "failed").
+		Select("COALESCE(SUM(amount), 0)").
+		Scan(&totalPaid).Error; err != nil {
+		return nil, err
+	}
+
+	if totalPaid >= invoice.Amount {
+		if err := models.TransitionInvoice(tx, &invoice, models.InvoiceStatusPaid, models.StatusActor{Type: models.PrincipalUser, ID: invoice.UserID}); err != nil {
+			return nil, err
+		}
+	}
+
+	return &payment, nil
+}
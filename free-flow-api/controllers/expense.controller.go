@@ -20,6 +20,7 @@ type ExpenseInput struct {
 	Date        time.Time `json:"date"`
 	ReceiptURL  *string   `json:"receipt_url"`
 	Vendor      *string   `json:"vendor"`
+	Billable    *bool     `json:"billable"`
 }
 
 type ExpenseWithProject struct {
@@ -62,8 +63,11 @@ func CreateExpense(c *gin.Context) {
 		Vendor:      utils.StringOrDefault(input.Vendor, ""),
 		UserID:      uuid.MustParse(userID),
 	}
+	if input.Billable != nil {
+		expense.Billable = *input.Billable
+	}
 
-	if err := config.DB.Create(&expense).Error; err != nil {
+	if err := config.DB.WithContext(c).Create(&expense).Error; err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, "could not create expense")
 		return
 	}
@@ -159,6 +163,10 @@ func UpdateExpense(c *gin.Context) {
 		utils.SendErrorResponse(c, http.StatusNotFound, "expense not found")
 		return
 	}
+	if expense.InvoiceID != nil {
+		utils.SendErrorResponse(c, http.StatusConflict, "expense has already been billed")
+		return
+	}
 
 	var input ExpenseInput
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -184,8 +192,11 @@ func UpdateExpense(c *gin.Context) {
 	if input.Vendor != nil {
 		expense.Vendor = *input.Vendor
 	}
+	if input.Billable != nil {
+		expense.Billable = *input.Billable
+	}
 
-	if err := config.DB.Save(&expense).Error; err != nil {
+	if err := config.DB.WithContext(c).Save(&expense).Error; err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, "could not update expense")
 		return
 	}
@@ -203,7 +214,17 @@ func DeleteExpense(c *gin.Context) {
 
 	id := c.Param("id")
 
-	if err := config.DB.Delete(&models.Expense{}, "id = ?", id).Error; err != nil {
+	var expense models.Expense
+	if err := config.DB.First(&expense, "id = ?", id).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "expense not found")
+		return
+	}
+	if expense.InvoiceID != nil {
+		utils.SendErrorResponse(c, http.StatusConflict, "expense has already been billed")
+		return
+	}
+
+	if err := config.DB.WithContext(c).Delete(&expense).Error; err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, "could not delete expense")
 		return
 	}
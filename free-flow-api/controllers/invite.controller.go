@@ -12,32 +12,44 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-func InviteAssociate(task models.Task, associateID uuid.UUID, contractID uuid.UUID) error {
-	invite := models.Invite{
-		TaskID:      task.ID,
-		ProjectID:   task.ProjectID,
-		AssociateID: associateID,
-		ContractID:  contractID,
-		Status:      "pending",
-		CreatedAt:   time.Now(),
+func InviteAssociate(tx *gorm.DB, task models.Task, associateID uuid.UUID, contractID uuid.UUID) error {
+	_, err := sendInvite(tx, task, associateID, contractID, nil)
+	return err
+}
+
+// sendInvite creates the invite record and issues its link
+func sendInvite(tx *gorm.DB, task models.Task, associateID uuid.UUID, contractID uuid.UUID, offerID *uuid.UUID) (*models.Invite, error) {
+	// invites always offer the latest terms
+	var contract models.Contract
+	if err := tx.Select("id", "current_version").First(&contract, "id = ?", contractID).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch contract: %w", err)
 	}
 
-	if err := config.DB.Create(&invite).Error; err != nil {
-		return fmt.Errorf("failed to create invite: %w", err)
+	invite := models.Invite{
+		TaskID:          task.ID,
+		ProjectID:       task.ProjectID,
+		AssociateID:     associateID,
+		ContractID:      contractID,
+		OfferID:         offerID,
+		ContractVersion: contract.CurrentVersion,
+		Status:          "pending",
+		TokenID:         uuid.NewString(),
+		ExpiresAt:       time.Now().Add(models.InviteTTL),
+		CreatedAt:       time.Now(),
 	}
 
-	// generate invite token
-	token, err := config.GenerateInviteToken(invite.ID.String(), associateID.String(), contractID.String(), task.ID.String(), 72*time.Hour)
-	if err != nil {
-		return err
+	if err := tx.Create(&invite).Error; err != nil {
+		return nil, fmt.Errorf("failed to create invite: %w", err)
 	}
 
-	//send mail to associate right after
-	fmt.Println(token)
+	if err := models.NotifyInvite(tx, invite, models.NotificationInviteSent, "You have a new task invite"); err != nil {
+		return nil, err
+	}
 
-	return nil
+	return &invite, nil
 }
 
 func InviteResponse(c *gin.Context) {
@@ -62,35 +74,107 @@ func InviteResponse(c *gin.Context) {
 	}
 
 	var input struct {
-		Status string `json:"status" binding:"required,oneof=accepted declined"`
+		Status    string          `json:"status" binding:"required,oneof=accepted declined"`
+		Signature *SignatureInput `json:"signature"`
 	}
 	if err := c.ShouldBindJSON(&input); err != nil {
 		utils.SendErrorResponse(c, http.StatusBadRequest, "invalid request body")
 		return
 	}
 
+	// accepting means signing the contract
+	if input.Status == "accepted" {
+		if input.Signature == nil {
+			utils.SendErrorResponse(c, http.StatusBadRequest, "a signature is required to accept the contract")
+			return
+		}
+		if err := input.Signature.Validate(); err != nil {
+			utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
 	var invite models.Invite
 	if err := config.DB.First(&invite, "id = ?", inviteID).Error; err != nil {
 		utils.SendErrorResponse(c, http.StatusNotFound, "invite not found")
 		return
 	}
 
+	if invite.IsExpired() {
+		config.DB.WithContext(c).Model(&invite).Update("status", "expired")
+		utils.SendErrorResponse(c, http.StatusGone, "invite has expired")
+		return
+	}
+
+	if invite.Status != "pending" {
+		utils.SendErrorResponse(c, http.StatusConflict, "invite is no longer pending ("+invite.Status+")")
+		return
+	}
+
+	var contract models.Contract
+	if err := config.DB.First(&contract, "id = ?", invite.ContractID).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "contract not found")
+		return
+	}
+
+	// the terms changed after this invite was sent
+	if invite.ContractVersion != contract.CurrentVersion {
+		utils.SendErrorResponse(c, http.StatusConflict, "contract terms have changed, please review the latest version")
+		return
+	}
+
+	// invites sent as part of a task offer follow the offer's rules
+	var offer *models.TaskOffer
+	if invite.OfferID != nil {
+		var o models.TaskOffer
+		if err := config.DB.First(&o, "id = ?", *invite.OfferID).Error; err != nil {
+			utils.SendErrorResponse(c, http.StatusNotFound, "offer not found")
+			return
+		}
+		awardedHere := o.AwardedTo != nil && *o.AwardedTo == invite.AssociateID
+		if input.Status == "accepted" && !awardedHere {
+			if o.Status != "open" {
+				utils.SendErrorResponse(c, http.StatusConflict, "offer is no longer open")
+				return
+			}
+			if o.Mode == models.OfferModeBid {
+				utils.SendErrorResponse(c, http.StatusConflict, "this offer takes bids, submit a bid instead")
+				return
+			}
+		}
+		offer = &o
+	}
+
 	invite.Status = input.Status
 	invite.RespondedAt = time.Now()
 
-	tx := config.DB.Begin()
+	tx := config.DB.WithContext(c).Begin()
 	if tx.Error != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to start transaction")
 		return
 	}
 
-	if err := tx.Save(&invite).Error; err != nil {
+	// only one response wins: a concurrent accept or decline finds the invite no longer pending
+	result := tx.Model(&models.Invite{}).
+		Where("id = ? AND status = ?", invite.ID, "pending").
+		Updates(map[string]interface{}{
+			"status":       invite.Status,
+			"responded_at": invite.RespondedAt,
+		})
+	if result.Error != nil {
 		tx.Rollback()
 		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to update invite")
 		return
 	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		utils.SendErrorResponse(c, http.StatusConflict, "invite is no longer pending")
+		return
+	}
+
+	var signatureID *uuid.UUID
 
-	// If accepted, update task assignment
+	// If accepted, update task assignment, sign and lock the accepted terms
 	if input.Status == "accepted" {
 		if err := tx.Model(&models.Task{}).
 			Where("id = ?", invite.TaskID).
@@ -99,6 +183,61 @@ func InviteResponse(c *gin.Context) {
 			utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to assign associate to task")
 			return
 		}
+
+		signature, err := signContractVersion(tx, c, invite, *input.Signature)
+		if err != nil {
+			tx.Rollback()
+			if errors.Is(err, errDocumentChanged) {
+				utils.SendErrorResponse(c, http.StatusConflict, err.Error())
+				return
+			}
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to sign contract")
+			return
+		}
+		signatureID = &signature.ID
+
+		if err := acceptContractVersion(tx, invite.ContractID, invite.ContractVersion, invite.AssociateID); err != nil {
+			tx.Rollback()
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to accept contract version")
+			return
+		}
+
+		// first to accept wins an open offer
+		if offer != nil && offer.Status == "open" {
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(offer, "id = ?", offer.ID).Error; err != nil {
+				tx.Rollback()
+				utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch offer")
+				return
+			}
+			if offer.Status != "open" {
+				tx.Rollback()
+				utils.SendErrorResponse(c, http.StatusConflict, "offer has already been taken")
+				return
+			}
+			if err := awardOffer(tx, offer, invite.AssociateID, nil); err != nil {
+				tx.Rollback()
+				utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to award offer")
+				return
+			}
+		}
+	}
+
+	// The awarded associate backing out releases the task again
+	if input.Status == "declined" && offer != nil && offer.AwardedTo != nil && *offer.AwardedTo == invite.AssociateID {
+		if err := releaseAwardedOffer(tx, offer); err != nil {
+			tx.Rollback()
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to release task offer")
+			return
+		}
+	}
+
+	// A declined amendment leaves the previously accepted terms in force
+	if input.Status == "declined" && contract.AmendmentPending {
+		if err := rejectContractAmendment(tx, invite.ContractID); err != nil {
+			tx.Rollback()
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to restore accepted contract terms")
+			return
+		}
 	}
 
 	if err := tx.Commit().Error; err != nil {
@@ -107,8 +246,9 @@ func InviteResponse(c *gin.Context) {
 	}
 
 	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
-		"message": "Invite updated successfully",
-		"status":  invite.Status,
+		"message":      "Invite updated successfully",
+		"status":       invite.Status,
+		"signature_id": signatureID,
 	})
 }
 
@@ -148,11 +288,24 @@ func GetInviteDetails(c *gin.Context) {
 		return
 	}
 
+	// The exact document the associate is asked to sign
+	var version models.ContractVersion
+	if err := config.DB.First(&version, "contract_id = ? AND version = ?", invite.ContractID, invite.ContractVersion).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch contract version")
+		return
+	}
+
 	// Flatten and clean up the data for frontend use
 	response := gin.H{
-		"invite_id":    invite.ID,
-		"status":       invite.Status,
-		"responded_at": invite.RespondedAt,
+		"invite_id":        invite.ID,
+		"status":           invite.Status,
+		"contract_version": invite.ContractVersion,
+		"responded_at":     invite.RespondedAt,
+
+		"document": gin.H{
+			"text": version.Document(),
+			"hash": version.DocumentHash(),
+		},
 
 		"freelancer": gin.H{
 			"id":         invite.Associate.ID,
@@ -212,6 +365,12 @@ func GetInvitesByProjectID(c *gin.Context) {
 		return
 	}
 
+	// keep statuses current without waiting for the background job
+	if _, err := models.ExpireStaleInvites(config.DB.WithContext(c)); err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to refresh invites")
+		return
+	}
+
 	var invites []models.Invite
 	if err := config.DB.
 		Preload("Associate").
@@ -227,6 +386,7 @@ func GetInvitesByProjectID(c *gin.Context) {
 		ID          uuid.UUID `json:"id"`
 		Status      string    `json:"status"`
 		RespondedAt time.Time `json:"responded_at"`
+		ExpiresAt   time.Time `json:"expires_at"`
 		Associate   struct {
 			ID     uuid.UUID `json:"id"`
 			Name   string    `json:"name"`
@@ -259,6 +419,7 @@ func GetInvitesByProjectID(c *gin.Context) {
 			ID:          inv.ID,
 			Status:      inv.Status,
 			RespondedAt: inv.RespondedAt,
+			ExpiresAt:   inv.ExpiresAt,
 		}
 
 		cleanInv.Associate = struct {
@@ -314,3 +475,100 @@ func GetInvitesByProjectID(c *gin.Context) {
 
 	c.JSON(http.StatusOK, cleanInvites)
 }
+
+// loadOwnedInvite fetches an invite on one of the freelancer's projects
+func loadOwnedInvite(c *gin.Context) (*models.Invite, bool) {
+	userID := c.GetString("userID")
+	if !utils.IsAuthenticated(userID) {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid user token")
+		c.Abort()
+		return nil, false
+	}
+
+	var invite models.Invite
+	if err := config.DB.
+		Joins("JOIN projects ON projects.id = invites.project_id").
+		Where("invites.id = ? AND projects.user_id = ?", c.Param("id"), userID).
+		First(&invite).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "invite not found")
+		return nil, false
+	}
+
+	return &invite, true
+}
+
+// ResendInvite issues a fresh link for a pending or expired invite; earlier links stop working
+func ResendInvite(c *gin.Context) {
+	invite, ok := loadOwnedInvite(c)
+	if !ok {
+		return
+	}
+
+	if invite.Status != "pending" && invite.Status != "expired" {
+		utils.SendErrorResponse(c, http.StatusConflict, "only pending or expired invites can be resent")
+		return
+	}
+
+	var contract models.Contract
+	if err := config.DB.Select("id", "current_version").First(&contract, "id = ?", invite.ContractID).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "contract not found")
+		return
+	}
+
+	invite.Status = "pending"
+	invite.ContractVersion = contract.CurrentVersion
+	invite.TokenID = uuid.NewString()
+	invite.ExpiresAt = time.Now().Add(models.InviteTTL)
+	invite.ReminderSentAt = nil
+
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(invite).Error; err != nil {
+			return err
+		}
+		return models.NotifyInvite(tx, *invite, models.NotificationInviteSent, "Your task invite was resent")
+	})
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to resend invite")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"message":    "Invite resent successfully",
+		"expires_at": invite.ExpiresAt,
+	})
+}
+
+// RevokeInvite withdraws a pending invite so its link can no longer be used
+func RevokeInvite(c *gin.Context) {
+	invite, ok := loadOwnedInvite(c)
+	if !ok {
+		return
+	}
+
+	if invite.Status != "pending" {
+		utils.SendErrorResponse(c, http.StatusConflict, "only pending invites can be revoked")
+		return
+	}
+
+	now := time.Now()
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(invite).Updates(map[string]interface{}{
+			"status":     "revoked",
+			"revoked_at": now,
+		}).Error; err != nil {
+			return err
+		}
+
+		// a withdrawn amendment leaves the accepted terms in force
+		return rejectContractAmendment(tx, invite.ContractID)
+	})
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to revoke invite")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"message":    "Invite revoked successfully",
+		"revoked_at": now,
+	})
+}
@@ -5,11 +5,12 @@ import (
 	"free-flow-api/models"
 	"free-flow-api/utils"
 	"net/http"
-	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type InvoiceInput struct {
@@ -37,34 +38,52 @@ func CreateInvoice(c *gin.Context) {
 		return
 	}
 
+	// later statuses are reached through payments and updates, which stamp what goes with them
+	status := utils.StringOrDefault(input.Status, models.InvoiceStatusDraft)
+	if err := models.CheckInitialStatus(models.StatusEntityInvoice, status); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	var project models.Project
 	if err := config.DB.First(&project, "id = ?", input.ProjectID).Error; err != nil {
 		utils.SendErrorResponse(c, http.StatusNotFound, "Project Not found")
 		return
 	}
 
-	prefix := "INV-FF-"
-	id := uuid.New().String()
-	short_id := id[:8]
-	inv := prefix + short_id
-	invoice_number := strings.ToUpper(inv)
-
 	invoice := models.Invoice{
 		ProjectID:      input.ProjectID,
 		Amount:         project.ActualValue,
 		Currency:       utils.StringOrDefault(&project.Currency, *input.Currency),
-		Status:         utils.StringOrDefault(input.Status, "draft"),
+		Status:         status,
 		DueDate:        input.DueDate,
 		Description:    utils.StringOrDefault(input.Description, ""),
 		Notes:          utils.StringOrDefault(input.Notes, ""),
-		InvoiceNumber:  invoice_number,
+		InvoiceNumber:  utils.GenerateInvoiceNumber(),
 		PaymentMethod:  input.PaymentMethod,
 		TransactionRef: input.TransactionRef,
 		IssueDate:      time.Now(),
 		UserID:         uuid.MustParse(userID),
+		LineItems: []models.InvoiceLineItem{{
+			Description: project.Name,
+			Quantity:    1,
+			UnitPrice:   project.ActualValue,
+			Amount:      project.ActualValue,
+		}},
 	}
 
-	if err := config.DB.Create(&invoice).Error; err != nil {
+	// paid deposits on the project are deducted from the new invoice
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&invoice).Error; err != nil {
+			return err
+		}
+		actor := models.StatusActor{Type: models.PrincipalUser, ID: invoice.UserID}
+		if err := models.RecordStatusChange(tx, models.StatusEntityInvoice, invoice.ID, invoice.ProjectID, "", invoice.Status, actor); err != nil {
+			return err
+		}
+		return ApplyProjectDeposits(tx, &invoice)
+	})
+	if err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, "could not create invoice")
 		return
 	}
@@ -76,6 +95,78 @@ func CreateInvoice(c *gin.Context) {
 	utils.SendSuccessResponse(c, http.StatusCreated, data)
 }
 
+// DraftMilestoneInvoice creates a draft invoice for a billable milestone, billed to the project's
+// freelancer, and links the two. It is a no-op when the milestone has already been invoiced or has
+// nothing to bill.
+func DraftMilestoneInvoice(tx *gorm.DB, milestone *models.Milestone) (*models.Invoice, error) {
+	// lock the milestone so concurrent completion and approval can't both invoice it
+	var locked models.Milestone
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id", "invoice_id").
+		First(&locked, "id = ?", milestone.ID).Error; err != nil {
+		return nil, err
+	}
+	if locked.InvoiceID != nil {
+		milestone.InvoiceID = locked.InvoiceID
+		return nil, nil
+	}
+
+	var project models.Project
+	if err := tx.First(&project, "id = ?", milestone.ProjectID).Error; err != nil {
+		return nil, err
+	}
+
+	amount := milestone.BillableAmount(project)
+	if amount <= 0 {
+		return nil, nil
+	}
+
+	now := time.Now()
+	dueDate := now.AddDate(0, 0, 14)
+	milestoneID := milestone.ID
+	description := "Milestone: " + milestone.Title
+
+	invoice := models.Invoice{
+		ProjectID:     project.ID,
+		MilestoneID:   &milestoneID,
+		Amount:        amount,
+		Currency:      project.Currency,
+		Status:        "draft",
+		IssueDate:     now,
+		DueDate:       dueDate,
+		Description:   description,
+		InvoiceNumber: utils.GenerateInvoiceNumber(),
+		UserID:        project.UserID,
+		LineItems: []models.InvoiceLineItem{{
+			Description: description,
+			Quantity:    1,
+			UnitPrice:   amount,
+			Amount:      amount,
+		}},
+	}
+
+	if err := tx.Create(&invoice).Error; err != nil {
+		return nil, err
+	}
+	actor := models.StatusActor{Type: models.PrincipalUser, ID: invoice.UserID}
+	if err := models.RecordStatusChange(tx, models.StatusEntityInvoice, invoice.ID, invoice.ProjectID, "", invoice.Status, actor); err != nil {
+		return nil, err
+	}
+
+	if err := ApplyProjectDeposits(tx, &invoice); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Model(milestone).Updates(map[string]interface{}{
+		"invoice_id":     invoice.ID,
+		"billing_status": "invoiced",
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	return &invoice, nil
+}
+
 // GetInvoices godoc
 func GetInvoices(c *gin.Context) {
 	userID := c.GetString("userID")
@@ -123,7 +214,7 @@ func GetInvoiceByID(c *gin.Context) {
 	id := c.Param("id")
 
 	var invoice models.Invoice
-	if err := config.DB.First(&invoice, "id = ?", id).Error; err != nil {
+	if err := config.DB.Preload("LineItems").First(&invoice, "id = ?", id).Error; err != nil {
 		utils.SendErrorResponse(c, http.StatusNotFound, "invoice not found")
 		return
 	}
@@ -156,9 +247,6 @@ func UpdateInvoice(c *gin.Context) {
 	if input.Currency != nil {
 		invoice.Currency = *input.Currency
 	}
-	if input.Status != nil {
-		invoice.Status = *input.Status
-	}
 	if !input.DueDate.IsZero() {
 		invoice.DueDate = input.DueDate
 	}
@@ -175,8 +263,18 @@ func UpdateInvoice(c *gin.Context) {
 		invoice.TransactionRef = input.TransactionRef
 	}
 
-	if err := config.DB.Save(&invoice).Error; err != nil {
-		utils.SendErrorResponse(c, http.StatusInternalServerError, "could not update invoice")
+	// the status moves through the invoice transition graph after the other fields are saved
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&invoice).Error; err != nil {
+			return err
+		}
+		if input.Status == nil {
+			return nil
+		}
+		return models.TransitionInvoice(tx, &invoice, *input.Status, models.StatusActor{Type: models.PrincipalUser, ID: uuid.MustParse(userID)})
+	})
+	if err != nil {
+		sendTransitionError(c, err, "could not update invoice")
 		return
 	}
 
@@ -193,7 +291,14 @@ func DeleteInvoice(c *gin.Context) {
 
 	id := c.Param("id")
 
-	if err := config.DB.Delete(&models.Invoice{}, "id = ?", id).Error; err != nil {
+	// load it first so billed time and expenses are released by the delete hook
+	var invoice models.Invoice
+	if err := config.DB.First(&invoice, "id = ?", id).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "invoice not found")
+		return
+	}
+
+	if err := config.DB.WithContext(c).Delete(&invoice).Error; err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, "could not delete invoice")
 		return
 	}
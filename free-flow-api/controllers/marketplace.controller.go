@@ -0,0 +1,666 @@
+package controllers
+
+import (
+	"errors"
+	"free-flow-api/config"
+	"free-flow-api/models"
+	"free-flow-api/utils"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+type JobPostingInput struct {
+	TaskID      uuid.UUID  `json:"task_id" binding:"required"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Skills      []string   `json:"skills"`
+	Budget      *float64   `json:"budget" binding:"omitempty,gt=0"`
+	Currency    string     `json:"currency"`
+	Deadline    *time.Time `json:"deadline"`
+	Visibility  string     `json:"visibility" binding:"omitempty,oneof=public private"`
+}
+
+type JobPostingUpdateInput struct {
+	Title       *string    `json:"title"`
+	Description *string    `json:"description"`
+	Skills      []string   `json:"skills"`
+	Budget      *float64   `json:"budget" binding:"omitempty,gt=0"`
+	Deadline    *time.Time `json:"deadline"`
+	Visibility  *string    `json:"visibility" binding:"omitempty,oneof=public private"`
+}
+
+type JobApplicationInput struct {
+	Proposal       string     `json:"proposal" binding:"required"`
+	Price          *float64   `json:"price" binding:"omitempty,gt=0"`
+	EstimatedHours *float64   `json:"estimated_hours" binding:"omitempty,gt=0"`
+	DeliveryDate   *time.Time `json:"delivery_date"`
+}
+
+type MarketplaceStatusInput struct {
+	Status string `json:"status" binding:"required,oneof=inactive pending approved suspended"`
+	Notes  string `json:"notes"`
+}
+
+// normalizeSkills lowercases, trims and de-duplicates skills so overlap matching is reliable
+func normalizeSkills(skills []string) []string {
+	seen := make(map[string]bool)
+	out := []string{}
+	for _, s := range skills {
+		s = strings.ToLower(strings.TrimSpace(s))
+		if s == "" || seen[s] {
+			continue
+		}
+		seen[s] = true
+		out = append(out, s)
+	}
+	return out
+}
+
+// loadMarketplaceAssociate resolves the calling associate and requires approved marketplace access
+func loadMarketplaceAssociate(c *gin.Context) (*models.Associate, *models.AssociateProfile, bool) {
+	associateID := c.GetString("userID")
+	if !utils.IsAssociateAuthenticated(associateID) {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid associate token")
+		c.Abort()
+		return nil, nil, false
+	}
+
+	var associate models.Associate
+	if err := config.DB.Preload("Profile").First(&associate, "id = ?", associateID).Error; err != nil || associate.Profile == nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "associate not found")
+		return nil, nil, false
+	}
+
+	profile := associate.Profile
+	if profile.HasMarketplaceAccess == nil || !*profile.HasMarketplaceAccess ||
+		profile.MarketplaceStatus == nil || *profile.MarketplaceStatus != models.MarketplaceApproved {
+		utils.SendErrorResponse(c, http.StatusForbidden, "marketplace access has not been approved")
+		return nil, nil, false
+	}
+	if !profile.HasVerificationLevel(models.MarketplaceVerificationLevel) {
+		utils.SendErrorResponse(c, http.StatusForbidden, "identity verification is required for marketplace access")
+		return nil, nil, false
+	}
+
+	return &associate, profile, true
+}
+
+// loadOwnedPosting fetches a posting created by the authenticated freelancer
+func loadOwnedPosting(c *gin.Context) (*models.JobPosting, bool) {
+	userID := c.GetString("userID")
+	if !utils.IsAuthenticated(userID) {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid user token")
+		c.Abort()
+		return nil, false
+	}
+
+	var posting models.JobPosting
+	if err := config.DB.First(&posting, "id = ? AND user_id = ?", c.Param("id"), userID).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "job posting not found")
+		return nil, false
+	}
+
+	return &posting, true
+}
+
+func CreateJobPosting(c *gin.Context) {
+	userID := c.GetString("userID")
+	if !utils.IsAuthenticated(userID) {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid user token")
+		c.Abort()
+		return
+	}
+
+	var input JobPostingInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var task models.Task
+	if err := config.DB.
+		Preload("Project").
+		Joins("JOIN projects ON projects.id = tasks.project_id").
+		Where("tasks.id = ? AND projects.user_id = ?", input.TaskID, userID).
+		First(&task).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "task not found")
+		return
+	}
+
+	if task.AssignedToAssociate != nil {
+		utils.SendErrorResponse(c, http.StatusConflict, "task is already assigned")
+		return
+	}
+
+	var open int64
+	config.DB.Model(&models.JobPosting{}).Where("task_id = ? AND status = ?", task.ID, "open").Count(&open)
+	if open > 0 {
+		utils.SendErrorResponse(c, http.StatusConflict, "task already has an open job posting")
+		return
+	}
+
+	// default the posting from the task
+	posting := models.JobPosting{
+		UserID:      uuid.MustParse(userID),
+		TaskID:      task.ID,
+		ProjectID:   task.ProjectID,
+		Title:       input.Title,
+		Description: input.Description,
+		Skills:      normalizeSkills(input.Skills),
+		Budget:      input.Budget,
+		Currency:    input.Currency,
+		Deadline:    input.Deadline,
+		Visibility:  input.Visibility,
+		Status:      "open",
+	}
+	if posting.Title == "" {
+		posting.Title = task.Title
+	}
+	if posting.Description == "" {
+		posting.Description = task.Description
+	}
+	if posting.Budget == nil {
+		posting.Budget = task.TaskValue
+	}
+	if posting.Currency == "" {
+		posting.Currency = task.Project.Currency
+	}
+	if posting.Deadline == nil {
+		posting.Deadline = task.DueDate
+	}
+	if posting.Visibility == "" {
+		posting.Visibility = "public"
+	}
+
+	if err := config.DB.WithContext(c).Create(&posting).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to create job posting")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusCreated, gin.H{
+		"message": "Job posting created successfully",
+		"posting": posting,
+	})
+}
+
+func GetMyJobPostings(c *gin.Context) {
+	userID := c.GetString("userID")
+	if !utils.IsAuthenticated(userID) {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid user token")
+		c.Abort()
+		return
+	}
+
+	query := config.DB.Where("user_id = ?", userID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var postings []models.JobPosting
+	if err := query.Order("created_at DESC").Find(&postings).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch job postings")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, postings)
+}
+
+func GetJobPostingByID(c *gin.Context) {
+	posting, ok := loadOwnedPosting(c)
+	if !ok {
+		return
+	}
+
+	if err := config.DB.
+		Preload("Applications", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC") }).
+		Preload("Applications.Associate").
+		First(posting, "id = ?", posting.ID).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch job posting")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, posting)
+}
+
+func UpdateJobPosting(c *gin.Context) {
+	posting, ok := loadOwnedPosting(c)
+	if !ok {
+		return
+	}
+
+	var input JobPostingUpdateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if posting.Status != "open" {
+		utils.SendErrorResponse(c, http.StatusConflict, "only open job postings can be edited")
+		return
+	}
+
+	if input.Title != nil {
+		posting.Title = *input.Title
+	}
+	if input.Description != nil {
+		posting.Description = *input.Description
+	}
+	if input.Skills != nil {
+		posting.Skills = normalizeSkills(input.Skills)
+	}
+	if input.Budget != nil {
+		posting.Budget = input.Budget
+	}
+	if input.Deadline != nil {
+		posting.Deadline = input.Deadline
+	}
+	if input.Visibility != nil {
+		posting.Visibility = *input.Visibility
+	}
+
+	if err := config.DB.WithContext(c).Save(posting).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to update job posting")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"message": "Job posting updated successfully",
+		"posting": posting,
+	})
+}
+
+func CloseJobPosting(c *gin.Context) {
+	posting, ok := loadOwnedPosting(c)
+	if !ok {
+		return
+	}
+
+	if posting.Status != "open" {
+		utils.SendErrorResponse(c, http.StatusConflict, "job posting is not open")
+		return
+	}
+
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(posting).Update("status", "closed").Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.JobApplication{}).
+			Where("posting_id = ? AND status = ?", posting.ID, "submitted").
+			Update("status", "rejected").Error
+	})
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to close job posting")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"message": "Job posting closed successfully",
+	})
+}
+
+// loadPostingApplication fetches an application on one of the freelancer's postings
+func loadPostingApplication(c *gin.Context) (*models.JobApplication, bool) {
+	userID := c.GetString("userID")
+	if !utils.IsAuthenticated(userID) {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid user token")
+		c.Abort()
+		return nil, false
+	}
+
+	var application models.JobApplication
+	if err := config.DB.
+		Preload("Posting").
+		Joins("JOIN job_postings ON job_postings.id = job_applications.posting_id").
+		Where("job_applications.id = ? AND job_postings.user_id = ?", c.Param("id"), userID).
+		First(&application).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "application not found")
+		return nil, false
+	}
+
+	return &application, true
+}
+
+// AcceptJobApplication fills the posting and invites the applicant to sign the task contract
+func AcceptJobApplication(c *gin.Context) {
+	application, ok := loadPostingApplication(c)
+	if !ok {
+		return
+	}
+
+	if application.Status != "submitted" || application.Posting.Status != "open" {
+		utils.SendErrorResponse(c, http.StatusConflict, "application can no longer be accepted")
+		return
+	}
+
+	var task models.Task
+	if err := config.DB.First(&task, "id = ?", application.Posting.TaskID).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "task not found")
+		return
+	}
+
+	var contract models.Contract
+	if err := config.DB.First(&contract, "task_id = ?", task.ID).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusConflict, "No contract found for this task. Cannot send invite.")
+		return
+	}
+
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(application).Update("status", "accepted").Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.JobApplication{}).
+			Where("posting_id = ? AND id <> ? AND status = ?", application.PostingID, application.ID, "submitted").
+			Update("status", "rejected").Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.JobPosting{}).
+			Where("id = ?", application.PostingID).
+			Update("status", "filled").Error; err != nil {
+			return err
+		}
+		return InviteAssociate(tx, task, application.AssociateID, contract.ID)
+	})
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to accept application")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"message": "Application accepted, invite sent to associate",
+	})
+}
+
+func RejectJobApplication(c *gin.Context) {
+	application, ok := loadPostingApplication(c)
+	if !ok {
+		return
+	}
+
+	if application.Status != "submitted" {
+		utils.SendErrorResponse(c, http.StatusConflict, "application can no longer be rejected")
+		return
+	}
+
+	if err := config.DB.WithContext(c).Model(application).Update("status", "rejected").Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to reject application")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"message": "Application rejected",
+	})
+}
+
+// GetMarketplaceFeed lists open postings visible to the associate that match their skills
+func GetMarketplaceFeed(c *gin.Context) {
+	associate, profile, ok := loadMarketplaceAssociate(c)
+	if !ok {
+		return
+	}
+
+	skills := normalizeSkills(append(append([]string{}, associate.Skills...), profile.Skills...))
+	if skill := c.Query("skill"); skill != "" {
+		skills = normalizeSkills([]string{skill})
+	}
+
+	query := config.DB.
+		Where("status = ?", "open").
+		Where("visibility = ? OR (visibility = ? AND user_id = ?)", "public", "private", associate.UserID).
+		Where("deadline IS NULL OR deadline > ?", time.Now())
+	if len(skills) > 0 {
+		query = query.Where("skills && ?", pq.StringArray(skills))
+	}
+
+	var postings []models.JobPosting
+	if err := query.Order("created_at DESC").Find(&postings).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch marketplace feed")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"skills":   skills,
+		"postings": postings,
+	})
+}
+
+func ApplyToJobPosting(c *gin.Context) {
+	associate, _, ok := loadMarketplaceAssociate(c)
+	if !ok {
+		return
+	}
+
+	var input JobApplicationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var posting models.JobPosting
+	if err := config.DB.First(&posting, "id = ? AND status = ?", c.Param("id"), "open").Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "job posting not found")
+		return
+	}
+	if posting.Visibility == "private" && posting.UserID != associate.UserID {
+		utils.SendErrorResponse(c, http.StatusNotFound, "job posting not found")
+		return
+	}
+
+	var existing models.JobApplication
+	err := config.DB.First(&existing, "posting_id = ? AND associate_id = ?", posting.ID, associate.ID).Error
+	if err == nil {
+		utils.SendErrorResponse(c, http.StatusConflict, "you have already applied to this posting")
+		return
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to check existing application")
+		return
+	}
+
+	application := models.JobApplication{
+		PostingID:      posting.ID,
+		AssociateID:    associate.ID,
+		Proposal:       input.Proposal,
+		Price:          input.Price,
+		EstimatedHours: input.EstimatedHours,
+		DeliveryDate:   input.DeliveryDate,
+		Status:         "submitted",
+	}
+	if err := config.DB.WithContext(c).Create(&application).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to submit application")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusCreated, gin.H{
+		"message":     "Application submitted successfully",
+		"application": application,
+	})
+}
+
+func GetMyJobApplications(c *gin.Context) {
+	associateID := c.GetString("userID")
+	if !utils.IsAssociateAuthenticated(associateID) {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid associate token")
+		c.Abort()
+		return
+	}
+
+	var applications []models.JobApplication
+	if err := config.DB.
+		Preload("Posting").
+		Where("associate_id = ?", associateID).
+		Order("created_at DESC").
+		Find(&applications).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch applications")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, applications)
+}
+
+func WithdrawJobApplication(c *gin.Context) {
+	associateID := c.GetString("userID")
+	if !utils.IsAssociateAuthenticated(associateID) {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid associate token")
+		c.Abort()
+		return
+	}
+
+	var application models.JobApplication
+	if err := config.DB.First(&application, "id = ? AND associate_id = ?", c.Param("id"), associateID).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "application not found")
+		return
+	}
+	if application.Status != "submitted" {
+		utils.SendErrorResponse(c, http.StatusConflict, "application can no longer be withdrawn")
+		return
+	}
+
+	if err := config.DB.WithContext(c).Model(&application).Update("status", "withdrawn").Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to withdraw application")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"message": "Application withdrawn",
+	})
+}
+
+// JoinMarketplace lets an associate request marketplace access
+func JoinMarketplace(c *gin.Context) {
+	associateID := c.GetString("userID")
+	if !utils.IsAssociateAuthenticated(associateID) {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid associate token")
+		c.Abort()
+		return
+	}
+
+	var profile models.AssociateProfile
+	if err := config.DB.First(&profile, "associate_id = ?", associateID).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "associate profile not found")
+		return
+	}
+
+	if err := setMarketplaceStatus(config.DB.WithContext(c), &profile, models.MarketplacePending, nil); err != nil {
+		utils.SendErrorResponse(c, http.StatusConflict, err.Error())
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"message":            "Marketplace access requested",
+		"marketplace_status": profile.MarketplaceStatus,
+	})
+}
+
+// setMarketplaceStatus applies an allowed marketplace status change; only approved associates have access
+func setMarketplaceStatus(tx *gorm.DB, profile *models.AssociateProfile, status string, notes *string) error {
+	current := ""
+	if profile.MarketplaceStatus != nil {
+		current = *profile.MarketplaceStatus
+	}
+	if !models.CanTransitionMarketplace(current, status) {
+		return errors.New("cannot move marketplace status from " + current + " to " + status)
+	}
+	if status == models.MarketplaceApproved && !profile.HasVerificationLevel(models.MarketplaceVerificationLevel) {
+		return errors.New("associate must be verified at the " + models.MarketplaceVerificationLevel + " level first")
+	}
+
+	access := status == models.MarketplaceApproved
+	profile.MarketplaceStatus = &status
+	profile.HasMarketplaceAccess = &access
+	updates := map[string]interface{}{
+		"marketplace_status":     status,
+		"has_marketplace_access": access,
+	}
+	if notes != nil {
+		profile.MarketplaceNotes = notes
+		updates["marketplace_notes"] = *notes
+	}
+
+	return tx.Model(profile).Updates(updates).Error
+}
+
+// GetMarketplaceQueue lists associates by marketplace status for admin review (pending by default)
+func GetMarketplaceQueue(c *gin.Context) {
+	status := c.DefaultQuery("status", models.MarketplacePending)
+
+	var profiles []models.AssociateProfile
+	if err := config.DB.
+		Where("marketplace_status = ?", status).
+		Order("updated_at ASC").
+		Find(&profiles).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch marketplace queue")
+		return
+	}
+
+	ids := make([]uuid.UUID, 0, len(profiles))
+	for _, p := range profiles {
+		ids = append(ids, p.AssociateID)
+	}
+	var associates []models.Associate
+	if len(ids) > 0 {
+		if err := config.DB.Where("id IN ?", ids).Find(&associates).Error; err != nil {
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch associates")
+			return
+		}
+	}
+	byID := make(map[uuid.UUID]models.Associate, len(associates))
+	for _, a := range associates {
+		byID[a.ID] = a
+	}
+
+	queue := make([]gin.H, 0, len(profiles))
+	for _, p := range profiles {
+		a := byID[p.AssociateID]
+		queue = append(queue, gin.H{
+			"associate_id":       p.AssociateID,
+			"name":               a.Name,
+			"email":              a.Email,
+			"skills":             p.Skills,
+			"portfolio_url":      p.PortfolioURL,
+			"verification_level": p.VerificationLevel,
+			"marketplace_status": p.MarketplaceStatus,
+			"marketplace_notes":  p.MarketplaceNotes,
+			"requested_at":       p.UpdatedAt,
+		})
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, queue)
+}
+
+func UpdateMarketplaceStatus(c *gin.Context) {
+	var input MarketplaceStatusInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var profile models.AssociateProfile
+	if err := config.DB.First(&profile, "associate_id = ?", c.Param("id")).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "associate profile not found")
+		return
+	}
+
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := setMarketplaceStatus(tx, &profile, input.Status, &input.Notes); err != nil {
+			return err
+		}
+		return recordAdminAction(tx, c, models.AdminActionMarketplaceStatus, models.PrincipalAssociate, profile.AssociateID, input.Notes,
+			"marketplace status set to "+input.Status)
+	})
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusConflict, err.Error())
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"message":                "Marketplace status updated",
+		"marketplace_status":     profile.MarketplaceStatus,
+		"has_marketplace_access": profile.HasMarketplaceAccess,
+	})
+}
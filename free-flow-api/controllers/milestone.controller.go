@@ -9,17 +9,20 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type CreateMilestoneInput struct {
-	Title         string    `json:"title" binding:"required"`
-	Description   string    `json:"description" binding:"required"`
-	Priority      string    `json:"priority" binding:"required,oneof=low medium high"`
-	Status        string    `json:"status" binding:"omitempty,oneof=not_started in_progress completed delayed"`
-	Deliverables  []string  `json:"deliverables" binding:"omitempty"`
-	ClientVisible bool      `json:"client_visible"`
-	DueDate       time.Time `json:"due_date"`
-	ProjectID     uuid.UUID `json:"project_id" binding:"required"`
+	Title          string    `json:"title" binding:"required"`
+	Description    string    `json:"description" binding:"required"`
+	Priority       string    `json:"priority" binding:"required,oneof=low medium high"`
+	Status         string    `json:"status" binding:"omitempty,oneof=not_started in_progress completed delayed cancelled"`
+	Deliverables   []string  `json:"deliverables" binding:"omitempty"`
+	ClientVisible  bool      `json:"client_visible"`
+	DueDate        time.Time `json:"due_date"`
+	ProjectID      uuid.UUID `json:"project_id" binding:"required"`
+	BillingAmount  *float64  `json:"billing_amount" binding:"omitempty,gte=0"`
+	BillingPercent *float64  `json:"billing_percent" binding:"omitempty,gte=0,lte=100"`
 }
 
 func CreateMilestone(c *gin.Context) {
@@ -37,17 +40,40 @@ func CreateMilestone(c *gin.Context) {
 		return
 	}
 
+	// completing or cancelling goes through UpdateMilestone, which dates and bills the milestone
+	if input.Status == "" {
+		input.Status = models.MilestoneNotStarted
+	}
+	if err := models.CheckInitialStatus(models.StatusEntityMilestone, input.Status); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "invalid input: "+err.Error())
+		return
+	}
+
 	milestone := models.Milestone{
-		Title:        input.Title,
-		Description:  input.Description,
-		Priority:     input.Priority,
-		Status:       input.Status,
-		Deliverables: input.Deliverables,
-		ProjectID:    input.ProjectID,
-		DueDate:      &input.DueDate,
+		Title:          input.Title,
+		Description:    input.Description,
+		Priority:       input.Priority,
+		Status:         input.Status,
+		Deliverables:   input.Deliverables,
+		ProjectID:      input.ProjectID,
+		DueDate:        &input.DueDate,
+		BillingAmount:  input.BillingAmount,
+		BillingPercent: input.BillingPercent,
+	}
+
+	if milestone.Status == models.MilestoneInProgress {
+		now := time.Now()
+		milestone.StartDate = &now
 	}
 
-	if err := config.DB.Create(&milestone).Error; err != nil {
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&milestone).Error; err != nil {
+			return err
+		}
+		actor := models.StatusActor{Type: models.PrincipalUser, ID: uuid.MustParse(userID)}
+		return models.RecordStatusChange(tx, models.StatusEntityMilestone, milestone.ID, milestone.ProjectID, "", milestone.Status, actor)
+	})
+	if err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to create milestone")
 		return
 	}
@@ -146,16 +172,18 @@ func GetMilestoneByID(c *gin.Context) {
 }
 
 type UpdateMilestoneInput struct {
-	Title         *string   `json:"title,omitempty"`
-	Description   *string   `json:"description,omitempty"`
-	Status        *string   `json:"status,omitempty"`
-	Priority      *string   `json:"priority,omitempty"`
-	StartDate     *string   `json:"start_date,omitempty"`
-	DueDate       *string   `json:"due_date,omitempty"`
-	CompletedDate *string   `json:"completed_date,omitempty"`
-	Progress      *int      `json:"progress,omitempty"`
-	ClientVisible *bool     `json:"client_visible,omitempty"`
-	Deliverables  *[]string `json:"deliverables,omitempty"`
+	Title          *string   `json:"title,omitempty"`
+	Description    *string   `json:"description,omitempty"`
+	Status         *string   `json:"status,omitempty"`
+	Priority       *string   `json:"priority,omitempty"`
+	StartDate      *string   `json:"start_date,omitempty"`
+	DueDate        *string   `json:"due_date,omitempty"`
+	CompletedDate  *string   `json:"completed_date,omitempty"`
+	Progress       *int      `json:"progress,omitempty"`
+	ClientVisible  *bool     `json:"client_visible,omitempty"`
+	Deliverables   *[]string `json:"deliverables,omitempty"`
+	BillingAmount  *float64  `json:"billing_amount,omitempty" binding:"omitempty,gte=0"`
+	BillingPercent *float64  `json:"billing_percent,omitempty" binding:"omitempty,gte=0,lte=100"`
 }
 
 func UpdateMilestone(c *gin.Context) {
@@ -176,8 +204,12 @@ func UpdateMilestone(c *gin.Context) {
 		return
 	}
 
+	// only the project's freelancer can change its milestones
 	var milestone models.Milestone
-	if err := config.DB.First(&milestone, "id = ?", id).Error; err != nil {
+	if err := config.DB.
+		Joins("JOIN projects ON projects.id = milestones.project_id").
+		Where("milestones.id = ? AND projects.user_id = ?", id, userID).
+		First(&milestone).Error; err != nil {
 		utils.SendErrorResponse(c, http.StatusNotFound, "milestone not found")
 		c.Abort()
 		return
@@ -198,9 +230,6 @@ func UpdateMilestone(c *gin.Context) {
 	if input.Description != nil {
 		updateData["description"] = *input.Description
 	}
-	if input.Status != nil {
-		updateData["status"] = *input.Status
-	}
 	if input.Priority != nil {
 		updateData["priority"] = *input.Priority
 	}
@@ -213,6 +242,12 @@ func UpdateMilestone(c *gin.Context) {
 	if input.Deliverables != nil {
 		updateData["deliverables"] = *input.Deliverables
 	}
+	if input.BillingAmount != nil {
+		updateData["billing_amount"] = *input.BillingAmount
+	}
+	if input.BillingPercent != nil {
+		updateData["billing_percent"] = *input.BillingPercent
+	}
 
 	// optional: parse date strings into time.Time if provided
 	if input.StartDate != nil {
@@ -225,13 +260,37 @@ func UpdateMilestone(c *gin.Context) {
 		updateData["completed_date"] = input.CompletedDate
 	}
 
-	if len(updateData) == 0 {
+	if len(updateData) == 0 && input.Status == nil {
 		utils.SendErrorResponse(c, http.StatusBadRequest, "no valid fields to update")
 		return
 	}
 
-	if err := config.DB.Model(&milestone).Updates(updateData).Error; err != nil {
-		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to update milestone")
+	err = config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if len(updateData) > 0 {
+			if err := tx.Model(&milestone).Updates(updateData).Error; err != nil {
+				return err
+			}
+		}
+		if input.Status == nil {
+			return nil
+		}
+
+		from := milestone.Status
+		if err := models.TransitionMilestone(tx, &milestone, *input.Status, models.StatusActor{Type: models.PrincipalUser, ID: uuid.MustParse(userID)}); err != nil {
+			return err
+		}
+
+		// completing a billable milestone drafts its invoice
+		if from != models.MilestoneCompleted && milestone.Status == models.MilestoneCompleted {
+			if _, err := DraftMilestoneInvoice(tx, &milestone); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		sendTransitionError(c, err, "failed to update milestone")
 		c.Abort()
 		return
 	}
@@ -239,6 +298,79 @@ func UpdateMilestone(c *gin.Context) {
 	utils.SendSuccessResponse(c, http.StatusOK, gin.H{"message": "milestone updated successfully"})
 }
 
+// ClientApproveMilestone records the client's sign-off on a milestone through their project link
+// and drafts its invoice. Escrow for the milestone can only be released once it is approved.
+func ClientApproveMilestone(c *gin.Context) {
+	projectID, err := uuid.Parse(c.GetString("project_id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid or expired client link")
+		c.Abort()
+		return
+	}
+	entityID, err := uuid.Parse(c.GetString("entity_id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid or expired client link")
+		c.Abort()
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "invalid milestone id format")
+		c.Abort()
+		return
+	}
+
+	// the link only reaches milestones the client can see on its own project
+	var milestone models.Milestone
+	if err := config.DB.
+		Joins("JOIN projects ON projects.id = milestones.project_id").
+		Where("milestones.id = ? AND milestones.project_id = ? AND milestones.client_visible = ?", id, projectID, true).
+		Where("projects.entity_id = ?", entityID).
+		First(&milestone).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "milestone not found")
+		c.Abort()
+		return
+	}
+	if milestone.ClientApprovedAt != nil {
+		utils.SendErrorResponse(c, http.StatusConflict, "milestone is already approved")
+		return
+	}
+
+	var invoice *models.Invoice
+	err = config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		var project models.Project
+		if err := tx.First(&project, "id = ?", milestone.ProjectID).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Model(&milestone).Updates(map[string]interface{}{
+			"client_approved_at": &now,
+			"client_approved_by": entityID,
+		}).Error; err != nil {
+			return err
+		}
+
+		// client approval bills the milestone even if it isn't marked completed yet
+		inv, err := DraftMilestoneInvoice(tx, &milestone)
+		if err != nil {
+			return err
+		}
+		invoice = inv
+		return nil
+	})
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to approve milestone")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"message": "milestone approved successfully",
+		"invoice": invoice,
+	})
+}
+
 func DeleteMilestone(c *gin.Context) {
 	// Validate JWT token
 	userID := c.GetString("userID")
@@ -264,7 +396,7 @@ func DeleteMilestone(c *gin.Context) {
 		return
 	}
 
-	if err := config.DB.Delete(&milestone).Error; err != nil {
+	if err := config.DB.WithContext(c).Delete(&milestone).Error; err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to delete milestone")
 		c.Abort()
 		return
@@ -302,7 +434,7 @@ func AddTasksToMilestone(c *gin.Context) {
 		return
 	}
 
-	tx := config.DB.Begin()
+	tx := config.DB.WithContext(c).Begin()
 	for _, taskID := range input.TaskIDs {
 		if err := tx.Model(&models.Task{}).
 			Where("id = ?", taskID).
@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"free-flow-api/config"
+	"free-flow-api/models"
+	"free-flow-api/utils"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetNotifications lists the caller's notifications, newest first; ?unread=true skips read ones
+func GetNotifications(c *gin.Context) {
+	recipientType, recipientID, ok := timePrincipal(c)
+	if !ok {
+		return
+	}
+
+	limit, offset := pageParams(c)
+	query := config.DB.Model(&models.Notification{}).Where("recipient_type = ? AND recipient_id = ?", recipientType, recipientID)
+	if c.Query("unread") == "true" {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var unread int64
+	config.DB.Model(&models.Notification{}).
+		Where("recipient_type = ? AND recipient_id = ? AND read_at IS NULL", recipientType, recipientID).
+		Count(&unread)
+
+	var notifications []models.Notification
+	if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&notifications).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch notifications")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"notifications": notifications,
+		"total":         total,
+		"unread":        unread,
+	})
+}
+
+func MarkNotificationRead(c *gin.Context) {
+	recipientType, recipientID, ok := timePrincipal(c)
+	if !ok {
+		return
+	}
+
+	result := config.DB.WithContext(c).Model(&models.Notification{}).
+		Where("id = ? AND recipient_type = ? AND recipient_id = ? AND read_at IS NULL", c.Param("id"), recipientType, recipientID).
+		Update("read_at", time.Now())
+	if result.Error != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to update notification")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{"message": "notification marked as read"})
+}
+
+func MarkAllNotificationsRead(c *gin.Context) {
+	recipientType, recipientID, ok := timePrincipal(c)
+	if !ok {
+		return
+	}
+
+	result := config.DB.WithContext(c).Model(&models.Notification{}).
+		Where("recipient_type = ? AND recipient_id = ? AND read_at IS NULL", recipientType, recipientID).
+		Update("read_at", time.Now())
+	if result.Error != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to update notifications")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"message": "notifications marked as read",
+		"updated": result.RowsAffected,
+	})
+}
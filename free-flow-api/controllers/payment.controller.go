@@ -9,6 +9,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type PaymentInput struct {
@@ -72,26 +73,26 @@ func CreatePayment(c *gin.Context) {
 		UserID:         uuid.MustParse(userID),
 	}
 
-	// Calculate total paid so far for this invoice
-	var totalPaid float64
-	config.DB.Model(&models.Payment{}).
-		Where("invoice_id = ? AND status != ?", input.InvoiceID, "failed").
-		Select("SUM(amount)").
-		Scan(&totalPaid)
-
-		// Check if invoice is fully paid
-	if totalPaid >= invoice.Amount {
-		now := time.Now()
-		invoice.Status = "paid"
-		invoice.PaidDate = &now
-		if err := config.DB.Save(&invoice).Error; err != nil {
-			utils.SendErrorResponse(c, http.StatusInternalServerError, "invoice update failed")
-			return
+	// book the payment, then mark the invoice paid once payments cover it
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&payment).Error; err != nil {
+			return err
 		}
-	}
 
-	if err := config.DB.Create(&payment).Error; err != nil {
-		utils.SendErrorResponse(c, http.StatusInternalServerError, "could not create payment")
+		var totalPaid float64
+		if err := tx.Model(&models.Payment{}).
+			Where("invoice_id = ? AND status != ?", input.InvoiceID, "failed").
+			Select("COALESCE(SUM(amount), 0)").
+			Scan(&totalPaid).Error; err != nil {
+			return err
+		}
+		if totalPaid < invoice.Amount {
+			return nil
+		}
+		return models.TransitionInvoice(tx, &invoice, models.InvoiceStatusPaid, models.StatusActor{Type: models.PrincipalUser, ID: payment.UserID})
+	})
+	if err != nil {
+		sendTransitionError(c, err, "could not create payment")
 		return
 	}
 
@@ -201,7 +202,7 @@ func UpdatePayment(c *gin.Context) {
 		payment.Status = *input.Status
 	}
 
-	if err := config.DB.Save(&payment).Error; err != nil {
+	if err := config.DB.WithContext(c).Save(&payment).Error; err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, "could not update payment")
 		return
 	}
@@ -219,7 +220,7 @@ func DeletePayment(c *gin.Context) {
 
 	id := c.Param("id")
 
-	if err := config.DB.Delete(&models.Payment{}, "id = ?", id).Error; err != nil {
+	if err := config.DB.WithContext(c).Delete(&models.Payment{}, "id = ?", id).Error; err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, "could not delete payment")
 		return
 	}
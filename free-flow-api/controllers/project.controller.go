@@ -67,7 +67,7 @@ func NewProject(c *gin.Context) {
 		UserID:         uuid.MustParse(userID),
 	}
 
-	if err := config.DB.Create(&project).Error; err != nil {
+	if err := config.DB.WithContext(c).Create(&project).Error; err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to create an entity")
 		return
 	}
@@ -192,7 +192,7 @@ func UpdateProject(c *gin.Context) {
 	}
 
 	// Start a transaction for atomic updates
-	tx := config.DB.Begin()
+	tx := config.DB.WithContext(c).Begin()
 	if tx.Error != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to start transaction")
 		return
@@ -235,35 +235,30 @@ func UpdateProject(c *gin.Context) {
 		updateMap["is_outsourced"] = *updates.YourCutPercent > 0
 	}
 
-	// Handle Status changes and related logic
-	if updates.Status != nil {
-		newStatus := *updates.Status
-
-		updateMap["status"] = newStatus
-
-		// If status changes to active and project hasn't started yet, set StartDate
-		if newStatus == models.ProjectStatusActive && project.StartDate == nil {
-			now := time.Now()
-			updateMap["start_date"] = &now
+	// Perform the update
+	if len(updateMap) > 0 {
+		if err := tx.Model(&project).Updates(updateMap).Error; err != nil {
+			tx.Rollback()
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to update project")
+			return
 		}
+	}
 
-		// If status changes to completed, set CompletedAt
-		if newStatus == models.ProjectStatusCompleted {
-			now := time.Now()
-			updateMap["completed_at"] = &now
+	// Status and phase follow their transition graphs, which also set the start and completion dates
+	actor := models.StatusActor{Type: models.PrincipalUser, ID: uuid.MustParse(userID)}
+	if updates.Status != nil {
+		if err := models.TransitionProject(tx, &project, *updates.Status, actor); err != nil {
+			tx.Rollback()
+			sendTransitionError(c, err, "failed to update project status")
+			return
 		}
 	}
-
-	// Handle CurrentPhase updates
 	if updates.CurrentPhase != nil {
-		updateMap["current_phase"] = *updates.CurrentPhase
-	}
-
-	// Perform the update
-	if err := tx.Model(&project).Updates(updateMap).Error; err != nil {
-		tx.Rollback()
-		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to update project")
-		return
+		if err := models.TransitionProjectPhase(tx, &project, *updates.CurrentPhase, actor); err != nil {
+			tx.Rollback()
+			sendTransitionError(c, err, "failed to update project phase")
+			return
+		}
 	}
 
 	// Commit transaction
@@ -308,14 +303,14 @@ func DeleteProject(c *gin.Context) {
 	}
 
 	// Set entity_id to NULL in all related projects before deleting
-	if err := config.DB.Model(&models.Project{}).
+	if err := config.DB.WithContext(c).Model(&models.Project{}).
 		Where("entity_id = ?", entity.ID).
 		Update("entity_id", nil).Error; err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to update related projects")
 		return
 	}
 
-	if err := config.DB.Delete(&project).Error; err != nil {
+	if err := config.DB.WithContext(c).Delete(&project).Error; err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to delete project")
 		return
 	}
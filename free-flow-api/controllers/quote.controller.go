@@ -0,0 +1,569 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"free-flow-api/config"
+	"free-flow-api/models"
+	"free-flow-api/utils"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type QuoteLineItemInput struct {
+	Milestone      string  `json:"milestone"`
+	Description    string  `json:"description" binding:"required"`
+	Quantity       float64 `json:"quantity" binding:"omitempty,gt=0"`
+	UnitPrice      float64 `json:"unit_price" binding:"gte=0"`
+	EstimatedHours float64 `json:"estimated_hours"`
+}
+
+type QuoteInput struct {
+	Title          string               `json:"title" binding:"required"`
+	Description    string               `json:"description"`
+	Category       string               `json:"category"`
+	Terms          string               `json:"terms"`
+	Currency       *string              `json:"currency,omitempty"`
+	DepositPercent *float64             `json:"deposit_percent" binding:"omitempty,gte=0,lte=100"`
+	ValidUntil     time.Time            `json:"valid_until" binding:"required"`
+	EntityID       *uuid.UUID           `json:"entity_id"`
+	LineItems      []QuoteLineItemInput `json:"line_items" binding:"required,min=1,dive"`
+}
+
+type QuoteUpdateInput struct {
+	Title          *string               `json:"title,omitempty"`
+	Description    *string               `json:"description,omitempty"`
+	Category       *string               `json:"category,omitempty"`
+	Terms          *string               `json:"terms,omitempty"`
+	Currency       *string               `json:"currency,omitempty"`
+	DepositPercent *float64              `json:"deposit_percent,omitempty" binding:"omitempty,gte=0,lte=100"`
+	ValidUntil     *time.Time            `json:"valid_until,omitempty"`
+	EntityID       *uuid.UUID            `json:"entity_id,omitempty"`
+	LineItems      *[]QuoteLineItemInput `json:"line_items,omitempty" binding:"omitempty,min=1,dive"`
+}
+
+type ConvertQuoteInput struct {
+	CreateDepositInvoice bool     `json:"create_deposit_invoice"`
+	DepositPercent       *float64 `json:"deposit_percent" binding:"omitempty,gt=0,lte=100"`
+}
+
+var errQuoteConverted = errors.New("quote has already been converted")
+
+func buildQuoteLineItems(inputs []QuoteLineItemInput) ([]models.QuoteLineItem, float64) {
+	items := make([]models.QuoteLineItem, 0, len(inputs))
+	var total float64
+
+	for i, in := range inputs {
+		qty := in.Quantity
+		if qty == 0 {
+			qty = 1
+		}
+		item := models.QuoteLineItem{
+			Milestone:      in.Milestone,
+			Description:    in.Description,
+			Quantity:       qty,
+			UnitPrice:      in.UnitPrice,
+			Amount:         qty * in.UnitPrice,
+			EstimatedHours: in.EstimatedHours,
+			Position:       i,
+		}
+		total += item.Amount
+		items = append(items, item)
+	}
+
+	return items, total
+}
+
+// expireQuoteIfDue flips an open quote past its validity date to expired
+func expireQuoteIfDue(db *gorm.DB, quote *models.Quote) {
+	if !quote.IsExpired() {
+		return
+	}
+	quote.Status = models.QuoteStatusExpired
+	db.Model(quote).Update("status", models.QuoteStatusExpired)
+}
+
+func CreateQuote(c *gin.Context) {
+	//validate jwt
+	userID := c.GetString("userID")
+	if !utils.IsAuthenticated(userID) {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid user token")
+		c.Abort()
+		return
+	}
+
+	var input QuoteInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if input.EntityID != nil && !utils.EntityExists(input.EntityID.String()) {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "entity not found")
+		return
+	}
+
+	items, total := buildQuoteLineItems(input.LineItems)
+
+	quote := models.Quote{
+		UserID:         uuid.MustParse(userID),
+		EntityID:       input.EntityID,
+		QuoteNumber:    utils.GenerateQuoteNumber(),
+		Title:          input.Title,
+		Description:    input.Description,
+		Category:       input.Category,
+		Terms:          input.Terms,
+		Status:         models.QuoteStatusDraft,
+		Total:          total,
+		Currency:       utils.StringOrDefault(input.Currency, "KES"),
+		DepositPercent: input.DepositPercent,
+		ValidUntil:     input.ValidUntil,
+		LineItems:      items,
+	}
+
+	if err := config.DB.WithContext(c).Create(&quote).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to create quote")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusCreated, gin.H{
+		"quote_id": quote.ID,
+		"message":  "Quote created successfully",
+	})
+}
+
+func GetQuotesByUserID(c *gin.Context) {
+	//validate jwt token
+	userID := c.GetString("userID")
+	if !utils.IsAuthenticated(userID) {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid user token")
+		c.Abort()
+		return
+	}
+
+	var quotes []models.Quote
+	if err := config.DB.
+		Preload("Entity").
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&quotes).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch quotes")
+		return
+	}
+
+	for i := range quotes {
+		expireQuoteIfDue(config.DB.WithContext(c), &quotes[i])
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, quotes)
+}
+
+func GetQuoteByID(c *gin.Context) {
+	//validate jwt token
+	userID := c.GetString("userID")
+	if !utils.IsAuthenticated(userID) {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid user token")
+		c.Abort()
+		return
+	}
+
+	var quote models.Quote
+	if err := config.DB.
+		Preload("LineItems", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position ASC")
+		}).
+		Preload("Entity").
+		First(&quote, "id = ? AND user_id = ?", c.Param("id"), userID).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "quote not found")
+		return
+	}
+
+	expireQuoteIfDue(config.DB.WithContext(c), &quote)
+
+	utils.SendSuccessResponse(c, http.StatusOK, quote)
+}
+
+func UpdateQuote(c *gin.Context) {
+	userID := c.GetString("userID")
+	if !utils.IsAuthenticated(userID) {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid user token")
+		c.Abort()
+		return
+	}
+
+	var input QuoteUpdateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if input.EntityID != nil && !utils.EntityExists(input.EntityID.String()) {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "entity not found")
+		return
+	}
+
+	var quote models.Quote
+	if err := config.DB.First(&quote, "id = ? AND user_id = ?", c.Param("id"), userID).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "quote not found")
+		return
+	}
+
+	if quote.Status != models.QuoteStatusDraft {
+		utils.SendErrorResponse(c, http.StatusConflict, "only draft quotes can be edited")
+		return
+	}
+
+	updateMap := make(map[string]interface{})
+
+	if input.Title != nil {
+		updateMap["title"] = *input.Title
+	}
+	if input.Description != nil {
+		updateMap["description"] = *input.Description
+	}
+	if input.Category != nil {
+		updateMap["category"] = *input.Category
+	}
+	if input.Terms != nil {
+		updateMap["terms"] = *input.Terms
+	}
+	if input.Currency != nil {
+		updateMap["currency"] = *input.Currency
+	}
+	if input.DepositPercent != nil {
+		updateMap["deposit_percent"] = *input.DepositPercent
+	}
+	if input.ValidUntil != nil {
+		updateMap["valid_until"] = *input.ValidUntil
+	}
+	if input.EntityID != nil {
+		updateMap["entity_id"] = *input.EntityID
+	}
+
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		// line items are replaced wholesale when provided
+		if input.LineItems != nil {
+			items, total := buildQuoteLineItems(*input.LineItems)
+			if err := tx.Where("quote_id = ?", quote.ID).Delete(&models.QuoteLineItem{}).Error; err != nil {
+				return err
+			}
+			for i := range items {
+				items[i].QuoteID = quote.ID
+			}
+			if len(items) > 0 {
+				if err := tx.Create(&items).Error; err != nil {
+					return err
+				}
+			}
+			updateMap["total"] = total
+		}
+
+		if len(updateMap) == 0 {
+			return nil
+		}
+		return tx.Model(&quote).Updates(updateMap).Error
+	})
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to update quote")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{"message": "Quote updated successfully"})
+}
+
+func DeleteQuote(c *gin.Context) {
+	userID := c.GetString("userID")
+	if !utils.IsAuthenticated(userID) {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid user token")
+		c.Abort()
+		return
+	}
+
+	var quote models.Quote
+	if err := config.DB.First(&quote, "id = ? AND user_id = ?", c.Param("id"), userID).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "quote not found")
+		return
+	}
+
+	if err := config.DB.WithContext(c).Delete(&quote).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to delete quote")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{"message": "Quote deleted successfully"})
+}
+
+// SendQuote marks the quote as sent and issues the client acceptance link
+func SendQuote(c *gin.Context) {
+	userID := c.GetString("userID")
+	if !utils.IsAuthenticated(userID) {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid user token")
+		c.Abort()
+		return
+	}
+
+	var quote models.Quote
+	if err := config.DB.First(&quote, "id = ? AND user_id = ?", c.Param("id"), userID).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "quote not found")
+		return
+	}
+
+	expireQuoteIfDue(config.DB.WithContext(c), &quote)
+	if quote.Status != models.QuoteStatusDraft && quote.Status != models.QuoteStatusSent {
+		utils.SendErrorResponse(c, http.StatusConflict, fmt.Sprintf("cannot send a quote that is %s", quote.Status))
+		return
+	}
+
+	token, err := config.GenerateQuoteToken(quote.ID.String(), quote.ValidUntil)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to generate quote link")
+		return
+	}
+
+	now := time.Now()
+	if err := config.DB.WithContext(c).Model(&quote).Updates(map[string]interface{}{
+		"status":  models.QuoteStatusSent,
+		"sent_at": &now,
+	}).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to send quote")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"message": "Quote sent successfully",
+		"token":   token,
+	})
+}
+
+// GetPublicQuote lets the client view a quote through its acceptance link
+func GetPublicQuote(c *gin.Context) {
+	quoteID := c.GetString("quote_id")
+
+	var quote models.Quote
+	if err := config.DB.
+		Preload("LineItems", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position ASC")
+		}).
+		Preload("Entity").
+		Preload("User").
+		First(&quote, "id = ?", quoteID).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "quote not found")
+		return
+	}
+
+	expireQuoteIfDue(config.DB.WithContext(c), &quote)
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"quote": quote,
+		"freelancer": gin.H{
+			"first_name": quote.User.FirstName,
+			"last_name":  quote.User.LastName,
+			"email":      quote.User.Email,
+		},
+	})
+}
+
+// RespondToQuote records the client's acceptance or rejection
+func RespondToQuote(c *gin.Context) {
+	quoteID := c.GetString("quote_id")
+
+	var input struct {
+		Status string `json:"status" binding:"required,oneof=accepted rejected"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	var quote models.Quote
+	if err := config.DB.First(&quote, "id = ?", quoteID).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "quote not found")
+		return
+	}
+
+	expireQuoteIfDue(config.DB.WithContext(c), &quote)
+	if quote.Status != models.QuoteStatusSent {
+		utils.SendErrorResponse(c, http.StatusConflict, fmt.Sprintf("quote is %s and can no longer be answered", quote.Status))
+		return
+	}
+
+	now := time.Now()
+	if err := config.DB.WithContext(c).Model(&quote).Updates(map[string]interface{}{
+		"status":       input.Status,
+		"responded_at": &now,
+	}).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to update quote")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"message": "Quote updated successfully",
+		"status":  input.Status,
+	})
+}
+
+// ConvertQuote turns an accepted quote into a project with its milestones, tasks and optional deposit invoice
+func ConvertQuote(c *gin.Context) {
+	userID := c.GetString("userID")
+	if !utils.IsAuthenticated(userID) {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid user token")
+		c.Abort()
+		return
+	}
+
+	// the body is optional
+	var input ConvertQuoteInput
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var quote models.Quote
+	if err := config.DB.
+		Preload("LineItems", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position ASC")
+		}).
+		First(&quote, "id = ? AND user_id = ?", c.Param("id"), userID).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "quote not found")
+		return
+	}
+
+	if quote.Status != models.QuoteStatusAccepted {
+		utils.SendErrorResponse(c, http.StatusConflict, "only accepted quotes can be converted")
+		return
+	}
+
+	owner := uuid.MustParse(userID)
+	now := time.Now()
+
+	var project models.Project
+	var deposit *models.Invoice
+
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		// lock the quote so concurrent conversions can't each create a project
+		var current models.Quote
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "status", "project_id").
+			First(&current, "id = ?", quote.ID).Error; err != nil {
+			return err
+		}
+		if current.Status != models.QuoteStatusAccepted || current.ProjectID != nil {
+			return errQuoteConverted
+		}
+
+		project = models.Project{
+			UserID:         owner,
+			EntityID:       quote.EntityID,
+			Name:           quote.Title,
+			Category:       quote.Category,
+			Description:    quote.Description,
+			Status:         models.ProjectStatusActive,
+			StartDate:      &now,
+			EstimatedValue: quote.Total,
+			Currency:       quote.Currency,
+			Notes:          quote.Terms,
+		}
+		if err := tx.Create(&project).Error; err != nil {
+			return err
+		}
+
+		// one milestone per distinct milestone name, billed at the sum of its items and
+		// created in the order the names first appear in the quote
+		var milestones []*models.Milestone
+		byName := make(map[string]*models.Milestone)
+		for _, item := range quote.LineItems {
+			if item.Milestone == "" {
+				continue
+			}
+			m, exists := byName[item.Milestone]
+			if !exists {
+				amount := 0.0
+				m = &models.Milestone{
+					ProjectID:     project.ID,
+					Title:         item.Milestone,
+					BillingAmount: &amount,
+				}
+				byName[item.Milestone] = m
+				milestones = append(milestones, m)
+			}
+			*m.BillingAmount += item.Amount
+		}
+		for _, m := range milestones {
+			if err := tx.Create(m).Error; err != nil {
+				return err
+			}
+		}
+
+		for _, item := range quote.LineItems {
+			value := item.Amount
+			task := models.Task{
+				ProjectID:      project.ID,
+				Title:          item.Description,
+				EstimatedHours: item.EstimatedHours,
+				TaskValue:      &value,
+				CreatedBy:      owner,
+			}
+			if m, ok := byName[item.Milestone]; ok {
+				task.MilestoneID = &m.ID
+			}
+			if err := tx.Create(&task).Error; err != nil {
+				return err
+			}
+		}
+
+		percent := quote.DepositPercent
+		if input.DepositPercent != nil {
+			percent = input.DepositPercent
+		}
+		if input.CreateDepositInvoice && percent != nil && *percent > 0 {
+			amount := quote.Total * (*percent / 100.0)
+			description := fmt.Sprintf("Deposit (%.0f%%) for %s", *percent, quote.Title)
+			invoice := models.Invoice{
+				ProjectID:     project.ID,
+				Type:          "deposit",
+				Amount:        amount,
+				Currency:      quote.Currency,
+				Status:        "draft",
+				IssueDate:     now,
+				DueDate:       now.AddDate(0, 0, 7),
+				Description:   description,
+				InvoiceNumber: utils.GenerateInvoiceNumber(),
+				UserID:        owner,
+				LineItems: []models.InvoiceLineItem{{
+					Description: description,
+					Quantity:    1,
+					UnitPrice:   amount,
+					Amount:      amount,
+				}},
+			}
+			if err := tx.Create(&invoice).Error; err != nil {
+				return err
+			}
+			deposit = &invoice
+		}
+
+		return tx.Model(&quote).Updates(map[string]interface{}{
+			"project_id":   project.ID,
+			"converted_at": &now,
+		}).Error
+	})
+	if errors.Is(err, errQuoteConverted) {
+		utils.SendErrorResponse(c, http.StatusConflict, "quote has already been converted")
+		return
+	}
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to convert quote")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusCreated, gin.H{
+		"message":         "Quote converted successfully",
+		"project_id":      project.ID,
+		"deposit_invoice": deposit,
+	})
+}
@@ -0,0 +1,184 @@
+package controllers
+
+import (
+	"errors"
+	"free-flow-api/config"
+	"free-flow-api/models"
+	"free-flow-api/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ReviewInput struct {
+	Quality       int    `json:"quality" binding:"required,min=1,max=5"`
+	Communication int    `json:"communication" binding:"required,min=1,max=5"`
+	Timeliness    int    `json:"timeliness" binding:"required,min=1,max=5"`
+	Comment       string `json:"comment"`
+}
+
+// SubmitTaskReview records feedback on a completed task.
+// Freelancers review the assigned associate; the associate reviews the freelancer.
+func SubmitTaskReview(c *gin.Context) {
+	principalID := c.GetString("userID")
+	isFreelancer := utils.IsAuthenticated(principalID)
+	if !isFreelancer && !utils.IsAssociateAuthenticated(principalID) {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid user token")
+		c.Abort()
+		return
+	}
+
+	var input ReviewInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var task models.Task
+	if err := config.DB.Preload("Project").First(&task, "id = ?", c.Param("id")).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "task not found")
+		return
+	}
+
+	if task.AssignedToAssociate == nil {
+		utils.SendErrorResponse(c, http.StatusConflict, "task has no assigned associate")
+		return
+	}
+	if task.Status != models.TaskStatusDone && task.Status != models.TaskStatusPaid {
+		utils.SendErrorResponse(c, http.StatusConflict, "only completed tasks can be reviewed")
+		return
+	}
+
+	subject := models.ReviewOfAssociate
+	if isFreelancer {
+		if task.Project.UserID.String() != principalID {
+			utils.SendErrorResponse(c, http.StatusForbidden, "access denied")
+			return
+		}
+	} else {
+		if task.AssignedToAssociate.String() != principalID {
+			utils.SendErrorResponse(c, http.StatusForbidden, "access denied")
+			return
+		}
+		subject = models.ReviewOfFreelancer
+	}
+
+	// one review per side per task; resubmitting edits it
+	var review models.Review
+	err := config.DB.First(&review, "task_id = ? AND subject = ?", task.ID, subject).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch review")
+		return
+	}
+
+	review.TaskID = task.ID
+	review.Subject = subject
+	review.ProjectID = task.ProjectID
+	review.AssociateID = *task.AssignedToAssociate
+	review.UserID = task.Project.UserID
+	review.Quality = input.Quality
+	review.Communication = input.Communication
+	review.Timeliness = input.Timeliness
+	review.Comment = input.Comment
+
+	if err := config.DB.WithContext(c).Save(&review).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to save review")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"message": "Review saved successfully",
+		"review":  review,
+	})
+}
+
+func GetTaskReviews(c *gin.Context) {
+	principalID := c.GetString("userID")
+	if !utils.IsAuthenticated(principalID) && !utils.IsAssociateAuthenticated(principalID) {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid user token")
+		c.Abort()
+		return
+	}
+
+	var reviews []models.Review
+	if err := config.DB.
+		Where("task_id = ? AND (user_id = ? OR associate_id = ?)", c.Param("id"), principalID, principalID).
+		Find(&reviews).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch reviews")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, reviews)
+}
+
+func GetAssociateReviews(c *gin.Context) {
+	principalID := c.GetString("userID")
+	if !utils.IsAuthenticated(principalID) && !utils.IsAssociateAuthenticated(principalID) {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid user token")
+		c.Abort()
+		return
+	}
+
+	associateID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "invalid associate id")
+		return
+	}
+
+	reputation, err := models.ReputationFor(config.DB, models.ReviewOfAssociate, "associate_id = ?", associateID)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch reputation")
+		return
+	}
+
+	var reviews []models.Review
+	if err := config.DB.
+		Where("subject = ? AND associate_id = ?", models.ReviewOfAssociate, associateID).
+		Order("created_at DESC").
+		Find(&reviews).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch reviews")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"reputation": reputation,
+		"reviews":    reviews,
+	})
+}
+
+func GetFreelancerReviews(c *gin.Context) {
+	principalID := c.GetString("userID")
+	if !utils.IsAuthenticated(principalID) && !utils.IsAssociateAuthenticated(principalID) {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid user token")
+		c.Abort()
+		return
+	}
+
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	reputation, err := models.ReputationFor(config.DB, models.ReviewOfFreelancer, "user_id = ?", userID)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch reputation")
+		return
+	}
+
+	var reviews []models.Review
+	if err := config.DB.
+		Where("subject = ? AND user_id = ?", models.ReviewOfFreelancer, userID).
+		Order("created_at DESC").
+		Find(&reviews).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch reviews")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"reputation": reputation,
+		"reviews":    reviews,
+	})
+}
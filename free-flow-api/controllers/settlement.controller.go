@@ -27,7 +27,7 @@ func UpsertSettlementOnTaskAssignment(c *gin.Context, task models.Task) error {
 	if task.AssignedToAssociate == nil {
 		// You can choose to delete or just mark as unassigned.
 		// Option A: Delete the settlement record
-		if err := config.DB.
+		if err := config.DB.WithContext(c).
 			Where("task_id = ?", task.ID).
 			Delete(&models.AssociateSettlement{}).Error; err != nil {
 			return err
@@ -42,31 +42,51 @@ func UpsertSettlementOnTaskAssignment(c *gin.Context, task models.Task) error {
 	}
 
 	// 🧩 CASE 3: Upsert logic (assign or reassign)
+	return upsertTaskSettlement(config.DB.WithContext(c), task, *task.AssignedToAssociate, uuid.MustParse(userID), nil)
+}
+
+// upsertTaskSettlement records what the associate is owed for a task.
+// An agreed price (e.g. a winning bid) overrides the project's cut of the task value.
+func upsertTaskSettlement(tx *gorm.DB, task models.Task, associateID, userID uuid.UUID, price *float64) error {
+	if task.TaskValue == nil && price == nil {
+		return nil
+	}
+
 	var project models.Project
-	if err := config.DB.First(&project, "id = ?", &task.ProjectID).Error; err != nil {
+	if err := tx.First(&project, "id = ?", &task.ProjectID).Error; err != nil {
 		return err
 	}
 
 	// Calculate associate cut
 	percentage := 100 - project.YourCutPercent
-	expectedAmount := int64(*task.TaskValue * (percentage / 100.0))
+	var expectedAmount int64
+	if price != nil {
+		expectedAmount = int64(*price)
+		if task.TaskValue != nil && *task.TaskValue > 0 {
+			percentage = *price / *task.TaskValue * 100
+		} else {
+			percentage = 100
+		}
+	} else {
+		expectedAmount = int64(*task.TaskValue * (percentage / 100.0))
+	}
 
 	var existing models.AssociateSettlement
-	err := config.DB.First(&existing, "task_id = ?", task.ID).Error
+	err := tx.First(&existing, "task_id = ?", task.ID).Error
 
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		// New record (first assignment)
 		settlement := models.AssociateSettlement{
 			ProjectID:      task.ProjectID,
 			TaskID:         task.ID,
-			AssociateID:    *task.AssignedToAssociate,
-			UserID:         uuid.MustParse(userID),
+			AssociateID:    associateID,
+			UserID:         userID,
 			PercentageCut:  percentage,
 			ExpectedAmount: expectedAmount,
 			SettledAmount:  0,
 			Status:         "pending",
 		}
-		return config.DB.Create(&settlement).Error
+		return tx.Create(&settlement).Error
 	}
 
 	if err != nil {
@@ -74,12 +94,32 @@ func UpsertSettlementOnTaskAssignment(c *gin.Context, task models.Task) error {
 	}
 
 	// Update existing record (reassignment or task value change)
-	existing.AssociateID = *task.AssignedToAssociate
+	existing.AssociateID = associateID
 	existing.PercentageCut = percentage
 	existing.ExpectedAmount = expectedAmount
 	existing.UpdatedAt = time.Now()
 
-	return config.DB.Save(&existing).Error
+	return tx.Save(&existing).Error
+}
+
+var (
+	errPayoutNotVerified = errors.New("associate must complete KYC verification before receiving payouts")
+	errNotPayable        = errors.New("the task's work has not been approved yet, so its settlement is not payable")
+)
+
+// requirePayoutVerification checks the associate has reached the verification level needed for payouts
+func requirePayoutVerification(tx *gorm.DB, associateID uuid.UUID) error {
+	var profile models.AssociateProfile
+	if err := tx.First(&profile, "associate_id = ?", associateID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errPayoutNotVerified
+		}
+		return err
+	}
+	if !profile.HasVerificationLevel(models.PayoutVerificationLevel) {
+		return errPayoutNotVerified
+	}
+	return nil
 }
 
 func UpdateSettlementPayment(c *gin.Context) {
@@ -102,8 +142,21 @@ func UpdateSettlementPayment(c *gin.Context) {
 		return
 	}
 
+	// payouts need approved work and a verified associate
+	if input.SettledAmount > settlement.SettledAmount {
+		if settlement.PayableAt == nil {
+			utils.SendErrorResponse(c, http.StatusConflict, errNotPayable.Error())
+			return
+		}
+		if err := requirePayoutVerification(config.DB, settlement.AssociateID); err != nil {
+			utils.SendErrorResponse(c, http.StatusConflict, err.Error())
+			return
+		}
+	}
+
 	// Update values
 	now := time.Now()
+	paid := input.SettledAmount - settlement.SettledAmount
 	settlement.SettledAmount = input.SettledAmount
 	settlement.TransactionRef = input.TransactionRef
 
@@ -120,8 +173,14 @@ func UpdateSettlementPayment(c *gin.Context) {
 
 	settlement.UpdatedAt = time.Now()
 
-	// Save changes
-	if err := config.DB.Save(&settlement).Error; err != nil {
+	// Save changes, keeping a dated record of what was paid this time
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&settlement).Error; err != nil {
+			return err
+		}
+		return models.RecordSettlementPayment(tx, settlement, paid, now)
+	})
+	if err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to update settlement")
 		return
 	}
@@ -153,6 +212,7 @@ func GetRecentSettlements(c *gin.Context) {
 		PercentageCut  float64    `json:"percentage_cut"`
 		Method         string     `json:"method"`
 		Status         string     `json:"status"`
+		PayableAt      *time.Time `json:"payable_at"`
 		CreatedAt      time.Time  `json:"created_at"`
 	}
 
@@ -173,6 +233,7 @@ func GetRecentSettlements(c *gin.Context) {
 			s.percentage_cut,
 			s.method,
 			s.status,
+			s.payable_at,
 			s.created_at
 		`).
 		Where("s.user_id = ? AND s.status = ?", userID, "pending").
@@ -190,6 +251,7 @@ func GetRecentSettlements(c *gin.Context) {
 		PercentageCut  float64    `json:"percentage_cut"`
 		Method         string     `json:"method"`
 		Status         string     `json:"status"`
+		PayableAt      *time.Time `json:"payable_at"` // nil until the task's work is approved
 		CreatedAt      time.Time  `json:"created_at"`
 	}
 
@@ -243,6 +305,7 @@ func GetRecentSettlements(c *gin.Context) {
 			PercentageCut:  r.PercentageCut,
 			Method:         r.Method,
 			Status:         r.Status,
+			PayableAt:      r.PayableAt,
 			CreatedAt:      r.CreatedAt,
 		})
 	}
@@ -44,6 +44,8 @@ type AssociateStat struct {
 	RatingDeviation            float64 `json:"rating_deviation"`
 	EfficiencyRatePercent      float64 `json:"efficiency_rate_percent"`
 	EfficiencyDeviationPercent float64 `json:"efficiency_deviation_percent"`
+	AverageRating              float64 `json:"average_rating"`
+	TotalReviews               int64   `json:"total_reviews"`
 }
 
 type FinanceStat struct {
@@ -320,7 +322,7 @@ func GetAssociateStats(c *gin.Context) {
 		Joins("JOIN projects ON projects.id = tasks.project_id").
 		Where("projects.is_outsourced = ?", true).
 		Where("tasks.assigned_to_associate IS NOT NULL").
-		Where("tasks.status = ?", "done").
+		Where("tasks.status IN ?", models.CompletedTaskStatuses).
 		Where("projects.deleted_at IS NULL").
 		Count(&total_completed_tasks).Error; err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to count completed tasks")
@@ -332,7 +334,7 @@ func GetAssociateStats(c *gin.Context) {
 		Joins("JOIN projects ON projects.id = tasks.project_id").
 		Where("projects.is_outsourced = ?", true).
 		Where("tasks.assigned_to_associate IS NOT NULL").
-		Where("tasks.status = ?", "done").
+		Where("tasks.status IN ?", models.CompletedTaskStatuses).
 		Where("projects.deleted_at is NULL").
 		Where("tasks.created_at >= ?", start_of_this_month).
 		Count(&monthly_completed_tasks).Error; err != nil {
@@ -377,7 +379,7 @@ func GetAssociateStats(c *gin.Context) {
 
 	if err := config.DB.
 		Model(&models.Task{}).
-		Where("assigned_to_associate IS NOT NULL AND status = ?", "done").
+		Where("assigned_to_associate IS NOT NULL AND status IN ?", models.CompletedTaskStatuses).
 		Select("COUNT(*)").
 		Scan(&total_tasks_completed).Error; err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch total completed tasks")
@@ -429,9 +431,9 @@ func GetAssociateStats(c *gin.Context) {
 			projects.user_id = ? 
 			AND projects.is_outsourced = ? 
 			AND tasks.assigned_to_associate IS NOT NULL 
-			AND tasks.status = ? 
+			AND tasks.status IN ? 
 			AND tasks.created_at BETWEEN ? AND ?`,
-			userID, true, "done", start_of_last_month, end_of_last_month).
+			userID, true, models.CompletedTaskStatuses, start_of_last_month, end_of_last_month).
 		Select("COUNT(*)").
 		Scan(&totalCompletedLast).Error; err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch last month's completed tasks")
@@ -470,7 +472,16 @@ func GetAssociateStats(c *gin.Context) {
 		efficiency_deviation_percent = ((avgEfficiencyAll - avgEfficiencyLast) / avgEfficiencyLast) * 100
 	}
 
+	//ratings left for associates
+	reputation, err := models.ReputationFor(config.DB, models.ReviewOfAssociate, "user_id = ?", userID)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch associate ratings")
+		return
+	}
+
 	stats := AssociateStat{
+		AverageRating:              reputation.Overall,
+		TotalReviews:               reputation.Count,
 		TotalAssociates:            total_associates,
 		ActiveAssociates:           active_associates,
 		TotalAssociateProjects:     total_associate_projects,
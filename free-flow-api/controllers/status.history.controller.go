@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"errors"
+	"free-flow-api/config"
+	"free-flow-api/models"
+	"free-flow-api/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sendTransitionError maps a failed status change to a response: unknown statuses are bad
+// requests, moves the graph doesn't allow and records changed underneath are conflicts,
+// anything else is a server error
+func sendTransitionError(c *gin.Context, err error, fallback string) {
+	if errors.Is(err, models.ErrStaleStatus) {
+		utils.SendErrorResponse(c, http.StatusConflict, err.Error())
+		return
+	}
+	var transition *models.TransitionError
+	if !errors.As(err, &transition) {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, fallback)
+		return
+	}
+	if !models.IsStatus(transition.Entity, transition.To) {
+		utils.SendErrorResponse(c, http.StatusBadRequest, transition.Error())
+		return
+	}
+	utils.SendErrorResponse(c, http.StatusConflict, transition.Error())
+}
+
+// GetProjectStatusHistory lists status changes on a project and its tasks, milestones and invoices,
+// newest first; ?entity_type= and ?entity_id= narrow it to one kind of record or one record
+func GetProjectStatusHistory(c *gin.Context) {
+	userID := c.GetString("userID")
+	if !utils.IsAuthenticated(userID) {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid user token")
+		c.Abort()
+		return
+	}
+
+	project, ok := loadBillingProject(c, userID, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	limit, offset := pageParams(c)
+	query := config.DB.Model(&models.StatusChange{}).Where("project_id = ?", project.ID)
+	if entityType := c.Query("entity_type"); entityType != "" {
+		query = query.Where("entity_type = ?", entityType)
+	}
+	if entityID := c.Query("entity_id"); entityID != "" {
+		query = query.Where("entity_id = ?", entityID)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var changes []models.StatusChange
+	if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&changes).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch status history")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"changes": changes,
+		"total":   total,
+	})
+}
@@ -0,0 +1,183 @@
+package controllers
+
+import (
+	"free-flow-api/config"
+	"free-flow-api/models"
+	"free-flow-api/utils"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type ChecklistItemInput struct {
+	Title    string `json:"title" binding:"required,max=255"`
+	Position *int   `json:"position"`
+}
+
+type ChecklistItemUpdateInput struct {
+	Title    *string `json:"title" binding:"omitempty,max=255"`
+	Position *int    `json:"position"`
+	Done     *bool   `json:"done"`
+}
+
+// loadChecklistTask resolves the task for the freelancer who owns it or the associate working on it
+func loadChecklistTask(c *gin.Context) (*models.Task, string, uuid.UUID, bool) {
+	principalType, principalID, ok := timePrincipal(c)
+	if !ok {
+		return nil, "", uuid.Nil, false
+	}
+
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "invalid task id")
+		return nil, "", uuid.Nil, false
+	}
+
+	task, ok := loadTrackableTask(c, principalType, principalID, taskID)
+	if !ok {
+		return nil, "", uuid.Nil, false
+	}
+	return task, principalType, principalID, true
+}
+
+func loadChecklistItem(c *gin.Context, taskID uuid.UUID) (*models.ChecklistItem, bool) {
+	var item models.ChecklistItem
+	if err := config.DB.First(&item, "id = ? AND task_id = ?", c.Param("itemId"), taskID).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "checklist item not found")
+		return nil, false
+	}
+	return &item, true
+}
+
+func GetTaskChecklist(c *gin.Context) {
+	task, _, _, ok := loadChecklistTask(c)
+	if !ok {
+		return
+	}
+
+	var items []models.ChecklistItem
+	if err := config.DB.Where("task_id = ?", task.ID).Order("position ASC, created_at ASC").Find(&items).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch checklist")
+		return
+	}
+
+	done := 0
+	for _, item := range items {
+		if item.Done {
+			done++
+		}
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"items": items,
+		"total": len(items),
+		"done":  done,
+	})
+}
+
+func AddChecklistItem(c *gin.Context) {
+	task, _, _, ok := loadChecklistTask(c)
+	if !ok {
+		return
+	}
+
+	var input ChecklistItemInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	item := models.ChecklistItem{
+		TaskID: task.ID,
+		Title:  strings.TrimSpace(input.Title),
+	}
+	if input.Position != nil {
+		item.Position = *input.Position
+	} else {
+		// new items go to the bottom
+		var last int
+		config.DB.Model(&models.ChecklistItem{}).Where("task_id = ?", task.ID).Select("COALESCE(MAX(position), -1)").Scan(&last)
+		item.Position = last + 1
+	}
+
+	if err := config.DB.WithContext(c).Create(&item).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to add checklist item")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusCreated, gin.H{
+		"message": "Checklist item added",
+		"item":    item,
+	})
+}
+
+// UpdateChecklistItem renames, reorders or ticks off an item
+func UpdateChecklistItem(c *gin.Context) {
+	task, principalType, principalID, ok := loadChecklistTask(c)
+	if !ok {
+		return
+	}
+
+	item, ok := loadChecklistItem(c, task.ID)
+	if !ok {
+		return
+	}
+
+	var input ChecklistItemUpdateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if input.Title != nil {
+		item.Title = strings.TrimSpace(*input.Title)
+	}
+	if input.Position != nil {
+		item.Position = *input.Position
+	}
+	if input.Done != nil && *input.Done != item.Done {
+		item.Done = *input.Done
+		if item.Done {
+			now := time.Now()
+			item.DoneAt = &now
+			item.DoneByType = &principalType
+			item.DoneByID = &principalID
+		} else {
+			item.DoneAt = nil
+			item.DoneByType = nil
+			item.DoneByID = nil
+		}
+	}
+
+	if err := config.DB.WithContext(c).Save(item).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to update checklist item")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"message": "Checklist item updated",
+		"item":    item,
+	})
+}
+
+func DeleteChecklistItem(c *gin.Context) {
+	task, _, _, ok := loadChecklistTask(c)
+	if !ok {
+		return
+	}
+
+	item, ok := loadChecklistItem(c, task.ID)
+	if !ok {
+		return
+	}
+
+	if err := config.DB.WithContext(c).Delete(item).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to delete checklist item")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{"message": "checklist item deleted"})
+}
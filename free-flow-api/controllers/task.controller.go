@@ -19,6 +19,7 @@ type TaskInput struct {
 	EstimatedHours      float64    `json:"estimated_hours"`
 	AssignedToAssociate *uuid.UUID `json:"assigned_to_associate,omitempty"`
 	ProjectID           uuid.UUID  `json:"project_id" binding:"required"`
+	ParentID            *uuid.UUID `json:"parent_id,omitempty"`
 }
 
 type TaskUpdateInput struct {
@@ -62,10 +63,25 @@ func NewTask(c *gin.Context) {
 		EstimatedHours:      input.EstimatedHours,
 		AssignedToAssociate: input.AssignedToAssociate,
 		ProjectID:           input.ProjectID,
+		ParentID:            input.ParentID,
 		CreatedBy:           uuid.MustParse(userID),
 	}
 
-	if err := config.DB.Create(&task).Error; err != nil {
+	// subtasks live on the parent's project and milestone
+	if input.ParentID != nil {
+		var parent models.Task
+		if err := config.DB.First(&parent, "id = ? AND project_id = ?", *input.ParentID, input.ProjectID).Error; err != nil {
+			utils.SendErrorResponse(c, http.StatusNotFound, "parent task not found on this project")
+			return
+		}
+		if parent.Status == models.TaskStatusPaid {
+			utils.SendErrorResponse(c, http.StatusConflict, "cannot add subtasks to a paid task")
+			return
+		}
+		task.MilestoneID = parent.MilestoneID
+	}
+
+	if err := config.DB.WithContext(c).Create(&task).Error; err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to create a new task")
 		return
 	}
@@ -132,7 +148,12 @@ func GetTasksByID(c *gin.Context) {
 	taskID := c.Param("id")
 
 	var task models.Task
-	if err := config.DB.First(&task, "id = ?", taskID).Error; err != nil {
+	if err := config.DB.
+		Preload("Subtasks").
+		Preload("Checklist", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position ASC, created_at ASC")
+		}).
+		First(&task, "id = ?", taskID).Error; err != nil {
 		utils.SendErrorResponse(c, http.StatusNotFound, "Task Not Found")
 		c.Abort()
 		return
@@ -168,8 +189,24 @@ func UpdateTask(c *gin.Context) {
 		return
 	}
 
+	// a parent's status, hours and value are rolled up from its subtasks
+	if updates.Status != nil || updates.EstimatedHours != nil || updates.TaskValue != nil {
+		var subtasks int64
+		config.DB.Model(&models.Task{}).Where("parent_id = ?", task.ID).Count(&subtasks)
+		if subtasks > 0 {
+			utils.SendErrorResponse(c, http.StatusConflict, "status, estimated hours and value of a task with subtasks follow its subtasks")
+			return
+		}
+	}
+
+	// statuses follow the task transition graph; paid is reached through settlements only
+	if updates.Status != nil && *updates.Status != task.Status && *updates.Status == models.TaskStatusPaid {
+		utils.SendErrorResponse(c, http.StatusConflict, "a task is marked paid once its associate settlement is settled")
+		return
+	}
+
 	//begin database transaction
-	tx := config.DB.Begin()
+	tx := config.DB.WithContext(c).Begin()
 	if tx.Error != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to start transaction")
 		return
@@ -214,7 +251,7 @@ func UpdateTask(c *gin.Context) {
 		}
 
 		// Create invite
-		if err := InviteAssociate(task, associateID, contract.ID); err != nil {
+		if err := InviteAssociate(tx, task, associateID, contract.ID); err != nil {
 			tx.Rollback()
 			utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to create invite")
 			return
@@ -223,35 +260,21 @@ func UpdateTask(c *gin.Context) {
 		updateMap["assigned_to_associate"] = nil
 	}
 
-	if updates.Status != nil {
-		newStatus := *updates.Status
-
-		updateMap["status"] = newStatus
-
-		if newStatus == models.TaskStatusInProgress && task.Project.StartDate == nil {
-			now := time.Now()
-			updateMap["start_date"] = &now
-		}
-
-		if newStatus == models.TaskStatusDone {
-			now := time.Now()
-			updateMap["completed_at"] = &now
-
-			// compute actual hours if start_date is known
-			start := task.StartDate
-			if start != nil {
-				duration := now.Sub(*start).Hours()
-				updateMap["actual_hours"] = duration
-			}
-		}
-	}
-
 	// perform an update
 	if err := tx.Model(&task).Updates(updateMap).Error; err != nil {
 		tx.Rollback()
 		utils.SendErrorResponse(c, http.StatusInternalServerError, "Failed to update the project")
 	}
 
+	if updates.Status != nil {
+		actor := models.StatusActor{Type: models.PrincipalUser, ID: uuid.MustParse(userID)}
+		if err := models.TransitionTask(tx, &task, *updates.Status, actor); err != nil {
+			tx.Rollback()
+			sendTransitionError(c, err, "failed to update task status")
+			return
+		}
+	}
+
 	// After successful update, call settlement upsert
 	if err := UpsertSettlementOnTaskAssignment(c, task); err != nil {
 		tx.Rollback()
@@ -293,7 +316,7 @@ func DeleteTask(c *gin.Context) {
 		return
 	}
 
-	if err := config.DB.Delete(&task).Error; err != nil {
+	if err := config.DB.WithContext(c).Delete(&task).Error; err != nil {
 		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to delete task")
 		return
 	}
@@ -305,6 +328,28 @@ func DeleteTask(c *gin.Context) {
 	utils.SendSuccessResponse(c, http.StatusOK, data)
 }
 
+// GetSubtasks lists a task's direct subtasks with their checklists
+func GetSubtasks(c *gin.Context) {
+	task, ok := loadOwnedTask(c)
+	if !ok {
+		return
+	}
+
+	var subtasks []models.Task
+	if err := config.DB.
+		Preload("Checklist", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position ASC, created_at ASC")
+		}).
+		Where("parent_id = ?", task.ID).
+		Order("created_at ASC").
+		Find(&subtasks).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch subtasks")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, subtasks)
+}
+
 func GetAllTasksByAssociateID(c *gin.Context) {
 	//validate jwt token
 	userID := c.GetString("userID")
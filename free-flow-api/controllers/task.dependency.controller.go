@@ -0,0 +1,489 @@
+package controllers
+
+import (
+	"errors"
+	"free-flow-api/config"
+	"free-flow-api/models"
+	"free-flow-api/utils"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// working hours that make up one calendar day of the schedule
+const defaultHoursPerDay = 8.0
+
+type TaskDependencyInput struct {
+	PredecessorID uuid.UUID `json:"predecessor_id" binding:"required"`
+	LagHours      float64   `json:"lag_hours" binding:"gte=0"`
+}
+
+type ScheduledTask struct {
+	TaskID              uuid.UUID         `json:"task_id"`
+	Title               string            `json:"title"`
+	Status              models.TaskStatus `json:"status"`
+	MilestoneID         *uuid.UUID        `json:"milestone_id"`
+	AssignedToAssociate *uuid.UUID        `json:"assigned_to_associate"`
+	Predecessors        []uuid.UUID       `json:"predecessors"`
+
+	DurationHours  float64    `json:"duration_hours"`
+	EarliestStart  time.Time  `json:"earliest_start"`
+	EarliestFinish time.Time  `json:"earliest_finish"`
+	LatestStart    time.Time  `json:"latest_start"`
+	LatestFinish   time.Time  `json:"latest_finish"`
+	SlackHours     float64    `json:"slack_hours"`
+	Critical       bool       `json:"critical"`
+	DueDate        *time.Time `json:"due_date"`
+	Late           bool       `json:"late"`    // earliest finish is past the due date
+	Blocked        bool       `json:"blocked"` // a predecessor is not finished yet
+
+	es, ef, ls, lf float64
+}
+
+type ProjectSchedule struct {
+	ProjectID     uuid.UUID               `json:"project_id"`
+	Start         time.Time               `json:"start"`
+	Finish        time.Time               `json:"finish"`
+	Deadline      *time.Time              `json:"deadline"`
+	MeetsDeadline bool                    `json:"meets_deadline"`
+	HoursPerDay   float64                 `json:"hours_per_day"`
+	DurationHours float64                 `json:"duration_hours"`
+	CriticalPath  []uuid.UUID             `json:"critical_path"`
+	Tasks         []ScheduledTask         `json:"tasks"`
+	Dependencies  []models.TaskDependency `json:"dependencies"`
+}
+
+var errParentDependency = errors.New("dependencies link subtasks, not tasks that have subtasks")
+
+func isTaskFinished(status models.TaskStatus) bool {
+	return status == models.TaskStatusDone || status == models.TaskStatusPaid
+}
+
+func roundHours(h float64) float64 {
+	return math.Round(h*100) / 100
+}
+
+// scheduleProject runs a forward pass from the project start and a backward pass from the
+// project finish (or earlier deadline) over the dependency graph. Offsets are working hours;
+// task start dates act as start-no-earlier-than and due dates as finish-no-later-than constraints.
+func scheduleProject(project models.Project, tasks []models.Task, deps []models.TaskDependency, hoursPerDay float64) (*ProjectSchedule, error) {
+	// only leaf tasks carry work of their own; a parent's hours are the sum of its subtasks
+	parents := make(map[uuid.UUID]bool)
+	for _, t := range tasks {
+		if t.ParentID != nil {
+			parents[*t.ParentID] = true
+		}
+	}
+	leaves := make([]models.Task, 0, len(tasks))
+	for _, t := range tasks {
+		if !parents[t.ID] {
+			leaves = append(leaves, t)
+		}
+	}
+	tasks = leaves
+
+	index := make(map[uuid.UUID]int, len(tasks))
+	for i, t := range tasks {
+		index[t.ID] = i
+	}
+
+	preds := make([][]models.TaskDependency, len(tasks))
+	succs := make([][]models.TaskDependency, len(tasks))
+	inDegree := make([]int, len(tasks))
+	links := make([]models.TaskDependency, 0, len(deps))
+	for _, d := range deps {
+		p, okP := index[d.PredecessorID]
+		s, okS := index[d.SuccessorID]
+		if !okP || !okS {
+			continue
+		}
+		preds[s] = append(preds[s], d)
+		succs[p] = append(succs[p], d)
+		inDegree[s]++
+		links = append(links, d)
+	}
+
+	// topological order; anything left over sits on a cycle
+	order := make([]int, 0, len(tasks))
+	queue := []int{}
+	for i := range tasks {
+		if inDegree[i] == 0 {
+			queue = append(queue, i)
+		}
+	}
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		order = append(order, i)
+		for _, d := range succs[i] {
+			s := index[d.SuccessorID]
+			inDegree[s]--
+			if inDegree[s] == 0 {
+				queue = append(queue, s)
+			}
+		}
+	}
+	if len(order) != len(tasks) {
+		return nil, models.ErrDependencyCycle
+	}
+
+	// the schedule is anchored on the project start, or the earliest fixed task date, or today
+	now := time.Now()
+	origin := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if project.StartDate != nil {
+		origin = *project.StartDate
+	}
+	for _, t := range tasks {
+		if t.StartDate != nil && t.StartDate.Before(origin) {
+			origin = *t.StartDate
+		}
+	}
+	toHours := func(at time.Time) float64 {
+		return at.Sub(origin).Hours() / 24 * hoursPerDay
+	}
+	toTime := func(h float64) time.Time {
+		return origin.Add(time.Duration(h / hoursPerDay * 24 * float64(time.Hour)))
+	}
+
+	scheduled := make([]ScheduledTask, len(tasks))
+	finish := 0.0
+	for _, i := range order {
+		t := tasks[i]
+		st := &scheduled[i]
+		st.TaskID = t.ID
+		st.Title = t.Title
+		st.Status = t.Status
+		st.MilestoneID = t.MilestoneID
+		st.AssignedToAssociate = t.AssignedToAssociate
+		st.DueDate = t.DueDate
+		st.Predecessors = []uuid.UUID{}
+		st.DurationHours = math.Max(t.EstimatedHours, 0)
+
+		// finished work sits where it actually happened
+		if isTaskFinished(t.Status) && t.CompletedAt != nil {
+			st.ef = toHours(*t.CompletedAt)
+			st.es = st.ef - st.DurationHours
+			if t.StartDate != nil {
+				st.es = math.Min(toHours(*t.StartDate), st.ef)
+			}
+		} else {
+			if t.StartDate != nil {
+				st.es = math.Max(st.es, toHours(*t.StartDate))
+			}
+			for _, d := range preds[i] {
+				p := &scheduled[index[d.PredecessorID]]
+				st.es = math.Max(st.es, p.ef+d.LagHours)
+			}
+			st.ef = st.es + st.DurationHours
+		}
+		for _, d := range preds[i] {
+			st.Predecessors = append(st.Predecessors, d.PredecessorID)
+			if !isTaskFinished(tasks[index[d.PredecessorID]].Status) {
+				st.Blocked = true
+			}
+		}
+		finish = math.Max(finish, st.ef)
+	}
+
+	end := finish
+	meetsDeadline := true
+	if project.Deadline != nil {
+		deadline := toHours(*project.Deadline)
+		meetsDeadline = finish <= deadline
+		end = math.Min(end, deadline)
+	}
+
+	for k := len(order) - 1; k >= 0; k-- {
+		i := order[k]
+		st := &scheduled[i]
+		st.lf = end
+		for _, d := range succs[i] {
+			s := &scheduled[index[d.SuccessorID]]
+			st.lf = math.Min(st.lf, s.ls-d.LagHours)
+		}
+		if st.DueDate != nil {
+			st.lf = math.Min(st.lf, toHours(*st.DueDate))
+			st.Late = st.ef > toHours(*st.DueDate)+1e-6
+		}
+		st.ls = st.lf - st.DurationHours
+	}
+
+	critical := []int{}
+	for i := range scheduled {
+		st := &scheduled[i]
+		st.EarliestStart = toTime(st.es)
+		st.EarliestFinish = toTime(st.ef)
+		st.LatestStart = toTime(st.ls)
+		st.LatestFinish = toTime(st.lf)
+		st.SlackHours = roundHours(st.ls - st.es)
+		st.Critical = st.ls-st.es <= 1e-6
+		if st.Critical {
+			critical = append(critical, i)
+		}
+	}
+	sort.SliceStable(critical, func(a, b int) bool { return scheduled[critical[a]].es < scheduled[critical[b]].es })
+	path := make([]uuid.UUID, 0, len(critical))
+	for _, i := range critical {
+		path = append(path, scheduled[i].TaskID)
+	}
+
+	sort.SliceStable(scheduled, func(a, b int) bool { return scheduled[a].es < scheduled[b].es })
+
+	return &ProjectSchedule{
+		ProjectID:     project.ID,
+		Start:         origin,
+		Finish:        toTime(finish),
+		Deadline:      project.Deadline,
+		MeetsDeadline: meetsDeadline,
+		HoursPerDay:   hoursPerDay,
+		DurationHours: roundHours(finish),
+		CriticalPath:  path,
+		Tasks:         scheduled,
+		Dependencies:  links,
+	}, nil
+}
+
+// loadProjectSchedule computes the schedule of a project owned by the freelancer
+func loadProjectSchedule(c *gin.Context) (*models.Project, *ProjectSchedule, bool) {
+	userID := c.GetString("userID")
+	if !utils.IsAuthenticated(userID) {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid user token")
+		c.Abort()
+		return nil, nil, false
+	}
+
+	var project models.Project
+	if err := config.DB.First(&project, "id = ? AND user_id = ?", c.Param("id"), userID).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "project not found")
+		return nil, nil, false
+	}
+
+	hoursPerDay := defaultHoursPerDay
+	if h := c.Query("hours_per_day"); h != "" {
+		parsed, err := strconv.ParseFloat(h, 64)
+		if err != nil || parsed <= 0 || parsed > 24 {
+			utils.SendErrorResponse(c, http.StatusBadRequest, "invalid hours_per_day")
+			return nil, nil, false
+		}
+		hoursPerDay = parsed
+	}
+
+	var tasks []models.Task
+	if err := config.DB.Where("project_id = ?", project.ID).Order("created_at ASC").Find(&tasks).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch tasks")
+		return nil, nil, false
+	}
+	var deps []models.TaskDependency
+	if err := config.DB.Where("project_id = ?", project.ID).Find(&deps).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch dependencies")
+		return nil, nil, false
+	}
+
+	schedule, err := scheduleProject(project, tasks, deps, hoursPerDay)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusConflict, err.Error())
+		return nil, nil, false
+	}
+	return &project, schedule, true
+}
+
+// loadOwnedTask fetches a task on one of the freelancer's projects
+func loadOwnedTask(c *gin.Context) (*models.Task, bool) {
+	userID := c.GetString("userID")
+	if !utils.IsAuthenticated(userID) {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid user token")
+		c.Abort()
+		return nil, false
+	}
+
+	var task models.Task
+	if err := config.DB.
+		Joins("JOIN projects ON projects.id = tasks.project_id").
+		Where("tasks.id = ? AND projects.user_id = ?", c.Param("id"), userID).
+		First(&task).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "task not found")
+		return nil, false
+	}
+	return &task, true
+}
+
+// AddTaskDependency makes the task wait for a predecessor on the same project to finish
+func AddTaskDependency(c *gin.Context) {
+	task, ok := loadOwnedTask(c)
+	if !ok {
+		return
+	}
+
+	var input TaskDependencyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var dependency models.TaskDependency
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		// links on a project are added one at a time, so two concurrent inserts can't close a cycle
+		var lockedIDs []uuid.UUID
+		if err := tx.Model(&models.Project{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", task.ProjectID).Pluck("id", &lockedIDs).Error; err != nil {
+			return err
+		}
+
+		var predecessor models.Task
+		if err := tx.First(&predecessor, "id = ? AND project_id = ?", input.PredecessorID, task.ProjectID).Error; err != nil {
+			return err
+		}
+
+		// parents are scheduled through their subtasks, so links go between leaf tasks
+		var parents int64
+		if err := tx.Model(&models.Task{}).Where("parent_id IN ?", []uuid.UUID{predecessor.ID, task.ID}).Count(&parents).Error; err != nil {
+			return err
+		}
+		if parents > 0 {
+			return errParentDependency
+		}
+
+		var existing int64
+		if err := tx.Model(&models.TaskDependency{}).
+			Where("predecessor_id = ? AND successor_id = ?", predecessor.ID, task.ID).
+			Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return gorm.ErrDuplicatedKey
+		}
+
+		cycle, err := models.DependencyCreatesCycle(tx, task.ProjectID, predecessor.ID, task.ID)
+		if err != nil {
+			return err
+		}
+		if cycle {
+			return models.ErrDependencyCycle
+		}
+
+		dependency = models.TaskDependency{
+			ProjectID:     task.ProjectID,
+			PredecessorID: predecessor.ID,
+			SuccessorID:   task.ID,
+			LagHours:      input.LagHours,
+		}
+		return tx.Create(&dependency).Error
+	})
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.SendErrorResponse(c, http.StatusNotFound, "predecessor task not found on this project")
+		return
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		utils.SendErrorResponse(c, http.StatusConflict, "dependency already exists")
+		return
+	case errors.Is(err, models.ErrDependencyCycle), errors.Is(err, errParentDependency):
+		utils.SendErrorResponse(c, http.StatusConflict, err.Error())
+		return
+	case err != nil:
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to add dependency")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusCreated, gin.H{
+		"message":    "Dependency added successfully",
+		"dependency": dependency,
+	})
+}
+
+// GetTaskDependencies lists what the task waits on and what waits on it
+func GetTaskDependencies(c *gin.Context) {
+	task, ok := loadOwnedTask(c)
+	if !ok {
+		return
+	}
+
+	var predecessors, successors []models.TaskDependency
+	if err := config.DB.Preload("Predecessor").Where("successor_id = ?", task.ID).Find(&predecessors).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch dependencies")
+		return
+	}
+	if err := config.DB.Preload("Successor").Where("predecessor_id = ?", task.ID).Find(&successors).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch dependencies")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"predecessors": predecessors,
+		"successors":   successors,
+	})
+}
+
+func DeleteTaskDependency(c *gin.Context) {
+	task, ok := loadOwnedTask(c)
+	if !ok {
+		return
+	}
+
+	result := config.DB.WithContext(c).
+		Where("id = ? AND (predecessor_id = ? OR successor_id = ?)", c.Param("dependencyId"), task.ID, task.ID).
+		Delete(&models.TaskDependency{})
+	if result.Error != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to delete dependency")
+		return
+	}
+	if result.RowsAffected == 0 {
+		utils.SendErrorResponse(c, http.StatusNotFound, "dependency not found")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{"message": "dependency deleted"})
+}
+
+// GetProjectSchedule returns the Gantt view of a project: dates, slack and the critical path
+func GetProjectSchedule(c *gin.Context) {
+	_, schedule, ok := loadProjectSchedule(c)
+	if !ok {
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, schedule)
+}
+
+// ApplyProjectSchedule writes the earliest dates back onto unfinished tasks; due dates
+// that were already set are kept
+func ApplyProjectSchedule(c *gin.Context) {
+	_, schedule, ok := loadProjectSchedule(c)
+	if !ok {
+		return
+	}
+
+	updated := 0
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		for _, st := range schedule.Tasks {
+			if isTaskFinished(st.Status) {
+				continue
+			}
+			updates := map[string]interface{}{"start_date": st.EarliestStart}
+			if st.DueDate == nil {
+				updates["due_date"] = st.EarliestFinish
+			}
+			if err := tx.Model(&models.Task{}).Where("id = ?", st.TaskID).UpdateColumns(updates).Error; err != nil {
+				return err
+			}
+			updated++
+		}
+		return nil
+	})
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to apply schedule")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"message":  "Schedule applied successfully",
+		"updated":  updated,
+		"schedule": schedule,
+	})
+}
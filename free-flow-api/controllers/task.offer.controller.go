@@ -0,0 +1,403 @@
+package controllers
+
+import (
+	"errors"
+	"free-flow-api/config"
+	"free-flow-api/models"
+	"free-flow-api/utils"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TaskOfferInput struct {
+	AssociateIDs []uuid.UUID `json:"associate_ids" binding:"required,min=1"`
+	Mode         string      `json:"mode" binding:"omitempty,oneof=first_accept bid"`
+	Message      string      `json:"message"`
+}
+
+type OfferBidInput struct {
+	Price          *float64   `json:"price" binding:"omitempty,gt=0"`
+	EstimatedHours *float64   `json:"estimated_hours" binding:"omitempty,gt=0"`
+	DeliveryDate   *time.Time `json:"delivery_date"`
+	Message        string     `json:"message"`
+}
+
+type AwardOfferInput struct {
+	BidID uuid.UUID `json:"bid_id" binding:"required"`
+}
+
+// awardOffer assigns the task to the winning associate, closes out the other invites and bids
+// and records the agreed price in both the contract terms and the settlement.
+func awardOffer(tx *gorm.DB, offer *models.TaskOffer, associateID uuid.UUID, bid *models.OfferBid) error {
+	now := time.Now()
+	offer.Status = "awarded"
+	offer.AwardedTo = &associateID
+	offer.AwardedAt = &now
+	if bid != nil {
+		offer.AwardedBidID = &bid.ID
+	}
+	if err := tx.Omit(clause.Associations).Save(offer).Error; err != nil {
+		return err
+	}
+
+	var task models.Task
+	if err := tx.First(&task, "id = ?", offer.TaskID).Error; err != nil {
+		return err
+	}
+
+	taskUpdates := map[string]interface{}{"assigned_to_associate": associateID}
+	var price *float64
+	if bid != nil {
+		price = bid.Price
+		if bid.EstimatedHours != nil {
+			taskUpdates["estimated_hours"] = *bid.EstimatedHours
+		}
+		if bid.DeliveryDate != nil {
+			taskUpdates["due_date"] = *bid.DeliveryDate
+		}
+	}
+	if err := tx.Model(&task).Updates(taskUpdates).Error; err != nil {
+		return err
+	}
+
+	// the agreed price and timeline become part of the contract the winner signs
+	if bid != nil && (bid.Price != nil || bid.DeliveryDate != nil) {
+		var contract models.Contract
+		if err := tx.First(&contract, "id = ?", offer.ContractID).Error; err != nil {
+			return err
+		}
+		changed := false
+		if bid.Price != nil && (contract.AgreedPrice == nil || *contract.AgreedPrice != *bid.Price) {
+			contract.AgreedPrice = bid.Price
+			changed = true
+		}
+		if bid.DeliveryDate != nil && !contract.EndDate.Equal(*bid.DeliveryDate) {
+			contract.EndDate = *bid.DeliveryDate
+			changed = true
+		}
+		if changed && !contract.IsAccepted() {
+			if err := tx.Save(&contract).Error; err != nil {
+				return err
+			}
+			if _, err := createContractVersion(tx, &contract, "awarded bid", false); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := tx.Model(&models.Invite{}).
+		Where("offer_id = ? AND associate_id <> ? AND status = ?", offer.ID, associateID, "pending").
+		Update("status", "cancelled").Error; err != nil {
+		return err
+	}
+
+	if err := tx.Model(&models.OfferBid{}).
+		Where("offer_id = ?", offer.ID).
+		Update("status", gorm.Expr("CASE WHEN associate_id = ? THEN 'won' ELSE 'lost' END", associateID)).Error; err != nil {
+		return err
+	}
+
+	return upsertTaskSettlement(tx, task, associateID, offer.UserID, price)
+}
+
+// releaseAwardedOffer undoes an award when the winner declines to sign
+func releaseAwardedOffer(tx *gorm.DB, offer *models.TaskOffer) error {
+	if err := tx.Model(offer).Update("status", "cancelled").Error; err != nil {
+		return err
+	}
+
+	if err := tx.Model(&models.Task{}).
+		Where("id = ?", offer.TaskID).
+		Update("assigned_to_associate", nil).Error; err != nil {
+		return err
+	}
+
+	return tx.Where("task_id = ? AND associate_id = ?", offer.TaskID, *offer.AwardedTo).
+		Delete(&models.AssociateSettlement{}).Error
+}
+
+// loadOwnedOffer fetches an offer created by the authenticated freelancer
+func loadOwnedOffer(c *gin.Context) (*models.TaskOffer, bool) {
+	userID := c.GetString("userID")
+	if !utils.IsAuthenticated(userID) {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid user token")
+		c.Abort()
+		return nil, false
+	}
+
+	var offer models.TaskOffer
+	if err := config.DB.First(&offer, "id = ? AND user_id = ?", c.Param("id"), userID).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "offer not found")
+		return nil, false
+	}
+
+	return &offer, true
+}
+
+// CreateTaskOffer sends the task's contract to a shortlist of associates
+func CreateTaskOffer(c *gin.Context) {
+	userID := c.GetString("userID")
+	if !utils.IsAuthenticated(userID) {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid user token")
+		c.Abort()
+		return
+	}
+
+	var input TaskOfferInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if input.Mode == "" {
+		input.Mode = models.OfferModeFirstAccept
+	}
+
+	var task models.Task
+	if err := config.DB.
+		Joins("JOIN projects ON projects.id = tasks.project_id").
+		Where("tasks.id = ? AND projects.user_id = ?", c.Param("id"), userID).
+		First(&task).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "task not found")
+		return
+	}
+
+	if task.AssignedToAssociate != nil {
+		utils.SendErrorResponse(c, http.StatusConflict, "task is already assigned")
+		return
+	}
+
+	var contract models.Contract
+	if err := config.DB.First(&contract, "task_id = ?", task.ID).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "No contract found for this task. Cannot send offer.")
+		return
+	}
+
+	var open int64
+	config.DB.Model(&models.TaskOffer{}).Where("task_id = ? AND status = ?", task.ID, "open").Count(&open)
+	if open > 0 {
+		utils.SendErrorResponse(c, http.StatusConflict, "task already has an open offer")
+		return
+	}
+
+	// only the freelancer's own associates can be shortlisted
+	var associates []models.Associate
+	if err := config.DB.
+		Where("id IN ? AND user_id = ?", input.AssociateIDs, userID).
+		Find(&associates).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch associates")
+		return
+	}
+	if len(associates) == 0 {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "no valid associates in shortlist")
+		return
+	}
+
+	offer := models.TaskOffer{
+		TaskID:     task.ID,
+		ProjectID:  task.ProjectID,
+		ContractID: contract.ID,
+		UserID:     uuid.MustParse(userID),
+		Mode:       input.Mode,
+		Status:     "open",
+		Message:    input.Message,
+	}
+
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&offer).Error; err != nil {
+			return err
+		}
+		for _, a := range associates {
+			invite, err := sendInvite(tx, task, a.ID, contract.ID, &offer.ID)
+			if err != nil {
+				return err
+			}
+			offer.Invites = append(offer.Invites, *invite)
+		}
+		return nil
+	})
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to create offer")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusCreated, gin.H{
+		"message": "Offer sent successfully",
+		"offer":   offer,
+	})
+}
+
+func GetTaskOffers(c *gin.Context) {
+	userID := c.GetString("userID")
+	if !utils.IsAuthenticated(userID) {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid user token")
+		c.Abort()
+		return
+	}
+
+	var offers []models.TaskOffer
+	if err := config.DB.
+		Preload("Invites").
+		Preload("Bids.Associate").
+		Where("task_id = ? AND user_id = ?", c.Param("id"), userID).
+		Order("created_at DESC").
+		Find(&offers).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch offers")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, offers)
+}
+
+func GetTaskOffer(c *gin.Context) {
+	offer, ok := loadOwnedOffer(c)
+	if !ok {
+		return
+	}
+
+	if err := config.DB.
+		Preload("Invites").
+		Preload("Bids.Associate").
+		First(offer, "id = ?", offer.ID).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch offer")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, offer)
+}
+
+// AwardTaskOffer picks a bid; the winner's invite stays open so they can sign the final terms
+func AwardTaskOffer(c *gin.Context) {
+	offer, ok := loadOwnedOffer(c)
+	if !ok {
+		return
+	}
+
+	var input AwardOfferInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if offer.Status != "open" {
+		utils.SendErrorResponse(c, http.StatusConflict, "offer is no longer open")
+		return
+	}
+
+	var bid models.OfferBid
+	if err := config.DB.First(&bid, "id = ? AND offer_id = ?", input.BidID, offer.ID).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "bid not found")
+		return
+	}
+
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		return awardOffer(tx, offer, bid.AssociateID, &bid)
+	})
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to award offer")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"message": "Offer awarded successfully",
+		"offer":   offer,
+	})
+}
+
+func CancelTaskOffer(c *gin.Context) {
+	offer, ok := loadOwnedOffer(c)
+	if !ok {
+		return
+	}
+
+	if offer.Status != "open" {
+		utils.SendErrorResponse(c, http.StatusConflict, "offer is no longer open")
+		return
+	}
+
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(offer).Update("status", "cancelled").Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Invite{}).
+			Where("offer_id = ? AND status = ?", offer.ID, "pending").
+			Update("status", "cancelled").Error
+	})
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to cancel offer")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"message": "Offer cancelled successfully",
+	})
+}
+
+// SubmitOfferBid lets an invited associate accept the listed terms or counter with their own price and timeline
+func SubmitOfferBid(c *gin.Context) {
+	inviteID := c.GetString("invite_id")
+	if inviteID == "" {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid or missing token")
+		return
+	}
+
+	var input OfferBidInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var invite models.Invite
+	if err := config.DB.First(&invite, "id = ?", inviteID).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "invite not found")
+		return
+	}
+	if invite.OfferID == nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "invite is not part of an offer")
+		return
+	}
+	if invite.Status != "pending" {
+		utils.SendErrorResponse(c, http.StatusConflict, "invite is no longer pending ("+invite.Status+")")
+		return
+	}
+
+	var offer models.TaskOffer
+	if err := config.DB.First(&offer, "id = ?", *invite.OfferID).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "offer not found")
+		return
+	}
+	if offer.Status != "open" {
+		utils.SendErrorResponse(c, http.StatusConflict, "offer is no longer open")
+		return
+	}
+
+	var bid models.OfferBid
+	err := config.DB.First(&bid, "offer_id = ? AND invite_id = ?", offer.ID, invite.ID).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch bid")
+		return
+	}
+
+	bid.OfferID = offer.ID
+	bid.InviteID = invite.ID
+	bid.AssociateID = invite.AssociateID
+	bid.Price = input.Price
+	bid.EstimatedHours = input.EstimatedHours
+	bid.DeliveryDate = input.DeliveryDate
+	bid.Message = input.Message
+	bid.Status = "submitted"
+
+	if err := config.DB.WithContext(c).Save(&bid).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to submit bid")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"message": "Bid submitted successfully",
+		"bid":     bid,
+	})
+}
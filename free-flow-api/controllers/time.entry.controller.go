@@ -0,0 +1,610 @@
+package controllers
+
+import (
+	"errors"
+	"free-flow-api/config"
+	"free-flow-api/models"
+	"free-flow-api/utils"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type StartTimerInput struct {
+	TaskID   uuid.UUID `json:"task_id" binding:"required"`
+	Notes    string    `json:"notes"`
+	Billable *bool     `json:"billable"`
+}
+
+type StopTimerInput struct {
+	Notes *string `json:"notes"`
+}
+
+// ManualTimeInput records past work; give either ended_at or hours
+type ManualTimeInput struct {
+	TaskID    uuid.UUID  `json:"task_id" binding:"required"`
+	StartedAt time.Time  `json:"started_at" binding:"required"`
+	EndedAt   *time.Time `json:"ended_at"`
+	Hours     *float64   `json:"hours" binding:"omitempty,gt=0,lte=24"`
+	Notes     string     `json:"notes"`
+	Billable  *bool      `json:"billable"`
+}
+
+type TimeEntryUpdateInput struct {
+	StartedAt *time.Time `json:"started_at"`
+	EndedAt   *time.Time `json:"ended_at"`
+	Notes     *string    `json:"notes"`
+	Billable  *bool      `json:"billable"`
+}
+
+type TimesheetDay struct {
+	Date          string  `json:"date"`
+	Hours         float64 `json:"hours"`
+	BillableHours float64 `json:"billable_hours"`
+}
+
+type TimesheetRow struct {
+	TaskID      uuid.UUID  `json:"task_id"`
+	TaskTitle   string     `json:"task_title"`
+	ProjectID   uuid.UUID  `json:"project_id"`
+	ProjectName string     `json:"project_name"`
+	Days        [7]float64 `json:"days"`
+	Total       float64    `json:"total"`
+}
+
+var errTimerRunning = errors.New("a timer is already running; stop it first")
+
+// timePrincipal works out who is tracking time: the freelancer or an associate
+func timePrincipal(c *gin.Context) (string, uuid.UUID, bool) {
+	principalID := c.GetString("userID")
+	ownerType := models.PrincipalUser
+	if !utils.IsAuthenticated(principalID) {
+		if !utils.IsAssociateAuthenticated(principalID) {
+			utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid user token")
+			c.Abort()
+			return "", uuid.Nil, false
+		}
+		ownerType = models.PrincipalAssociate
+	}
+	return ownerType, uuid.MustParse(principalID), true
+}
+
+// loadTrackableTask returns a task the principal may log time on: any task in the freelancer's
+// projects, or a task assigned to the associate
+func loadTrackableTask(c *gin.Context, ownerType string, ownerID, taskID uuid.UUID) (*models.Task, bool) {
+	var task models.Task
+	query := config.DB.Where("tasks.id = ?", taskID)
+	if ownerType == models.PrincipalUser {
+		query = query.Joins("JOIN projects ON projects.id = tasks.project_id").Where("projects.user_id = ?", ownerID)
+	} else {
+		query = query.Where("tasks.assigned_to_associate = ?", ownerID)
+	}
+	if err := query.First(&task).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "task not found")
+		return nil, false
+	}
+	return &task, true
+}
+
+// loadOwnTimeEntry returns an entry logged by the principal
+func loadOwnTimeEntry(c *gin.Context, ownerType string, ownerID uuid.UUID) (*models.TimeEntry, bool) {
+	var entry models.TimeEntry
+	if err := config.DB.First(&entry, "id = ? AND owner_type = ? AND owner_id = ?", c.Param("id"), ownerType, ownerID).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "time entry not found")
+		return nil, false
+	}
+	return &entry, true
+}
+
+func runningTimer(tx *gorm.DB, ownerType string, ownerID uuid.UUID) (*models.TimeEntry, error) {
+	var entry models.TimeEntry
+	err := tx.Preload("Task").
+		Where("owner_type = ? AND owner_id = ? AND ended_at IS NULL", ownerType, ownerID).
+		First(&entry).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// lockTimeOwner locks the user or associate row of whoever is tracking time
+func lockTimeOwner(tx *gorm.DB, ownerType string, ownerID uuid.UUID) error {
+	table := "users"
+	if ownerType == models.PrincipalAssociate {
+		table = "associates"
+	}
+	var locked []uuid.UUID
+	return tx.Table(table).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", ownerID).
+		Pluck("id", &locked).Error
+}
+
+// StartTimer starts a live timer on a task; each principal can run one timer at a time
+func StartTimer(c *gin.Context) {
+	ownerType, ownerID, ok := timePrincipal(c)
+	if !ok {
+		return
+	}
+
+	var input StartTimerInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	task, ok := loadTrackableTask(c, ownerType, ownerID, input.TaskID)
+	if !ok {
+		return
+	}
+	if task.Status == models.TaskStatusDone || task.Status == models.TaskStatusPaid {
+		utils.SendErrorResponse(c, http.StatusConflict, "cannot track time on a completed task")
+		return
+	}
+
+	entry := models.TimeEntry{
+		TaskID:    task.ID,
+		ProjectID: task.ProjectID,
+		OwnerType: ownerType,
+		OwnerID:   ownerID,
+		StartedAt: time.Now(),
+		Billable:  true,
+		Notes:     input.Notes,
+	}
+	if input.Billable != nil {
+		entry.Billable = *input.Billable
+	}
+
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		// lock the owner so concurrent starts queue up behind the running-timer check
+		if err := lockTimeOwner(tx, ownerType, ownerID); err != nil {
+			return err
+		}
+		running, err := runningTimer(tx, ownerType, ownerID)
+		if err != nil {
+			return err
+		}
+		if running != nil {
+			return errTimerRunning
+		}
+		// select all columns so billable=false is not replaced by the column default
+		return tx.Select("*").Omit("Task").Create(&entry).Error
+	})
+	if errors.Is(err, errTimerRunning) {
+		utils.SendErrorResponse(c, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to start timer")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusCreated, gin.H{
+		"message": "Timer started",
+		"entry":   entry,
+	})
+}
+
+// StopTimer stops the principal's running timer
+func StopTimer(c *gin.Context) {
+	ownerType, ownerID, ok := timePrincipal(c)
+	if !ok {
+		return
+	}
+
+	var input StopTimerInput
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	running, err := runningTimer(config.DB, ownerType, ownerID)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch timer")
+		return
+	}
+	if running == nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "no timer is running")
+		return
+	}
+
+	now := time.Now()
+	running.EndedAt = &now
+	if input.Notes != nil {
+		running.Notes = *input.Notes
+	}
+	running.Task = nil
+	if err := config.DB.WithContext(c).Save(running).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to stop timer")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"message": "Timer stopped",
+		"entry":   running,
+	})
+}
+
+func GetRunningTimer(c *gin.Context) {
+	ownerType, ownerID, ok := timePrincipal(c)
+	if !ok {
+		return
+	}
+
+	running, err := runningTimer(config.DB, ownerType, ownerID)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch timer")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"running": running != nil,
+		"entry":   running,
+	})
+}
+
+// CreateManualTimeEntry records work after the fact
+func CreateManualTimeEntry(c *gin.Context) {
+	ownerType, ownerID, ok := timePrincipal(c)
+	if !ok {
+		return
+	}
+
+	var input ManualTimeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	end := input.EndedAt
+	if end == nil {
+		if input.Hours == nil {
+			utils.SendErrorResponse(c, http.StatusBadRequest, "either ended_at or hours is required")
+			return
+		}
+		t := input.StartedAt.Add(time.Duration(*input.Hours * float64(time.Hour)))
+		end = &t
+	}
+	if !end.After(input.StartedAt) {
+		utils.SendErrorResponse(c, http.StatusBadRequest, models.ErrTimeEntryRange.Error())
+		return
+	}
+	if end.After(time.Now()) {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "time entries cannot end in the future")
+		return
+	}
+
+	task, ok := loadTrackableTask(c, ownerType, ownerID, input.TaskID)
+	if !ok {
+		return
+	}
+
+	entry := models.TimeEntry{
+		TaskID:    task.ID,
+		ProjectID: task.ProjectID,
+		OwnerType: ownerType,
+		OwnerID:   ownerID,
+		StartedAt: input.StartedAt,
+		EndedAt:   end,
+		Manual:    true,
+		Billable:  true,
+		Notes:     input.Notes,
+	}
+	if input.Billable != nil {
+		entry.Billable = *input.Billable
+	}
+
+	if err := config.DB.WithContext(c).Select("*").Omit("Task").Create(&entry).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to create time entry")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusCreated, gin.H{
+		"message": "Time entry created",
+		"entry":   entry,
+	})
+}
+
+func UpdateTimeEntry(c *gin.Context) {
+	ownerType, ownerID, ok := timePrincipal(c)
+	if !ok {
+		return
+	}
+
+	var input TimeEntryUpdateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	entry, ok := loadOwnTimeEntry(c, ownerType, ownerID)
+	if !ok {
+		return
+	}
+	if entry.IsBilled() {
+		utils.SendErrorResponse(c, http.StatusConflict, "time entry has already been billed")
+		return
+	}
+
+	if input.StartedAt != nil {
+		entry.StartedAt = *input.StartedAt
+	}
+	if input.EndedAt != nil {
+		if input.EndedAt.After(time.Now()) {
+			utils.SendErrorResponse(c, http.StatusBadRequest, "time entries cannot end in the future")
+			return
+		}
+		entry.EndedAt = input.EndedAt
+	}
+	if input.Notes != nil {
+		entry.Notes = *input.Notes
+	}
+	if input.Billable != nil {
+		entry.Billable = *input.Billable
+	}
+	if input.StartedAt != nil || input.EndedAt != nil {
+		entry.Manual = true
+	}
+
+	if err := config.DB.WithContext(c).Save(entry).Error; err != nil {
+		if errors.Is(err, models.ErrTimeEntryRange) {
+			utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to update time entry")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"message": "Time entry updated",
+		"entry":   entry,
+	})
+}
+
+func DeleteTimeEntry(c *gin.Context) {
+	ownerType, ownerID, ok := timePrincipal(c)
+	if !ok {
+		return
+	}
+
+	entry, ok := loadOwnTimeEntry(c, ownerType, ownerID)
+	if !ok {
+		return
+	}
+	if entry.IsBilled() {
+		utils.SendErrorResponse(c, http.StatusConflict, "time entry has already been billed")
+		return
+	}
+
+	if err := config.DB.WithContext(c).Delete(entry).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to delete time entry")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"message": "Time entry deleted",
+	})
+}
+
+// timeEntryScope limits entries to what the principal may see: their own, or for a freelancer
+// an associate's entries on the freelancer's projects (?associate_id=)
+func timeEntryScope(c *gin.Context, ownerType string, ownerID uuid.UUID) (*gorm.DB, bool) {
+	query := config.DB.Model(&models.TimeEntry{})
+	if c.Query("associate_id") == "" {
+		return query.Where("time_entries.owner_type = ? AND time_entries.owner_id = ?", ownerType, ownerID), true
+	}
+	if ownerType != models.PrincipalUser {
+		utils.SendErrorResponse(c, http.StatusForbidden, "access denied")
+		return nil, false
+	}
+	associateID, err := uuid.Parse(c.Query("associate_id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "invalid associate_id")
+		return nil, false
+	}
+	return query.
+		Where("time_entries.owner_type = ? AND time_entries.owner_id = ?", models.PrincipalAssociate, associateID).
+		Where("time_entries.project_id IN (?)", config.DB.Model(&models.Project{}).Select("id").Where("user_id = ?", ownerID)), true
+}
+
+// GetTimeEntries lists time entries filtered by ?task_id=, ?project_id=, ?from= and ?to= (YYYY-MM-DD)
+func GetTimeEntries(c *gin.Context) {
+	ownerType, ownerID, ok := timePrincipal(c)
+	if !ok {
+		return
+	}
+
+	query, ok := timeEntryScope(c, ownerType, ownerID)
+	if !ok {
+		return
+	}
+	if taskID := c.Query("task_id"); taskID != "" {
+		query = query.Where("task_id = ?", taskID)
+	}
+	if projectID := c.Query("project_id"); projectID != "" {
+		query = query.Where("project_id = ?", projectID)
+	}
+	if from := c.Query("from"); from != "" {
+		t, err := time.Parse("2006-01-02", from)
+		if err != nil {
+			utils.SendErrorResponse(c, http.StatusBadRequest, "invalid from date")
+			return
+		}
+		query = query.Where("started_at >= ?", t)
+	}
+	if to := c.Query("to"); to != "" {
+		t, err := time.Parse("2006-01-02", to)
+		if err != nil {
+			utils.SendErrorResponse(c, http.StatusBadRequest, "invalid to date")
+			return
+		}
+		query = query.Where("started_at < ?", t.AddDate(0, 0, 1))
+	}
+
+	var entries []models.TimeEntry
+	if err := query.Preload("Task").Order("started_at DESC").Find(&entries).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch time entries")
+		return
+	}
+
+	var total, billable float64
+	for _, e := range entries {
+		total += e.Hours
+		if e.Billable {
+			billable += e.Hours
+		}
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"total_hours":    total,
+		"billable_hours": billable,
+		"entries":        entries,
+	})
+}
+
+// GetTaskTimeEntries lists the time logged on a task; the project owner sees everyone's entries
+func GetTaskTimeEntries(c *gin.Context) {
+	ownerType, ownerID, ok := timePrincipal(c)
+	if !ok {
+		return
+	}
+
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "invalid task id")
+		return
+	}
+	task, ok := loadTrackableTask(c, ownerType, ownerID, taskID)
+	if !ok {
+		return
+	}
+
+	query := config.DB.Where("task_id = ?", task.ID)
+	if ownerType == models.PrincipalAssociate {
+		query = query.Where("owner_type = ? AND owner_id = ?", ownerType, ownerID)
+	}
+
+	var entries []models.TimeEntry
+	if err := query.Order("started_at DESC").Find(&entries).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch time entries")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"task_id":         task.ID,
+		"estimated_hours": task.EstimatedHours,
+		"actual_hours":    task.ActualHours,
+		"entries":         entries,
+	})
+}
+
+// GetWeeklyTimesheet returns a Monday-to-Sunday grid for the week containing ?week= (YYYY-MM-DD,
+// default today). Days follow the calendar in ?tz= (an IANA zone such as Africa/Nairobi, default
+// the server's zone).
+func GetWeeklyTimesheet(c *gin.Context) {
+	ownerType, ownerID, ok := timePrincipal(c)
+	if !ok {
+		return
+	}
+
+	loc := time.Local
+	if tz := c.Query("tz"); tz != "" {
+		l, err := time.LoadLocation(tz)
+		if err != nil {
+			utils.SendErrorResponse(c, http.StatusBadRequest, "invalid tz")
+			return
+		}
+		loc = l
+	}
+
+	day := time.Now().In(loc)
+	if week := c.Query("week"); week != "" {
+		t, err := time.ParseInLocation("2006-01-02", week, loc)
+		if err != nil {
+			utils.SendErrorResponse(c, http.StatusBadRequest, "invalid week date")
+			return
+		}
+		day = t
+	}
+	offset := (int(day.Weekday()) + 6) % 7 // days since Monday
+	start := time.Date(day.Year(), day.Month(), day.Day()-offset, 0, 0, 0, 0, day.Location())
+	end := start.AddDate(0, 0, 7)
+
+	query, ok := timeEntryScope(c, ownerType, ownerID)
+	if !ok {
+		return
+	}
+
+	type timesheetEntry struct {
+		models.TimeEntry
+		TaskTitle   string
+		ProjectName string
+	}
+	var entries []timesheetEntry
+	if err := query.
+		Joins("LEFT JOIN tasks ON tasks.id = time_entries.task_id").
+		Joins("LEFT JOIN projects ON projects.id = time_entries.project_id").
+		Select("time_entries.*, tasks.title AS task_title, projects.name AS project_name").
+		Where("time_entries.started_at >= ? AND time_entries.started_at < ? AND time_entries.ended_at IS NOT NULL", start, end).
+		Order("time_entries.started_at ASC").
+		Scan(&entries).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch time entries")
+		return
+	}
+
+	days := make([]TimesheetDay, 7)
+	dayIndex := make(map[string]int, len(days))
+	for i := range days {
+		days[i].Date = start.AddDate(0, 0, i).Format("2006-01-02")
+		dayIndex[days[i].Date] = i
+	}
+	rows := []*TimesheetRow{}
+	byTask := make(map[uuid.UUID]*TimesheetRow)
+	var total, billable float64
+	for _, e := range entries {
+		// work is booked on the calendar day it started, which stays right across DST changes
+		i, ok := dayIndex[e.StartedAt.In(loc).Format("2006-01-02")]
+		if !ok {
+			continue
+		}
+		days[i].Hours += e.Hours
+		total += e.Hours
+		if e.Billable {
+			days[i].BillableHours += e.Hours
+			billable += e.Hours
+		}
+
+		row, ok := byTask[e.TaskID]
+		if !ok {
+			row = &TimesheetRow{TaskID: e.TaskID, TaskTitle: e.TaskTitle, ProjectID: e.ProjectID, ProjectName: e.ProjectName}
+			byTask[e.TaskID] = row
+			rows = append(rows, row)
+		}
+		row.Days[i] += e.Hours
+		row.Total += e.Hours
+	}
+
+	running, err := runningTimer(config.DB, ownerType, ownerID)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch timer")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"week_start":     start.Format("2006-01-02"),
+		"week_end":       end.AddDate(0, 0, -1).Format("2006-01-02"),
+		"days":           days,
+		"tasks":          rows,
+		"total_hours":    total,
+		"billable_hours": billable,
+		"running":        running,
+	})
+}
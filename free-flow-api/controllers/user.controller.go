@@ -59,7 +59,7 @@ func Signup(c *gin.Context) {
 		Password:  string(hashed),
 	}
 
-	if err := config.DB.Create(&user).Error; err != nil {
+	if err := config.DB.WithContext(c).Create(&user).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
 		return
 	}
@@ -104,6 +104,11 @@ func Login(c *gin.Context) {
 		return
 	}
 
+	if user.SuspendedAt != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Account is suspended"})
+		return
+	}
+
 	//successfully login user
 	token, err := config.GenerateToken(user.ID.String(), 24*time.Hour)
 	if err != nil {
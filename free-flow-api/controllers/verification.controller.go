@@ -0,0 +1,391 @@
+package controllers
+
+import (
+	"errors"
+	"free-flow-api/config"
+	"free-flow-api/models"
+	"free-flow-api/utils"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var verificationFileTypes = []string{"image/jpeg", "image/png", "application/pdf"}
+
+var verificationDocumentKinds = []string{"id_front", "id_back", "selfie", "proof_of_address"}
+
+type VerificationInput struct {
+	RequestedLevel      string `form:"requested_level" binding:"required,oneof=advanced kyc"`
+	DocumentType        string `form:"document_type" binding:"required,oneof=passport national_id drivers_license"`
+	DocumentNumber      string `form:"document_number" binding:"required"`
+	PayoutMethod        string `form:"payout_method" binding:"omitempty,oneof=bank mobile_money paypal"`
+	PayoutProvider      string `form:"payout_provider"`
+	PayoutAccountName   string `form:"payout_account_name"`
+	PayoutAccountNumber string `form:"payout_account_number"`
+}
+
+type VerificationDecisionInput struct {
+	Notes string `json:"notes"`
+}
+
+// SubmitVerification lets an associate upload documents to reach the next verification level
+func SubmitVerification(c *gin.Context) {
+	associateID := c.GetString("userID")
+	if !utils.IsAssociateAuthenticated(associateID) {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid associate token")
+		c.Abort()
+		return
+	}
+
+	var input VerificationInput
+	if err := c.ShouldBind(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var profile models.AssociateProfile
+	if err := config.DB.First(&profile, "associate_id = ?", associateID).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "associate profile not found")
+		return
+	}
+
+	current := profile.VerificationLevel
+	if !profile.IsVerified {
+		current = models.VerificationBasic
+	}
+	next := models.NextVerificationLevel(current)
+	if next == "" {
+		utils.SendErrorResponse(c, http.StatusConflict, "associate is already fully verified")
+		return
+	}
+	if input.RequestedLevel != next {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "next verification level is "+next)
+		return
+	}
+
+	if input.RequestedLevel == models.VerificationKYC &&
+		(input.PayoutMethod == "" || input.PayoutAccountName == "" || input.PayoutAccountNumber == "") {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "payout method, account name and account number are required for kyc")
+		return
+	}
+
+	var pending int64
+	if err := config.DB.Model(&models.VerificationRequest{}).
+		Where("associate_id = ? AND status = ?", associateID, "pending").
+		Count(&pending).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to check verification requests")
+		return
+	}
+	if pending > 0 {
+		utils.SendErrorResponse(c, http.StatusConflict, "a verification request is already pending review")
+		return
+	}
+
+	for _, kind := range models.RequiredDocuments(input.RequestedLevel) {
+		if _, err := c.FormFile(kind); err != nil {
+			utils.SendErrorResponse(c, http.StatusBadRequest, kind+" document is required")
+			return
+		}
+	}
+
+	request := models.VerificationRequest{
+		AssociateID:         profile.AssociateID,
+		RequestedLevel:      input.RequestedLevel,
+		Status:              "pending",
+		DocumentType:        input.DocumentType,
+		DocumentNumber:      input.DocumentNumber,
+		PayoutMethod:        input.PayoutMethod,
+		PayoutProvider:      input.PayoutProvider,
+		PayoutAccountName:   input.PayoutAccountName,
+		PayoutAccountNumber: input.PayoutAccountNumber,
+	}
+	request.ID = uuid.New()
+
+	// store files first so a failed upload leaves no request behind
+	var saved []string
+	cleanup := func() {
+		for _, p := range saved {
+			utils.RemoveUpload(p)
+		}
+	}
+	for _, kind := range verificationDocumentKinds {
+		file, err := c.FormFile(kind)
+		if err != nil {
+			continue
+		}
+		path, mimeType, err := utils.SaveUpload(file, filepath.Join("verification", request.ID.String()), verificationFileTypes)
+		if err != nil {
+			cleanup()
+			if utils.IsUploadRejected(err) {
+				utils.SendErrorResponse(c, http.StatusBadRequest, kind+": "+err.Error())
+				return
+			}
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to store "+kind)
+			return
+		}
+		saved = append(saved, path)
+		request.Documents = append(request.Documents, models.VerificationDocument{
+			Kind:     kind,
+			FileName: filepath.Base(file.Filename),
+			Path:     path,
+			MimeType: mimeType,
+			Size:     file.Size,
+		})
+	}
+
+	if err := config.DB.WithContext(c).Create(&request).Error; err != nil {
+		cleanup()
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to submit verification")
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusCreated, gin.H{
+		"message": "Verification submitted for review",
+		"request": request,
+	})
+}
+
+// GetMyVerification returns the associate's current level and submission history
+func GetMyVerification(c *gin.Context) {
+	associateID := c.GetString("userID")
+	if !utils.IsAssociateAuthenticated(associateID) {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid associate token")
+		c.Abort()
+		return
+	}
+
+	var profile models.AssociateProfile
+	if err := config.DB.First(&profile, "associate_id = ?", associateID).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "associate profile not found")
+		return
+	}
+
+	var requests []models.VerificationRequest
+	if err := config.DB.
+		Preload("Documents").
+		Where("associate_id = ?", associateID).
+		Order("created_at DESC").
+		Find(&requests).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch verification requests")
+		return
+	}
+
+	current := profile.VerificationLevel
+	if !profile.IsVerified {
+		current = models.VerificationBasic
+	}
+	next := models.NextVerificationLevel(current)
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"verification_level": current,
+		"is_verified":        profile.IsVerified,
+		"verified_at":        profile.VerifiedAt,
+		"next_level":         next,
+		"required_documents": models.RequiredDocuments(next),
+		"marketplace_ready":  profile.HasVerificationLevel(models.MarketplaceVerificationLevel),
+		"payout_ready":       profile.HasVerificationLevel(models.PayoutVerificationLevel),
+		"requests":           requests,
+	})
+}
+
+// GetVerificationQueue lists verification requests for admin review (pending by default)
+func GetVerificationQueue(c *gin.Context) {
+	status := c.DefaultQuery("status", "pending")
+
+	var requests []models.VerificationRequest
+	if err := config.DB.
+		Preload("Associate").
+		Preload("Documents").
+		Where("status = ?", status).
+		Order("created_at ASC").
+		Find(&requests).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "failed to fetch verification queue")
+		return
+	}
+
+	queue := make([]gin.H, 0, len(requests))
+	for _, r := range requests {
+		queue = append(queue, gin.H{
+			"request":      r,
+			"associate_id": r.AssociateID,
+			"name":         r.Associate.Name,
+			"email":        r.Associate.Email,
+		})
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, queue)
+}
+
+func GetVerificationRequest(c *gin.Context) {
+	var request models.VerificationRequest
+	if err := config.DB.Preload("Associate").Preload("Documents").First(&request, "id = ?", c.Param("id")).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "verification request not found")
+		return
+	}
+
+	var profile models.AssociateProfile
+	config.DB.First(&profile, "associate_id = ?", request.AssociateID)
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"request":            request,
+		"name":               request.Associate.Name,
+		"email":              request.Associate.Email,
+		"verification_level": profile.VerificationLevel,
+		"is_verified":        profile.IsVerified,
+	})
+}
+
+// GetVerificationDocument streams an uploaded document to an admin
+func GetVerificationDocument(c *gin.Context) {
+	var doc models.VerificationDocument
+	if err := config.DB.First(&doc, "id = ?", c.Param("id")).Error; err != nil {
+		utils.SendErrorResponse(c, http.StatusNotFound, "document not found")
+		return
+	}
+
+	utils.ServeUpload(c, doc.Path, doc.MimeType, doc.FileName)
+}
+
+// loadPendingVerification fetches a request that is still awaiting an admin decision
+func loadPendingVerification(tx *gorm.DB, id string) (*models.VerificationRequest, error) {
+	var request models.VerificationRequest
+	if err := tx.First(&request, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	if request.Status != "pending" {
+		return nil, errors.New("verification request has already been " + request.Status)
+	}
+	return &request, nil
+}
+
+func ApproveVerification(c *gin.Context) {
+	adminID := c.GetString("userID")
+
+	// notes are optional on approval
+	var input VerificationDecisionInput
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var request *models.VerificationRequest
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		var err error
+		request, err = loadPendingVerification(tx, c.Param("id"))
+		if err != nil {
+			return err
+		}
+
+		var profile models.AssociateProfile
+		if err := tx.First(&profile, "associate_id = ?", request.AssociateID).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		reviewer := uuid.MustParse(adminID)
+		updates := map[string]interface{}{
+			"verification_level": request.RequestedLevel,
+			"is_verified":        true,
+			"verified_at":        now,
+			"verification_notes": input.Notes,
+		}
+		if request.RequestedLevel == models.VerificationKYC {
+			updates["payout_method"] = request.PayoutMethod
+			updates["payout_provider"] = request.PayoutProvider
+			updates["payout_account_name"] = request.PayoutAccountName
+			updates["payout_account_number"] = request.PayoutAccountNumber
+		}
+		if err := tx.Model(&profile).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		request.Status = "approved"
+		request.ReviewedBy = &reviewer
+		request.ReviewedAt = &now
+		request.ReviewNotes = &input.Notes
+		if err := tx.Model(request).Updates(map[string]interface{}{
+			"status":       request.Status,
+			"reviewed_by":  reviewer,
+			"reviewed_at":  now,
+			"review_notes": input.Notes,
+		}).Error; err != nil {
+			return err
+		}
+
+		return recordAdminAction(tx, c, models.AdminActionVerificationApprove, "verification", request.ID, input.Notes,
+			"associate "+request.AssociateID.String()+" verified at "+request.RequestedLevel)
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		utils.SendErrorResponse(c, http.StatusNotFound, "verification request not found")
+		return
+	}
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusConflict, err.Error())
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"message": "Verification approved",
+		"request": request,
+	})
+}
+
+func RejectVerification(c *gin.Context) {
+	adminID := c.GetString("userID")
+
+	var input VerificationDecisionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if strings.TrimSpace(input.Notes) == "" {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "notes are required when rejecting")
+		return
+	}
+
+	var request *models.VerificationRequest
+	err := config.DB.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		var err error
+		request, err = loadPendingVerification(tx, c.Param("id"))
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		reviewer := uuid.MustParse(adminID)
+		request.Status = "rejected"
+		request.ReviewedBy = &reviewer
+		request.ReviewedAt = &now
+		request.ReviewNotes = &input.Notes
+		if err := tx.Model(request).Updates(map[string]interface{}{
+			"status":       request.Status,
+			"reviewed_by":  reviewer,
+			"reviewed_at":  now,
+			"review_notes": input.Notes,
+		}).Error; err != nil {
+			return err
+		}
+
+		return recordAdminAction(tx, c, models.AdminActionVerificationReject, "verification", request.ID, input.Notes,
+			"associate "+request.AssociateID.String()+" rejected for "+request.RequestedLevel)
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		utils.SendErrorResponse(c, http.StatusNotFound, "verification request not found")
+		return
+	}
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusConflict, err.Error())
+		return
+	}
+
+	utils.SendSuccessResponse(c, http.StatusOK, gin.H{
+		"message": "Verification rejected",
+		"request": request,
+	})
+}
@@ -0,0 +1,67 @@
+package jobs
+
+import (
+	"free-flow-api/config"
+	"free-flow-api/models"
+	"log"
+	"time"
+)
+
+// StartInviteJobs periodically expires stale invites and reminds associates before their invites lapse
+func StartInviteJobs(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			RunInviteJobs()
+			<-ticker.C
+		}
+	}()
+}
+
+func RunInviteJobs() {
+	if n, err := models.ExpireStaleInvites(config.DB); err != nil {
+		log.Println("invite expiry failed:", err)
+	} else if n > 0 {
+		log.Printf("expired %d invites", n)
+	}
+
+	if err := sendInviteReminders(); err != nil {
+		log.Println("invite reminders failed:", err)
+	}
+}
+
+func sendInviteReminders() error {
+	now := time.Now()
+
+	var invites []models.Invite
+	if err := config.DB.
+		Where("status = ? AND reminder_sent_at IS NULL", "pending").
+		Where("expires_at > ? AND expires_at <= ?", now, now.Add(models.InviteReminderLag)).
+		Find(&invites).Error; err != nil {
+		return err
+	}
+
+	for _, invite := range invites {
+		// claim the reminder first so overlapping runs don't send it twice
+		result := config.DB.Model(&models.Invite{}).
+			Where("id = ? AND reminder_sent_at IS NULL", invite.ID).
+			Update("reminder_sent_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		// the original link stays valid until it expires, so the reminder points back to the invite
+		title := "Your invite expires on " + invite.ExpiresAt.Format("Jan 2, 15:04 MST")
+		if err := models.NotifyInvite(config.DB, invite, models.NotificationInviteReminder, title); err != nil {
+			return err
+		}
+		log.Printf("invite %s: reminder sent to associate %s, expires %s", invite.ID, invite.AssociateID, invite.ExpiresAt.Format(time.RFC3339))
+	}
+
+	return nil
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"free-flow-api/config"
+	"free-flow-api/models"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// activityGranularity limits how often LastActivityAt is written for a busy associate
+const activityGranularity = 5 * time.Minute
+
+// TrackActivity records when an associate last used the API. It reads the principal after the
+// handler chain has run, so it works on any group that uses VerifyToken.
+func TrackActivity() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if c.GetString("impersonatorID") != "" || c.Writer.Status() >= http.StatusInternalServerError {
+			return
+		}
+		id, err := uuid.Parse(c.GetString("userID"))
+		if err != nil {
+			return
+		}
+
+		// freelancers have no profile row, so this is a no-op for them
+		now := time.Now()
+		config.DB.Model(&models.AssociateProfile{}).
+			Where("associate_id = ? AND (last_activity_at IS NULL OR last_activity_at < ?)", id, now.Add(-activityGranularity)).
+			Update("last_activity_at", now)
+	}
+}
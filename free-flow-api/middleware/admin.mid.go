@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"free-flow-api/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VerifyAdmin must run after VerifyToken; it only lets operators through
+func VerifyAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// an admin impersonating someone does not keep admin rights
+		if c.GetString("impersonatorID") != "" || !utils.IsAdmin(c.GetString("userID")) {
+			utils.SendErrorResponse(c, http.StatusForbidden, "admin access required")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
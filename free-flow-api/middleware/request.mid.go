@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// callers may pass their own request id, as long as it is short and plain
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestContext tags each request with an id (echoed in X-Request-ID) and the client IP, so
+// logs and the audit trail can be traced back to the call that caused them
+func RequestContext() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader("X-Request-ID")
+		if !requestIDPattern.MatchString(requestID) {
+			requestID = uuid.NewString()
+		}
+		c.Set("requestID", requestID)
+		c.Set("clientIP", c.ClientIP())
+		c.Header("X-Request-ID", requestID)
+		c.Next()
+	}
+}
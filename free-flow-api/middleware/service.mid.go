@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"free-flow-api/config"
+	"free-flow-api/utils"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VerifySafeCollabKey authenticates service-to-service calls from the SafeCollab service.
+// The key is read once when routes are registered; without one every call is rejected.
+func VerifySafeCollabKey() gin.HandlerFunc {
+	expected := config.GetEnvOrDefault("SAFECOLLAB_API_KEY", "")
+	if expected == "" {
+		log.Println("SAFECOLLAB_API_KEY is not set, SafeCollab calls will be rejected")
+	}
+
+	return func(c *gin.Context) {
+		key := c.GetHeader("X-Service-Key")
+
+		if expected == "" || key == "" || subtle.ConstantTimeCompare([]byte(key), []byte(expected)) != 1 {
+			utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid service credentials")
+			c.Abort()
+			return
+		}
+
+		c.Set("service", "safecollab")
+		c.Next()
+	}
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"fmt"
 	"free-flow-api/config"
 	"free-flow-api/models"
 	"free-flow-api/utils"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 func VerifyToken() gin.HandlerFunc {
@@ -34,7 +36,32 @@ func VerifyToken() gin.HandlerFunc {
 		claims := token.Claims.(*config.JWTCustomClaims)
 		c.Set("userID", claims.UserID)
 
+		// impersonation tokens die with the admin's role
+		if claims.ImpersonatorID != "" {
+			if !utils.IsAdmin(claims.ImpersonatorID) {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+				c.Abort()
+				return
+			}
+			c.Set("impersonatorID", claims.ImpersonatorID)
+		}
+
 		c.Next()
+
+		// every write made while impersonating lands in the admin audit trail
+		if claims.ImpersonatorID != "" && c.Request.Method != http.MethodGet {
+			adminID, _ := uuid.Parse(claims.ImpersonatorID)
+			targetID, _ := uuid.Parse(claims.UserID)
+			config.DB.WithContext(c).Create(&models.AdminAction{
+				AdminID:    adminID,
+				Action:     models.AdminActionImpersonatedRequest,
+				TargetID:   targetID,
+				TargetType: "principal",
+				Details:    fmt.Sprintf("%s %s -> %d", c.Request.Method, c.Request.URL.Path, c.Writer.Status()),
+				IPAddress:  c.ClientIP(),
+				UserAgent:  c.Request.UserAgent(),
+			})
+		}
 	}
 }
 
@@ -75,6 +102,18 @@ func VerifyInvite() gin.HandlerFunc {
 			return
 		}
 
+		// links are replaced when an invite is resent
+		if claims.ID != invite.TokenID {
+			utils.SendErrorResponse(c, http.StatusUnauthorized, "invite link is no longer valid")
+			c.Abort()
+			return
+		}
+
+		if invite.IsExpired() {
+			invite.Status = "expired"
+			config.DB.WithContext(c).Model(&invite).Update("status", invite.Status)
+		}
+
 		// Set invite data into context for use by the next handler
 		c.Set("invite_id", invite.ID.String())
 		c.Set("associate_id", invite.AssociateID.String())
@@ -84,3 +123,56 @@ func VerifyInvite() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+func VerifyQuote() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString := c.Param("token")
+		if tokenString == "" {
+			utils.SendErrorResponse(c, http.StatusBadRequest, "missing token")
+			c.Abort()
+			return
+		}
+
+		claims, err := utils.VerifyQuoteToken(tokenString)
+		if err != nil {
+			utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid or expired quote link")
+			c.Abort()
+			return
+		}
+
+		c.Set("quote_id", claims.QuoteID)
+		c.Next()
+	}
+}
+
+func VerifyClient() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString := c.Param("token")
+		if tokenString == "" {
+			utils.SendErrorResponse(c, http.StatusBadRequest, "missing token")
+			c.Abort()
+			return
+		}
+
+		claims, err := utils.VerifyClientToken(tokenString)
+		if err != nil {
+			utils.SendErrorResponse(c, http.StatusUnauthorized, "invalid or expired client link")
+			c.Abort()
+			return
+		}
+
+		// links are replaced when a new one is issued and dropped when revoked or the client changes
+		var project models.Project
+		if err := config.DB.Select("id", "entity_id", "client_link_id").First(&project, "id = ?", claims.ProjectID).Error; err != nil ||
+			project.ClientLinkID == "" || claims.ID != project.ClientLinkID ||
+			project.EntityID == nil || project.EntityID.String() != claims.EntityID {
+			utils.SendErrorResponse(c, http.StatusUnauthorized, "client link is no longer valid")
+			c.Abort()
+			return
+		}
+
+		c.Set("project_id", claims.ProjectID)
+		c.Set("entity_id", claims.EntityID)
+		c.Next()
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"free-flow-api/config"
+	"free-flow-api/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// backfillInviteExpiry gives invites sent before links expired an expiry date. Pending ones get a
+// fresh window so they aren't expired the moment the job next runs; the rest expire from creation.
+func backfillInviteExpiry() error {
+	unset := config.DB.Model(&models.Invite{}).Where("expires_at IS NULL OR expires_at <= ?", time.Time{})
+	if err := unset.Session(&gorm.Session{}).
+		Where("status = ?", "pending").
+		UpdateColumn("expires_at", time.Now().Add(models.InviteTTL)).Error; err != nil {
+		return err
+	}
+	return unset.Session(&gorm.Session{}).
+		UpdateColumn("expires_at", gorm.Expr("created_at + ?::interval", fmt.Sprintf("%d seconds", int(models.InviteTTL.Seconds())))).Error
+}
+
+// backfillContractVersions snapshots contracts created before versioning as version 1, so their
+// pending invites can be reviewed and signed. A contract with an accepted invite gets that version
+// marked accepted, which also stops it being amended in place.
+func backfillContractVersions() error {
+	var contracts []models.Contract
+	if err := config.DB.
+		Where("NOT EXISTS (SELECT 1 FROM contract_versions WHERE contract_versions.contract_id = contracts.id)").
+		Find(&contracts).Error; err != nil {
+		return err
+	}
+
+	for _, contract := range contracts {
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			version := models.NewContractVersion(contract, 1)
+			version.ChangeNote = "initial version"
+
+			var accepted models.Invite
+			err := tx.Where("contract_id = ? AND status = ?", contract.ID, "accepted").
+				Order("responded_at DESC").
+				First(&accepted).Error
+			if err != nil && err != gorm.ErrRecordNotFound {
+				return err
+			}
+			acceptedVersion := 0
+			if err == nil {
+				acceptedAt, acceptedBy := accepted.RespondedAt, accepted.AssociateID
+				version.AcceptedAt = &acceptedAt
+				version.AcceptedBy = &acceptedBy
+				acceptedVersion = 1
+			}
+
+			if err := tx.Create(&version).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&models.Invite{}).
+				Where("contract_id = ? AND (contract_version IS NULL OR contract_version = 0)", contract.ID).
+				Update("contract_version", 1).Error; err != nil {
+				return err
+			}
+			return tx.Model(&contract).UpdateColumns(map[string]interface{}{
+				"current_version":   1,
+				"accepted_version":  acceptedVersion,
+				"amendment_pending": false,
+			}).Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backfillPayableSettlements flags settlements on tasks finished before payability was tracked,
+// so they can still be paid
+func backfillPayableSettlements() error {
+	return config.DB.Model(&models.AssociateSettlement{}).
+		Where("payable_at IS NULL").
+		Where("task_id IN (?)", config.DB.Model(&models.Task{}).Select("id").Where("status IN ?", models.CompletedTaskStatuses)).
+		UpdateColumn("payable_at", gorm.Expr("(SELECT COALESCE(tasks.completed_at, tasks.updated_at) FROM tasks WHERE tasks.id = associate_settlements.task_id)")).Error
+}
+
+// backfillSettlementPayments records what was paid on settlements before payments were kept one by
+// one. The split between partial payments is lost, so each total is dated to its last update.
+func backfillSettlementPayments() error {
+	return config.DB.Exec(`
+		INSERT INTO settlement_payments (id, created_at, settlement_id, associate_id, user_id, amount, method, transaction_ref, paid_at)
+		SELECT gen_random_uuid(), NOW(), s.id, s.associate_id, s.user_id, s.settled_amount, s.method, s.transaction_ref, COALESCE(s.settled_at, s.updated_at)
+		FROM associate_settlements AS s
+		WHERE s.settled_amount <> 0 AND s.deleted_at IS NULL
+		AND NOT EXISTS (SELECT 1 FROM settlement_payments AS sp WHERE sp.settlement_id = s.id)`).Error
+}
+
+// backfillSignatureDocuments stores the signed text on signatures recorded before it was kept.
+// The text is only taken from the version when it still hashes to what was signed; anything
+// else is left empty so the signature verifies as invalid rather than against altered terms.
+func backfillSignatureDocuments() error {
+	var signatures []models.ContractSignature
+	if err := config.DB.Where("document IS NULL OR document = ''").Find(&signatures).Error; err != nil {
+		return err
+	}
+
+	for _, signature := range signatures {
+		var version models.ContractVersion
+		if err := config.DB.First(&version, "contract_id = ? AND version = ?", signature.ContractID, signature.ContractVersion).Error; err != nil {
+			continue
+		}
+		if version.DocumentHash() != signature.DocumentHash {
+			continue
+		}
+		// UpdateColumn skips the hook that keeps signatures immutable
+		if err := config.DB.Model(&signature).UpdateColumn("document", version.Document()).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"free-flow-api/config"
 	"free-flow-api/models"
 	"log"
+	"strings"
 )
 
 func init() {
@@ -23,11 +24,68 @@ func main() {
 		&models.Invoice{},
 		&models.Payment{},
 		&models.AssociateSettlement{},
+		&models.SettlementPayment{},
 		&models.Milestone{},
 		&models.AssociateProfile{},
 		&models.Contract{},
 		&models.Invite{},
+		&models.EscrowTransaction{},
+		&models.Quote{},
+		&models.QuoteLineItem{},
+		&models.InvoiceLineItem{},
+		&models.ContractTemplate{},
+		&models.ContractVersion{},
+		&models.ContractSignature{},
+		&models.TaskOffer{},
+		&models.OfferBid{},
+		&models.JobPosting{},
+		&models.JobApplication{},
+		&models.Review{},
+		&models.VerificationRequest{},
+		&models.VerificationDocument{},
+		&models.AdminAction{},
+		&models.TimeEntry{},
+		&models.BillingRate{},
+		&models.TaskDependency{},
+		&models.ChecklistItem{},
+		&models.Comment{},
+		&models.CommentMention{},
+		&models.CommentRevision{},
+		&models.Notification{},
+		&models.Attachment{},
+		&models.Deliverable{},
+		&models.StatusChange{},
+		&models.AuditLog{},
 	); err != nil {
 		log.Fatalf("Migration failed: %v", err)
 	}
+
+	if err := backfillContractVersions(); err != nil {
+		log.Fatalf("Contract version backfill failed: %v", err)
+	}
+	if err := backfillInviteExpiry(); err != nil {
+		log.Fatalf("Invite expiry backfill failed: %v", err)
+	}
+	if err := backfillPayableSettlements(); err != nil {
+		log.Fatalf("Settlement backfill failed: %v", err)
+	}
+	if err := backfillSettlementPayments(); err != nil {
+		log.Fatalf("Settlement payment backfill failed: %v", err)
+	}
+	if err := backfillSignatureDocuments(); err != nil {
+		log.Fatalf("Signature backfill failed: %v", err)
+	}
+
+	// bootstrap operators from ADMIN_EMAILS (comma separated)
+	var emails []string
+	for _, e := range strings.Split(config.GetEnvOrDefault("ADMIN_EMAILS", ""), ",") {
+		if e = strings.ToLower(strings.TrimSpace(e)); e != "" {
+			emails = append(emails, e)
+		}
+	}
+	if len(emails) > 0 {
+		if err := config.DB.Model(&models.User{}).Where("email IN ?", emails).Update("is_admin", true).Error; err != nil {
+			log.Fatalf("Admin bootstrap failed: %v", err)
+		}
+	}
 }
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Admin audit actions
+const (
+	AdminActionSuspend             = "suspend"
+	AdminActionUnsuspend           = "unsuspend"
+	AdminActionImpersonate         = "impersonate"
+	AdminActionImpersonatedRequest = "impersonated_request" // a write made with an impersonation token
+	AdminActionVerificationApprove = "verification_approve"
+	AdminActionVerificationReject  = "verification_reject"
+	AdminActionMarketplaceStatus   = "marketplace_status"
+)
+
+// Principal types an admin can act on
+const (
+	PrincipalUser      = "user"
+	PrincipalAssociate = "associate"
+)
+
+// AdminAction is an append-only record of something an operator did
+type AdminAction struct {
+	ID        uuid.UUID `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+
+	AdminID    uuid.UUID `json:"admin_id" gorm:"not null;index"`
+	Action     string    `json:"action" gorm:"not null;index"`
+	TargetType string    `json:"target_type"` // "user", "associate", "verification", "invite"
+	TargetID   uuid.UUID `json:"target_id" gorm:"index"`
+	Reason     string    `json:"reason"`
+	Details    string    `json:"details"`
+
+	IPAddress string `json:"ip_address"`
+	UserAgent string `json:"user_agent"`
+}
+
+func (a *AdminAction) BeforeCreate(tx *gorm.DB) (err error) {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
@@ -16,17 +16,23 @@ type AssociateProfile struct {
 	ID          uuid.UUID `json:"id" gorm:"primaryKey"`
 	AssociateID uuid.UUID `json:"associate_id" gorm:"not null;uniqueIndex"` // Link to your existing Associate model
 
-	PasswordHash    string  `json:"password" gorm:"not null"` // bcrypt hash
-	PhoneNumber     *string `json:"phone_number" gorm:"size:20"`
-	ProfilePhotoURL *string `json:"profile_photo_url"`
-	Bio             *string `json:"bio" gorm:"size:500"`
+	PasswordHash     string  `json:"password" gorm:"not null" audit:"-"` // bcrypt hash
+	PhoneNumber      *string `json:"phone_number" gorm:"size:20"`
+	ProfilePhotoURL  *string `json:"profile_photo_url"`
+	ProfilePhotoPath string  `json:"-"` // uploaded photo, relative to the upload dir
+	Bio              *string `json:"bio" gorm:"size:500"`
 
 	// Onboarding
 	IsOnboarded bool `json:"is_onboarded" gorm:"default:false"`
 
+	// Suspension, set by an admin
+	SuspendedAt     *time.Time `json:"suspended_at"`
+	SuspendedReason *string    `json:"suspended_reason"`
+
 	// Marketplace
 	HasMarketplaceAccess *bool   `json:"has_marketplace_access" gorm:"default:false"`
 	MarketplaceStatus    *string `json:"marketplace_status" gorm:"default:'inactive'"` // inactive, pending, approved, suspended
+	MarketplaceNotes     *string `json:"marketplace_notes"`                            // admin note on the last status decision
 
 	// Verification
 	IsVerified        bool       `json:"is_verified" gorm:"default:false"`
@@ -34,6 +40,20 @@ type AssociateProfile struct {
 	VerifiedAt        *time.Time `json:"verified_at"`
 	VerificationNotes *string    `json:"verification_notes"` // internal admin note (why verified/denied)
 
+	// Payout details, copied from the approved KYC submission
+	PayoutMethod        *string `json:"payout_method"`
+	PayoutProvider      *string `json:"payout_provider"`
+	PayoutAccountName   *string `json:"payout_account_name"`
+	PayoutAccountNumber *string `json:"-" audit:"-"`
+	PayoutAccountLast4  string  `json:"payout_account_last4" gorm:"-"` // the only part of the number sent to clients
+
+	// Reputation, kept in sync from reviews
+	RatingCount         int64   `json:"rating_count" gorm:"default:0"`
+	RatingAverage       float64 `json:"rating_average" gorm:"default:0"`
+	RatingQuality       float64 `json:"rating_quality" gorm:"default:0"`
+	RatingCommunication float64 `json:"rating_communication" gorm:"default:0"`
+	RatingTimeliness    float64 `json:"rating_timeliness" gorm:"default:0"`
+
 	// Professional details (optional)
 	PortfolioURL *string        `json:"portfolio_url"`
 	LinkedInURL  *string        `json:"linkedin_url"`
@@ -51,3 +71,16 @@ func (u *AssociateProfile) BeforeCreate(tx *gorm.DB) (err error) {
 	}
 	return nil
 }
+
+// AfterFind masks the payout account number down to what responses may show
+func (u *AssociateProfile) AfterFind(tx *gorm.DB) (err error) {
+	u.PayoutAccountLast4 = ""
+	if u.PayoutAccountNumber != nil {
+		u.PayoutAccountLast4 = Last4(*u.PayoutAccountNumber)
+	}
+	return nil
+}
+
+func (u *AssociateProfile) AfterSave(tx *gorm.DB) (err error) {
+	return u.AfterFind(tx)
+}
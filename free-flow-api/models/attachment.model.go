@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Records a file can be attached to
+const (
+	AttachmentTask      = "task"
+	AttachmentExpense   = "expense"
+	AttachmentMilestone = "milestone"
+	AttachmentContract  = "contract"
+	AttachmentProfile   = "profile" // an associate's profile
+
+	AttachmentDeliverable = "deliverable"
+)
+
+// Attachment is an uploaded file linked to a record. The bytes live in the storage backend
+// under StorageKey; they are only handed out through short-lived signed URLs.
+type Attachment struct {
+	ID        uuid.UUID      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	ProjectID  *uuid.UUID `json:"project_id" gorm:"index"` // nil for profile attachments
+	TargetType string     `json:"target_type" gorm:"not null;index:idx_attachment_target"`
+	TargetID   uuid.UUID  `json:"target_id" gorm:"not null;index:idx_attachment_target"`
+
+	UploaderType string    `json:"uploader_type" gorm:"not null"` // "user", "associate"
+	UploaderID   uuid.UUID `json:"uploader_id" gorm:"not null;index"`
+
+	FileName    string `json:"file_name" gorm:"not null"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	Checksum    string `json:"checksum"`    // sha256 of the contents
+	ScanStatus  string `json:"scan_status"` // "clean", "skipped"
+	Description string `json:"description"`
+
+	StorageKey string `json:"-" gorm:"not null"`
+}
+
+func (a *Attachment) BeforeCreate(tx *gorm.DB) (err error) {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Audit actions
+const (
+	AuditCreate = "create"
+	AuditUpdate = "update"
+	AuditDelete = "delete"
+)
+
+// Principals that aren't users or associates
+const (
+	PrincipalService = "service" // another backend calling with a service key
+)
+
+// AuditChange is one column's value before and after a write
+type AuditChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// AuditChanges maps column names to their change, stored as jsonb
+type AuditChanges map[string]AuditChange
+
+func (a AuditChanges) Value() (driver.Value, error) {
+	if a == nil {
+		return "{}", nil
+	}
+	b, err := json.Marshal(a)
+	return string(b), err
+}
+
+func (a *AuditChanges) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*a = AuditChanges{}
+		return nil
+	case []byte:
+		return json.Unmarshal(v, a)
+	case string:
+		return json.Unmarshal([]byte(v), a)
+	}
+	return errors.New("unsupported audit changes value")
+}
+
+// AuditLog is an append-only record of a create, update or delete on any table,
+// written by the GORM audit callbacks in the same transaction as the change itself
+type AuditLog struct {
+	ID        uuid.UUID `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+
+	Action     string     `json:"action" gorm:"not null"`
+	RecordType string     `json:"record_type" gorm:"not null;index:idx_audit_record"` // table name, e.g. "tasks"
+	RecordID   *uuid.UUID `json:"record_id" gorm:"index:idx_audit_record"`
+	ProjectID  *uuid.UUID `json:"project_id" gorm:"index"`
+
+	ActorType      string     `json:"actor_type" gorm:"not null"` // "user", "associate", "client", "service", "system"
+	ActorID        *uuid.UUID `json:"actor_id" gorm:"index"`
+	ImpersonatorID *uuid.UUID `json:"impersonator_id"`
+
+	Changes AuditChanges `json:"changes" gorm:"type:jsonb"`
+
+	RequestID string `json:"request_id" gorm:"index"`
+	IPAddress string `json:"ip_address"`
+}
+
+func (a *AuditLog) BeforeCreate(tx *gorm.DB) (err error) {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// BillingRate is a freelancer's hourly rate to the client, in the project's currency.
+// ProjectID and OwnerID narrow where it applies; the most specific match wins.
+type BillingRate struct {
+	ID        uuid.UUID      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	UserID    uuid.UUID  `json:"user_id" gorm:"not null;index"`
+	ProjectID *uuid.UUID `json:"project_id" gorm:"index"` // nil: every project
+	OwnerType *string    `json:"owner_type"`              // "user", "associate"; nil: anyone
+	OwnerID   *uuid.UUID `json:"owner_id"`
+
+	HourlyRate float64 `json:"hourly_rate" gorm:"not null"`
+}
+
+func (r *BillingRate) BeforeCreate(tx *gorm.DB) (err error) {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+// specificity ranks a rate for a project and person, or returns -1 when it does not apply
+func (r BillingRate) specificity(projectID uuid.UUID, ownerType string, ownerID uuid.UUID) int {
+	score := 0
+	if r.ProjectID != nil {
+		if *r.ProjectID != projectID {
+			return -1
+		}
+		score += 2
+	}
+	if r.OwnerID != nil {
+		if *r.OwnerID != ownerID || (r.OwnerType != nil && *r.OwnerType != ownerType) {
+			return -1
+		}
+		score++
+	}
+	return score
+}
+
+// ResolveRate picks the most specific rate for work by a person on a project, or nil if none applies.
+// Order: project and person, project default, person default, freelancer default.
+func ResolveRate(rates []BillingRate, projectID uuid.UUID, ownerType string, ownerID uuid.UUID) *BillingRate {
+	var best *BillingRate
+	bestScore := -1
+	for i := range rates {
+		if s := rates[i].specificity(projectID, ownerType, ownerID); s > bestScore {
+			best = &rates[i]
+			bestScore = s
+		}
+	}
+	return best
+}
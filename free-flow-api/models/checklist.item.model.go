@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ChecklistItem is a lightweight step on a task, too small to be a subtask of its own
+type ChecklistItem struct {
+	ID        uuid.UUID      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	TaskID   uuid.UUID `json:"task_id" gorm:"not null;index"`
+	Title    string    `json:"title" gorm:"not null"`
+	Position int       `json:"position" gorm:"default:0"`
+
+	Done       bool       `json:"done" gorm:"default:false"`
+	DoneAt     *time.Time `json:"done_at"`
+	DoneByType *string    `json:"done_by_type"` // "user", "associate"
+	DoneByID   *uuid.UUID `json:"done_by_id"`
+}
+
+func (i *ChecklistItem) BeforeCreate(tx *gorm.DB) (err error) {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Clients have no account; they comment through a signed project link and are identified by their entity
+const PrincipalClient = "client"
+
+// Records a comment can be attached to
+const (
+	CommentTargetTask      = "task"
+	CommentTargetMilestone = "milestone"
+	CommentTargetContract  = "contract"
+	CommentTargetInvoice   = "invoice"
+)
+
+// Who besides the freelancer can see a thread
+const (
+	CommentInternal  = "internal"  // freelancer only
+	CommentAssociate = "associate" // shared with associates working on the record
+	CommentClient    = "client"    // shared with the client
+)
+
+// Comment is a message in a discussion on a task, milestone, contract or invoice.
+// Replies point at the thread's root comment and share its target and visibility.
+type Comment struct {
+	ID        uuid.UUID      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	ProjectID  uuid.UUID  `json:"project_id" gorm:"not null;index"`
+	TargetType string     `json:"target_type" gorm:"not null;index:idx_comment_target"`
+	TargetID   uuid.UUID  `json:"target_id" gorm:"not null;index:idx_comment_target"`
+	ParentID   *uuid.UUID `json:"parent_id" gorm:"index"`
+
+	AuthorType string    `json:"author_type" gorm:"not null"` // "user", "associate", "client"
+	AuthorID   uuid.UUID `json:"author_id" gorm:"not null;index"`
+	AuthorName string    `json:"author_name"`
+
+	Body       string     `json:"body" gorm:"type:text;not null"`
+	Visibility string     `json:"visibility" gorm:"default:'internal'"`
+	EditedAt   *time.Time `json:"edited_at"`
+
+	Mentions []CommentMention `json:"mentions,omitempty" gorm:"foreignKey:CommentID"`
+	Replies  []Comment        `json:"replies,omitempty" gorm:"foreignKey:ParentID"`
+}
+
+func (m *Comment) BeforeCreate(tx *gorm.DB) (err error) {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return nil
+}
+
+// CommentMention is someone called out in a comment
+type CommentMention struct {
+	ID        uuid.UUID `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+
+	CommentID     uuid.UUID `json:"comment_id" gorm:"not null;index"`
+	PrincipalType string    `json:"principal_type" gorm:"not null"` // "user", "associate"
+	PrincipalID   uuid.UUID `json:"principal_id" gorm:"not null;index"`
+}
+
+func (m *CommentMention) BeforeCreate(tx *gorm.DB) (err error) {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return nil
+}
+
+// CommentRevision keeps the text a comment had before it was edited or deleted
+type CommentRevision struct {
+	ID        uuid.UUID `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+
+	CommentID  uuid.UUID `json:"comment_id" gorm:"not null;index"`
+	Action     string    `json:"action" gorm:"not null"` // "edit", "delete"
+	Body       string    `json:"body" gorm:"type:text"`
+	Visibility string    `json:"visibility"`
+	ActorType  string    `json:"actor_type"`
+	ActorID    uuid.UUID `json:"actor_id"`
+}
+
+func (m *CommentRevision) BeforeCreate(tx *gorm.DB) (err error) {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return nil
+}
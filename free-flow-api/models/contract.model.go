@@ -31,7 +31,13 @@ type Contract struct {
 	TimelineNotes string    `json:"timeline_notes"`
 	Timestamp     time.Time `json:"timestamp"`
 
-	PaymentTerms string `json:"payment_terms"`
+	PaymentTerms string   `json:"payment_terms"`
+	AgreedPrice  *float64 `json:"agreed_price"` // set when an offer is awarded on a bid
+
+	// Versioning
+	CurrentVersion   int  `json:"current_version" gorm:"default:1"`
+	AcceptedVersion  int  `json:"accepted_version" gorm:"default:0"` // 0 until an associate accepts
+	AmendmentPending bool `json:"amendment_pending" gorm:"default:false"`
 
 	Project Project `json:"project" gorm:"foreignKey:ProjectID"`
 	Task    Task    `json:"task" gorm:"foreignKey:TaskID"`
@@ -43,3 +49,15 @@ func (m *Contract) BeforeCreate(tx *gorm.DB) (err error) {
 	}
 	return nil
 }
+
+// AfterCreate records the initial terms as version 1
+func (m *Contract) AfterCreate(tx *gorm.DB) (err error) {
+	version := NewContractVersion(*m, 1)
+	version.ChangeNote = "initial version"
+	return tx.Create(&version).Error
+}
+
+// IsAccepted reports whether any version of the contract has been accepted
+func (m *Contract) IsAccepted() bool {
+	return m.AcceptedVersion > 0
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrContractSignatureImmutable = errors.New("contract signatures cannot be changed")
+
+// ContractSignature is the evidence recorded when an associate signs a contract version
+type ContractSignature struct {
+	ID        uuid.UUID `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+
+	ContractID      uuid.UUID `json:"contract_id" gorm:"not null;index"`
+	ContractVersion int       `json:"contract_version" gorm:"not null"`
+	InviteID        uuid.UUID `json:"invite_id" gorm:"not null;uniqueIndex"`
+	AssociateID     uuid.UUID `json:"associate_id" gorm:"not null;index"`
+
+	SignerName    string `json:"signer_name" gorm:"not null"`
+	SignatureType string `json:"signature_type" gorm:"not null"`  // "typed", "drawn"
+	SignatureData string `json:"signature_data" gorm:"type:text"` // typed name or base64 image data url
+
+	Document     string    `json:"-" gorm:"type:text"`                    // the exact text the signer was shown
+	DocumentHash string    `json:"document_hash" gorm:"not null;size:64"` // sha256 of Document
+	IPAddress    string    `json:"ip_address"`
+	UserAgent    string    `json:"user_agent"`
+	SignedAt     time.Time `json:"signed_at"`
+
+	Contract Contract `json:"-" gorm:"foreignKey:ContractID"`
+}
+
+func (s *ContractSignature) BeforeCreate(tx *gorm.DB) (err error) {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+// Intact reports whether the stored document still hashes to what was signed
+func (s ContractSignature) Intact() bool {
+	if s.Document == "" {
+		return false
+	}
+	sum := sha256.Sum256([]byte(s.Document))
+	return hex.EncodeToString(sum[:]) == s.DocumentHash
+}
+
+func (s *ContractSignature) BeforeUpdate(tx *gorm.DB) (err error) {
+	return ErrContractSignatureImmutable
+}
+
+func (s *ContractSignature) BeforeDelete(tx *gorm.DB) (err error) {
+	return ErrContractSignatureImmutable
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+// ContractTemplate holds reusable contract wording with {{placeholders}} resolved per task
+type ContractTemplate struct {
+	ID        uuid.UUID      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	UserID uuid.UUID `json:"user_id" gorm:"not null;index"`
+	Name   string    `json:"name" gorm:"not null"`
+
+	Description     string `json:"description"`
+	Confidentiality string `json:"confidentiality"`
+	Ownership       string `json:"ownership"`
+
+	Role             string         `json:"role"`
+	Responsibilities pq.StringArray `json:"responsibilities" gorm:"type:text[]"`
+	Deliverables     pq.StringArray `json:"deliverables" gorm:"type:text[]"`
+
+	Effort        string `json:"effort"`
+	TimelineNotes string `json:"timeline_notes"`
+	PaymentTerms  string `json:"payment_terms"`
+
+	User User `json:"-" gorm:"foreignKey:UserID"`
+}
+
+func (m *ContractTemplate) BeforeCreate(tx *gorm.DB) (err error) {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return nil
+}
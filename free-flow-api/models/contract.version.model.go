@@ -0,0 +1,212 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+var ErrContractVersionLocked = errors.New("accepted contract versions are immutable")
+
+// ContractVersion is a snapshot of a contract's terms; once accepted it can no longer change
+type ContractVersion struct {
+	ID        uuid.UUID      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	ContractID  uuid.UUID `json:"contract_id" gorm:"not null;uniqueIndex:idx_contract_version"`
+	Version     int       `json:"version" gorm:"not null;uniqueIndex:idx_contract_version"`
+	IsAmendment bool      `json:"is_amendment" gorm:"default:false"`
+	ChangeNote  string    `json:"change_note"`
+
+	Description      string         `json:"description"`
+	Confidentiality  string         `json:"confidentiality"`
+	Ownership        string         `json:"ownership"`
+	Role             string         `json:"role"`
+	Responsibilities pq.StringArray `json:"responsibilities" gorm:"type:text[]"`
+	Deliverables     pq.StringArray `json:"deliverables" gorm:"type:text[]"`
+	Effort           string         `json:"effort"`
+	StartDate        time.Time      `json:"start_date"`
+	EndDate          time.Time      `json:"end_date"`
+	TimelineNotes    string         `json:"timeline_notes"`
+	PaymentTerms     string         `json:"payment_terms"`
+	AgreedPrice      *float64       `json:"agreed_price"`
+
+	// Acceptance
+	AcceptedAt *time.Time `json:"accepted_at"`
+	AcceptedBy *uuid.UUID `json:"accepted_by"` // associate id
+
+	Contract Contract `json:"-" gorm:"foreignKey:ContractID"`
+}
+
+// ContractFieldChange describes how a single term differs between two versions
+type ContractFieldChange struct {
+	Field   string   `json:"field"`
+	From    any      `json:"from,omitempty"`
+	To      any      `json:"to,omitempty"`
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+}
+
+func (v *ContractVersion) BeforeCreate(tx *gorm.DB) (err error) {
+	if v.ID == uuid.Nil {
+		v.ID = uuid.New()
+	}
+	return nil
+}
+
+func (v *ContractVersion) BeforeUpdate(tx *gorm.DB) (err error) {
+	if v.AcceptedAt != nil {
+		return ErrContractVersionLocked
+	}
+	return nil
+}
+
+func (v *ContractVersion) BeforeDelete(tx *gorm.DB) (err error) {
+	if v.AcceptedAt != nil {
+		return ErrContractVersionLocked
+	}
+	return nil
+}
+
+// NewContractVersion snapshots the contract's current terms
+func NewContractVersion(c Contract, version int) ContractVersion {
+	return ContractVersion{
+		ContractID:       c.ID,
+		Version:          version,
+		Description:      c.Description,
+		Confidentiality:  c.Confidentiality,
+		Ownership:        c.Ownership,
+		Role:             c.Role,
+		Responsibilities: slices.Clone(c.Responsibilities),
+		Deliverables:     slices.Clone(c.Deliverables),
+		Effort:           c.Effort,
+		StartDate:        c.StartDate,
+		EndDate:          c.EndDate,
+		TimelineNotes:    c.TimelineNotes,
+		PaymentTerms:     c.PaymentTerms,
+		AgreedPrice:      c.AgreedPrice,
+	}
+}
+
+// ApplyTo copies the version's terms back onto a contract
+func (v ContractVersion) ApplyTo(c *Contract) {
+	c.Description = v.Description
+	c.Confidentiality = v.Confidentiality
+	c.Ownership = v.Ownership
+	c.Role = v.Role
+	c.Responsibilities = slices.Clone(v.Responsibilities)
+	c.Deliverables = slices.Clone(v.Deliverables)
+	c.Effort = v.Effort
+	c.StartDate = v.StartDate
+	c.EndDate = v.EndDate
+	c.TimelineNotes = v.TimelineNotes
+	c.PaymentTerms = v.PaymentTerms
+	c.AgreedPrice = v.AgreedPrice
+}
+
+// DiffContractVersions lists the terms that changed going from a to b
+func DiffContractVersions(a, b ContractVersion) []ContractFieldChange {
+	changes := []ContractFieldChange{}
+
+	text := func(field, from, to string) {
+		if from != to {
+			changes = append(changes, ContractFieldChange{Field: field, From: from, To: to})
+		}
+	}
+	date := func(field string, from, to time.Time) {
+		if !from.Equal(to) {
+			changes = append(changes, ContractFieldChange{Field: field, From: from, To: to})
+		}
+	}
+	list := func(field string, from, to []string) {
+		var added, removed []string
+		for _, item := range to {
+			if !slices.Contains(from, item) {
+				added = append(added, item)
+			}
+		}
+		for _, item := range from {
+			if !slices.Contains(to, item) {
+				removed = append(removed, item)
+			}
+		}
+		if len(added) > 0 || len(removed) > 0 {
+			changes = append(changes, ContractFieldChange{Field: field, Added: added, Removed: removed})
+		}
+	}
+
+	text("role", a.Role, b.Role)
+	text("description", a.Description, b.Description)
+	text("confidentiality", a.Confidentiality, b.Confidentiality)
+	text("ownership", a.Ownership, b.Ownership)
+	list("responsibilities", a.Responsibilities, b.Responsibilities)
+	list("deliverables", a.Deliverables, b.Deliverables)
+	text("effort", a.Effort, b.Effort)
+	date("start_date", a.StartDate, b.StartDate)
+	date("end_date", a.EndDate, b.EndDate)
+	text("timeline_notes", a.TimelineNotes, b.TimelineNotes)
+	text("payment_terms", a.PaymentTerms, b.PaymentTerms)
+	if !floatPtrEqual(a.AgreedPrice, b.AgreedPrice) {
+		changes = append(changes, ContractFieldChange{Field: "agreed_price", From: a.AgreedPrice, To: b.AgreedPrice})
+	}
+
+	return changes
+}
+
+func floatPtrEqual(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// Document renders the version as the canonical text shown to signers.
+// The output must stay stable: signature hashes are computed over it.
+func (v ContractVersion) Document() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "CONTRACT %s\n", v.ContractID)
+	fmt.Fprintf(&b, "Version: %d\n\n", v.Version)
+	fmt.Fprintf(&b, "Role: %s\n", v.Role)
+	fmt.Fprintf(&b, "Description: %s\n\n", v.Description)
+
+	b.WriteString("Responsibilities:\n")
+	for _, r := range v.Responsibilities {
+		fmt.Fprintf(&b, "- %s\n", r)
+	}
+	b.WriteString("\nDeliverables:\n")
+	for _, d := range v.Deliverables {
+		fmt.Fprintf(&b, "- %s\n", d)
+	}
+
+	fmt.Fprintf(&b, "\nEffort: %s\n", v.Effort)
+	fmt.Fprintf(&b, "Start date: %s\n", v.StartDate.UTC().Format("2006-01-02"))
+	fmt.Fprintf(&b, "End date: %s\n", v.EndDate.UTC().Format("2006-01-02"))
+	fmt.Fprintf(&b, "Timeline notes: %s\n\n", v.TimelineNotes)
+	fmt.Fprintf(&b, "Payment terms: %s\n", v.PaymentTerms)
+	// only priced versions carry the line, so documents signed before it existed hash the same
+	if v.AgreedPrice != nil {
+		fmt.Fprintf(&b, "Agreed price: %.2f\n", *v.AgreedPrice)
+	}
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "Confidentiality: %s\n\n", v.Confidentiality)
+	fmt.Fprintf(&b, "Ownership: %s\n", v.Ownership)
+
+	return b.String()
+}
+
+// DocumentHash is the hex encoded SHA-256 of the rendered document
+func (v ContractVersion) DocumentHash() string {
+	sum := sha256.Sum256([]byte(v.Document()))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+// Deliverable statuses
+const (
+	DeliverableSubmitted        = "submitted"
+	DeliverableApproved         = "approved"
+	DeliverableChangesRequested = "changes_requested"
+)
+
+// Deliverable is one round of work an associate hands in on a task. Every round is kept,
+// along with the freelancer's verdict on it.
+type Deliverable struct {
+	ID        uuid.UUID      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	TaskID      uuid.UUID `json:"task_id" gorm:"not null;index;uniqueIndex:idx_deliverable_round"`
+	ProjectID   uuid.UUID `json:"project_id" gorm:"not null;index"`
+	AssociateID uuid.UUID `json:"associate_id" gorm:"not null;index"`
+	Round       int       `json:"round" gorm:"not null;uniqueIndex:idx_deliverable_round"`
+
+	Notes string         `json:"notes" gorm:"type:text"`
+	Links pq.StringArray `json:"links" gorm:"type:text[]"`
+
+	Status     string     `json:"status" gorm:"default:'submitted'"`
+	Feedback   string     `json:"feedback" gorm:"type:text"`
+	ReviewedAt *time.Time `json:"reviewed_at"`
+	ReviewedBy *uuid.UUID `json:"reviewed_by"`
+
+	// files are Attachments targeting the deliverable; filled in by the caller
+	Attachments []Attachment `json:"attachments" gorm:"-"`
+}
+
+func (d *Deliverable) BeforeCreate(tx *gorm.DB) (err error) {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Escrow ledger entry types
+const (
+	EscrowFund       = "fund"       // client money received and held
+	EscrowRelease    = "release"    // held money released to the freelancer's revenue
+	EscrowSettlement = "settlement" // held money paid out to an associate
+	EscrowRefund     = "refund"     // held money returned to the client
+)
+
+// EscrowTransaction is a single append-only ledger entry against a milestone's escrow
+type EscrowTransaction struct {
+	ID        uuid.UUID      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	UserID      uuid.UUID `json:"user_id" gorm:"not null;index"`
+	ProjectID   uuid.UUID `json:"project_id" gorm:"not null;index"`
+	MilestoneID uuid.UUID `json:"milestone_id" gorm:"not null;index"`
+
+	Type     string  `json:"type" gorm:"not null"` // "fund", "release", "settlement", "refund"
+	Amount   float64 `json:"amount" gorm:"not null"`
+	Currency string  `json:"currency" gorm:"default:'KES'"`
+
+	SettlementID   *uuid.UUID `json:"settlement_id"` // set for associate settlement payouts
+	PaymentID      *uuid.UUID `json:"payment_id"`    // set when a release is booked as revenue
+	TransactionRef string     `json:"transaction_ref"`
+	Source         string     `json:"source" gorm:"default:'user'"` // "user", "safecollab"
+	Notes          string     `json:"notes"`
+
+	Milestone Milestone `json:"-" gorm:"foreignKey:MilestoneID"`
+	Project   Project   `json:"-" gorm:"foreignKey:ProjectID"`
+	User      User      `json:"-" gorm:"foreignKey:UserID"`
+}
+
+func (e *EscrowTransaction) BeforeCreate(tx *gorm.DB) (err error) {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+// EscrowHeldBalance sums the funds still held in escrow for a milestone. It locks the milestone and
+// its ledger rows first, so it must run inside the transaction that spends the balance; a concurrent
+// release or refund waits and then sees what this one spent.
+func EscrowHeldBalance(tx *gorm.DB, milestoneID uuid.UUID) (float64, error) {
+	locking := clause.Locking{Strength: "UPDATE"}
+	var lockedIDs []uuid.UUID
+	if err := tx.Model(&Milestone{}).Clauses(locking).Where("id = ?", milestoneID).Pluck("id", &lockedIDs).Error; err != nil {
+		return 0, err
+	}
+	if err := tx.Model(&EscrowTransaction{}).Clauses(locking).Where("milestone_id = ?", milestoneID).Pluck("id", &lockedIDs).Error; err != nil {
+		return 0, err
+	}
+
+	var held float64
+	err := tx.Model(&EscrowTransaction{}).
+		Where("milestone_id = ?", milestoneID).
+		Select("COALESCE(SUM(CASE WHEN type = ? THEN amount ELSE -amount END), 0)", EscrowFund).
+		Scan(&held).Error
+	return held, err
+}
@@ -24,6 +24,11 @@ type Expense struct {
 
 	Vendor string `json:"vendor"`
 
+	// Billing: billable expenses are passed on to the client once
+	Billable  bool       `json:"billable" gorm:"default:false"`
+	InvoiceID *uuid.UUID `json:"invoice_id" gorm:"index"`
+	BilledAt  *time.Time `json:"billed_at"`
+
 	// Relationships
 	Project Project `json:"-" gorm:"foreignKey:ProjectID"`
 	User    User    `json:"-" gorm:"foreignKey:UserID"`
@@ -13,23 +13,63 @@ type Invite struct {
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
 
-	TaskID      uuid.UUID `json:"task_id"`
-	ProjectID   uuid.UUID `json:"project_id"`
-	AssociateID uuid.UUID `json:"associate_id"`
-	ContractID  uuid.UUID `json:"contract_id"`
+	TaskID      uuid.UUID  `json:"task_id"`
+	ProjectID   uuid.UUID  `json:"project_id"`
+	AssociateID uuid.UUID  `json:"associate_id"`
+	ContractID  uuid.UUID  `json:"contract_id"`
+	OfferID     *uuid.UUID `json:"offer_id" gorm:"index"` // set when sent as part of a task offer
 
-	Status      string    `json:"status"`
+	ContractVersion int `json:"contract_version" gorm:"default:1"` // version of the terms offered
+
+	Status      string    `json:"status"` // "pending", "accepted", "declined", "expired", "revoked", "cancelled"
 	RespondedAt time.Time `json:"responded_at"`
 
+	// Lifecycle
+	TokenID        string     `json:"-" gorm:"index"` // only links carrying this id are honoured
+	ExpiresAt      time.Time  `json:"expires_at"`
+	ReminderSentAt *time.Time `json:"reminder_sent_at"`
+	RevokedAt      *time.Time `json:"revoked_at"`
+
 	Project   Project   `json:"project" gorm:"foreignKey:ProjectID"`
 	Task      Task      `json:"task" gorm:"foreignKey:TaskID"`
 	Associate Associate `json:"associate" gorm:"foreignKey:AssociateID"`
 	Contract  Contract  `json:"contract" gorm:"foreignKey:ContractID"`
 }
 
+const (
+	InviteTTL         = 72 * time.Hour
+	InviteReminderLag = 24 * time.Hour // remind this long before expiry
+)
+
 func (m *Invite) BeforeCreate(tx *gorm.DB) (err error) {
 	if m.ID == uuid.Nil {
 		m.ID = uuid.New()
 	}
 	return nil
 }
+
+// IsExpired reports whether a pending invite has passed its expiry
+func (m *Invite) IsExpired() bool {
+	return m.Status == "pending" && !m.ExpiresAt.IsZero() && time.Now().After(m.ExpiresAt)
+}
+
+// ExpireStaleInvites moves pending invites past their expiry to expired
+func ExpireStaleInvites(tx *gorm.DB) (int64, error) {
+	result := tx.Model(&Invite{}).
+		Where("status = ? AND expires_at IS NOT NULL AND expires_at > ? AND expires_at < ?", "pending", time.Time{}, time.Now()).
+		Update("status", "expired")
+	return result.RowsAffected, result.Error
+}
+
+// NotifyInvite tells the associate about their invite; the link itself is never stored or logged
+func NotifyInvite(tx *gorm.DB, invite Invite, kind, title string) error {
+	inviteID := invite.ID
+	return tx.Create(&Notification{
+		RecipientType: PrincipalAssociate,
+		RecipientID:   invite.AssociateID,
+		Kind:          kind,
+		Title:         title,
+		TargetType:    "invite",
+		TargetID:      &inviteID,
+	}).Error
+}
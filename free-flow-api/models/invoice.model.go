@@ -16,10 +16,12 @@ type Invoice struct {
 
 	UserID uuid.UUID `json:"user_id"`
 
-	ProjectID     uuid.UUID `json:"project_id" gorm:"not null"`
-	InvoiceNumber string    `json:"invoice_number" gorm:"uniqueIndex"`
-	Amount        float64   `json:"amount" gorm:"not null"`
-	Currency      string    `json:"currency" gorm:"default:'KES'"`
+	ProjectID     uuid.UUID  `json:"project_id" gorm:"not null"`
+	MilestoneID   *uuid.UUID `json:"milestone_id" gorm:"index"`
+	InvoiceNumber string     `json:"invoice_number" gorm:"uniqueIndex"`
+	Amount        float64    `json:"amount" gorm:"not null"`
+	Currency      string     `json:"currency" gorm:"default:'KES'"`
+	Type          string     `json:"type" gorm:"default:'standard'"` // "standard", "deposit"
 
 	// Status and dates
 	Status    string     `json:"status" gorm:"default:'draft'"` // "draft", "sent", "paid", "overdue", "cancelled"
@@ -36,8 +38,32 @@ type Invoice struct {
 	TransactionRef *string `json:"transaction_ref"` // M-Pesa code, bank ref, etc.
 
 	// Relationships
-	Project Project `json:"-" gorm:"foreignKey:ProjectID"`
-	User    User    `json:"-" gorm:"foreignKey:UserID"`
+	LineItems []InvoiceLineItem `json:"line_items,omitempty" gorm:"foreignKey:InvoiceID;constraint:OnDelete:CASCADE"`
+	Project   Project           `json:"-" gorm:"foreignKey:ProjectID"`
+	User      User              `json:"-" gorm:"foreignKey:UserID"`
+}
+
+// InvoiceLineItem is a single billed line; deposit credits are stored as negative lines
+type InvoiceLineItem struct {
+	ID        uuid.UUID `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	InvoiceID   uuid.UUID `json:"invoice_id" gorm:"not null;index"`
+	Kind        string    `json:"kind" gorm:"default:'item'"` // "item", "deposit_credit", "time", "expense"
+	Description string    `json:"description"`
+	Quantity    float64   `json:"quantity" gorm:"default:1"`
+	UnitPrice   float64   `json:"unit_price"`
+	Amount      float64   `json:"amount"`
+
+	DepositInvoiceID *uuid.UUID `json:"deposit_invoice_id" gorm:"index"` // deposit a credit line draws from
+}
+
+func (l *InvoiceLineItem) BeforeCreate(tx *gorm.DB) (err error) {
+	if l.ID == uuid.Nil {
+		l.ID = uuid.New()
+	}
+	return nil
 }
 
 func (u *Invoice) BeforeCreate(tx *gorm.DB) (err error) {
@@ -46,3 +72,61 @@ func (u *Invoice) BeforeCreate(tx *gorm.DB) (err error) {
 	}
 	return nil
 }
+
+// AfterSave keeps the billing status of a linked milestone in step with its invoice
+func (u *Invoice) AfterSave(tx *gorm.DB) (err error) {
+	// cancelled invoices hand their time and expenses back for billing
+	if u.Status == "cancelled" {
+		if err := releaseBilledWork(tx, u.ID); err != nil {
+			return err
+		}
+	}
+
+	if u.MilestoneID == nil {
+		return nil
+	}
+
+	// a cancelled invoice unlinks its milestone so it can be billed again
+	if u.Status == "cancelled" {
+		return unlinkMilestone(tx, u.ID)
+	}
+
+	billingStatus := "invoiced"
+	if u.Status == "paid" {
+		billingStatus = "paid"
+	}
+
+	return tx.Model(&Milestone{}).
+		Where("id = ?", *u.MilestoneID).
+		Update("billing_status", billingStatus).Error
+}
+
+// AfterDelete hands billed work back and unlinks the milestone, like a cancellation
+func (u *Invoice) AfterDelete(tx *gorm.DB) (err error) {
+	if err := releaseBilledWork(tx, u.ID); err != nil {
+		return err
+	}
+	return unlinkMilestone(tx, u.ID)
+}
+
+// unlinkMilestone marks the milestone billed on an invoice as unbilled again
+func unlinkMilestone(tx *gorm.DB, invoiceID uuid.UUID) error {
+	if invoiceID == uuid.Nil {
+		return nil
+	}
+	return tx.Model(&Milestone{}).
+		Where("invoice_id = ?", invoiceID).
+		Updates(map[string]interface{}{"billing_status": "unbilled", "invoice_id": nil}).Error
+}
+
+// releaseBilledWork marks the time entries and expenses billed on an invoice as unbilled again
+func releaseBilledWork(tx *gorm.DB, invoiceID uuid.UUID) error {
+	if invoiceID == uuid.Nil {
+		return nil
+	}
+	unbilled := map[string]interface{}{"invoice_id": nil, "billed_at": nil}
+	if err := tx.Model(&TimeEntry{}).Where("invoice_id = ?", invoiceID).UpdateColumns(unbilled).Error; err != nil {
+		return err
+	}
+	return tx.Model(&Expense{}).Where("invoice_id = ?", invoiceID).UpdateColumns(unbilled).Error
+}
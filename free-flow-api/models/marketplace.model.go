@@ -0,0 +1,94 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+const (
+	MarketplaceInactive  = "inactive"
+	MarketplacePending   = "pending"
+	MarketplaceApproved  = "approved"
+	MarketplaceSuspended = "suspended"
+)
+
+// marketplaceTransitions lists the statuses an associate's marketplace standing can move to
+var marketplaceTransitions = map[string][]string{
+	MarketplaceInactive:  {MarketplacePending},
+	MarketplacePending:   {MarketplaceApproved, MarketplaceSuspended, MarketplaceInactive},
+	MarketplaceApproved:  {MarketplaceSuspended},
+	MarketplaceSuspended: {MarketplaceApproved, MarketplaceInactive},
+}
+
+// CanTransitionMarketplace reports whether a marketplace status change is allowed
+func CanTransitionMarketplace(from, to string) bool {
+	if from == "" {
+		from = MarketplaceInactive
+	}
+	for _, s := range marketplaceTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// JobPosting advertises a task to marketplace associates
+type JobPosting struct {
+	ID        uuid.UUID      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	UserID    uuid.UUID `json:"user_id" gorm:"not null;index"`
+	TaskID    uuid.UUID `json:"task_id" gorm:"not null;index"`
+	ProjectID uuid.UUID `json:"project_id" gorm:"not null"`
+
+	Title       string         `json:"title" gorm:"not null"`
+	Description string         `json:"description"`
+	Skills      pq.StringArray `json:"skills" gorm:"type:text[]"`
+	Budget      *float64       `json:"budget"`
+	Currency    string         `json:"currency" gorm:"default:'USD'"`
+	Deadline    *time.Time     `json:"deadline"`
+	Visibility  string         `json:"visibility" gorm:"default:'public'"` // "public", "private" (own associates only)
+	Status      string         `json:"status" gorm:"default:'open'"`       // "open", "closed", "filled"
+
+	Applications []JobApplication `json:"applications,omitempty" gorm:"foreignKey:PostingID"`
+}
+
+// JobApplication is an associate's proposal for a posting
+type JobApplication struct {
+	ID        uuid.UUID      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	PostingID   uuid.UUID `json:"posting_id" gorm:"not null;uniqueIndex:idx_posting_applicant"`
+	AssociateID uuid.UUID `json:"associate_id" gorm:"not null;uniqueIndex:idx_posting_applicant"`
+
+	Proposal       string     `json:"proposal" gorm:"type:text"`
+	Price          *float64   `json:"price"`
+	EstimatedHours *float64   `json:"estimated_hours"`
+	DeliveryDate   *time.Time `json:"delivery_date"`
+	Status         string     `json:"status" gorm:"default:'submitted'"` // "submitted", "accepted", "rejected", "withdrawn"
+
+	Posting   JobPosting `json:"posting,omitempty" gorm:"foreignKey:PostingID"`
+	Associate Associate  `json:"associate,omitempty" gorm:"foreignKey:AssociateID"`
+}
+
+func (p *JobPosting) BeforeCreate(tx *gorm.DB) (err error) {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
+func (a *JobApplication) BeforeCreate(tx *gorm.DB) (err error) {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
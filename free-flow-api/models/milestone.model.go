@@ -17,7 +17,7 @@ type Milestone struct {
 	ProjectID   uuid.UUID `json:"project_id" gorm:"not null"`
 	Title       string    `json:"title" gorm:"not null"`
 	Description string    `json:"description"`
-	Status      string    `json:"status" gorm:"default:'not_started'"` // "not_started", "in_progress", "completed", "delayed"
+	Status      string    `json:"status" gorm:"default:'not_started'"` // "not_started", "in_progress", "completed", "delayed", "cancelled"
 	Priority    string    `json:"priority" gorm:"default:'medium'"`
 
 	StartDate     *time.Time `json:"start_date"`
@@ -32,11 +32,31 @@ type Milestone struct {
 	Deliverables  pq.StringArray `json:"deliverables" gorm:"type:text[]"`
 	ClientVisible bool           `json:"client_visible" gorm:"default:true"`
 
+	// Billing
+	BillingAmount    *float64   `json:"billing_amount"`                           // fixed amount billed on completion
+	BillingPercent   *float64   `json:"billing_percent"`                          // or a percentage of project estimated value
+	BillingStatus    string     `json:"billing_status" gorm:"default:'unbilled'"` // "unbilled", "invoiced", "paid"
+	InvoiceID        *uuid.UUID `json:"invoice_id" gorm:"index"`
+	ClientApprovedAt *time.Time `json:"client_approved_at"`
+	ClientApprovedBy *uuid.UUID `json:"client_approved_by"` // client entity that approved through its project link
+
 	// Relationships
 	Project Project `json:"-" gorm:"foreignKey:ProjectID"`
 	Tasks   []Task  `json:"tasks" gorm:"foreignKey:MilestoneID"`
 }
 
+// BillableAmount resolves how much the milestone bills for, preferring a fixed
+// amount over a percentage of the project's estimated value.
+func (m *Milestone) BillableAmount(project Project) float64 {
+	if m.BillingAmount != nil {
+		return *m.BillingAmount
+	}
+	if m.BillingPercent != nil {
+		return project.EstimatedValue * (*m.BillingPercent / 100.0)
+	}
+	return 0
+}
+
 func (m *Milestone) BeforeCreate(tx *gorm.DB) (err error) {
 	if m.ID == uuid.Nil {
 		m.ID = uuid.New()
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Notification kinds
+const (
+	NotificationMention = "mention"
+	NotificationReply   = "reply"
+
+	NotificationDeliverableSubmitted = "deliverable_submitted"
+	NotificationDeliverableApproved  = "deliverable_approved"
+	NotificationChangesRequested     = "changes_requested"
+
+	NotificationStatusChanged = "status_changed"
+
+	NotificationInviteSent     = "invite_sent"
+	NotificationInviteReminder = "invite_reminder"
+)
+
+// Notification is an in-app message for a freelancer or an associate
+type Notification struct {
+	ID        uuid.UUID      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	RecipientType string    `json:"recipient_type" gorm:"not null;index:idx_notification_recipient"` // "user", "associate"
+	RecipientID   uuid.UUID `json:"recipient_id" gorm:"not null;index:idx_notification_recipient"`
+
+	Kind    string `json:"kind" gorm:"not null"`
+	Title   string `json:"title"`
+	Message string `json:"message"`
+
+	// What the notification is about
+	TargetType string     `json:"target_type"`
+	TargetID   *uuid.UUID `json:"target_id"`
+	CommentID  *uuid.UUID `json:"comment_id"`
+
+	ReadAt *time.Time `json:"read_at"`
+}
+
+func (n *Notification) BeforeCreate(tx *gorm.DB) (err error) {
+	if n.ID == uuid.Nil {
+		n.ID = uuid.New()
+	}
+	return nil
+}
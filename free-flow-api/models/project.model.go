@@ -68,6 +68,9 @@ type Project struct {
 	ProgressPercent int    `json:"progress_percent" gorm:"default:0"`
 	Notes           string `json:"notes"`
 
+	// Client access
+	ClientLinkID string `json:"-" gorm:"index"` // only client links carrying this id are honoured
+
 	// Relationships
 	Entity     Entity      `json:"entity" gorm:"foreignKey:EntityID"`
 	Associates []Associate `json:"associates" gorm:"many2many:project_associates;"`
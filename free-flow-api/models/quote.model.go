@@ -0,0 +1,88 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type QuoteStatus string
+
+const (
+	QuoteStatusDraft    QuoteStatus = "draft"
+	QuoteStatusSent     QuoteStatus = "sent"
+	QuoteStatusAccepted QuoteStatus = "accepted"
+	QuoteStatusRejected QuoteStatus = "rejected"
+	QuoteStatusExpired  QuoteStatus = "expired"
+)
+
+// Quote is a proposal sent to a client which converts into a project once accepted
+type Quote struct {
+	ID        uuid.UUID      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	UserID    uuid.UUID  `json:"user_id" gorm:"not null;index"`
+	EntityID  *uuid.UUID `json:"entity_id"`
+	ProjectID *uuid.UUID `json:"project_id"` // set once converted
+
+	QuoteNumber string      `json:"quote_number" gorm:"uniqueIndex"`
+	Title       string      `json:"title" gorm:"not null"`
+	Description string      `json:"description"`
+	Category    string      `json:"category" gorm:"type:varchar(50)"`
+	Terms       string      `json:"terms"`
+	Status      QuoteStatus `json:"status" gorm:"default:'draft'"`
+
+	Total          float64  `json:"total"`
+	Currency       string   `json:"currency" gorm:"default:'KES'"`
+	DepositPercent *float64 `json:"deposit_percent"` // upfront deposit requested on acceptance
+
+	ValidUntil  time.Time  `json:"valid_until"`
+	SentAt      *time.Time `json:"sent_at"`
+	RespondedAt *time.Time `json:"responded_at"`
+	ConvertedAt *time.Time `json:"converted_at"`
+
+	// Relationships
+	LineItems []QuoteLineItem `json:"line_items" gorm:"foreignKey:QuoteID;constraint:OnDelete:CASCADE"`
+	Entity    *Entity         `json:"entity,omitempty" gorm:"foreignKey:EntityID"`
+	User      User            `json:"-" gorm:"foreignKey:UserID"`
+}
+
+// QuoteLineItem is a priced unit of work; items sharing a milestone name are grouped on conversion
+type QuoteLineItem struct {
+	ID        uuid.UUID `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	QuoteID        uuid.UUID `json:"quote_id" gorm:"not null;index"`
+	Milestone      string    `json:"milestone"`
+	Description    string    `json:"description" gorm:"not null"`
+	Quantity       float64   `json:"quantity" gorm:"default:1"`
+	UnitPrice      float64   `json:"unit_price"`
+	Amount         float64   `json:"amount"`
+	EstimatedHours float64   `json:"estimated_hours"`
+	Position       int       `json:"position"`
+}
+
+func (q *Quote) BeforeCreate(tx *gorm.DB) (err error) {
+	if q.ID == uuid.Nil {
+		q.ID = uuid.New()
+	}
+	return nil
+}
+
+func (i *QuoteLineItem) BeforeCreate(tx *gorm.DB) (err error) {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	i.Amount = i.Quantity * i.UnitPrice
+	return nil
+}
+
+// IsExpired reports whether an open quote has passed its validity date
+func (q *Quote) IsExpired() bool {
+	open := q.Status == QuoteStatusDraft || q.Status == QuoteStatusSent
+	return open && !q.ValidUntil.IsZero() && time.Now().After(q.ValidUntil)
+}
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	ReviewOfAssociate  = "associate"  // written by the freelancer about the associate
+	ReviewOfFreelancer = "freelancer" // written by the associate about the freelancer
+)
+
+// Review is feedback left on a completed task by either side of the engagement
+type Review struct {
+	ID        uuid.UUID      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	TaskID      uuid.UUID `json:"task_id" gorm:"not null;uniqueIndex:idx_review_task_subject"`
+	Subject     string    `json:"subject" gorm:"not null;uniqueIndex:idx_review_task_subject"` // "associate", "freelancer"
+	ProjectID   uuid.UUID `json:"project_id" gorm:"not null"`
+	AssociateID uuid.UUID `json:"associate_id" gorm:"not null;index"`
+	UserID      uuid.UUID `json:"user_id" gorm:"not null;index"` // freelancer
+
+	Quality       int     `json:"quality" gorm:"not null"` // 1-5
+	Communication int     `json:"communication" gorm:"not null"`
+	Timeliness    int     `json:"timeliness" gorm:"not null"`
+	Overall       float64 `json:"overall"`
+	Comment       string  `json:"comment" gorm:"type:text"`
+
+	Task Task `json:"-" gorm:"foreignKey:TaskID"`
+}
+
+// Reputation aggregates the reviews received by an associate or freelancer
+type Reputation struct {
+	Count         int64   `json:"count"`
+	Overall       float64 `json:"overall"`
+	Quality       float64 `json:"quality"`
+	Communication float64 `json:"communication"`
+	Timeliness    float64 `json:"timeliness"`
+}
+
+func (r *Review) BeforeCreate(tx *gorm.DB) (err error) {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+func (r *Review) BeforeSave(tx *gorm.DB) (err error) {
+	r.Overall = float64(r.Quality+r.Communication+r.Timeliness) / 3
+	return nil
+}
+
+func (r *Review) AfterSave(tx *gorm.DB) (err error) {
+	if r.Subject == ReviewOfAssociate {
+		return updateAssociateReputation(tx, r.AssociateID)
+	}
+	return nil
+}
+
+func (r *Review) AfterDelete(tx *gorm.DB) (err error) {
+	if r.Subject == ReviewOfAssociate {
+		return updateAssociateReputation(tx, r.AssociateID)
+	}
+	return nil
+}
+
+// ReputationFor averages the reviews matching the given conditions
+func ReputationFor(tx *gorm.DB, subject string, query string, args ...interface{}) (Reputation, error) {
+	var rep Reputation
+	err := tx.Model(&Review{}).
+		Select(`COUNT(*) AS count,
+			COALESCE(AVG(overall), 0) AS overall,
+			COALESCE(AVG(quality), 0) AS quality,
+			COALESCE(AVG(communication), 0) AS communication,
+			COALESCE(AVG(timeliness), 0) AS timeliness`).
+		Where("subject = ?", subject).
+		Where(query, args...).
+		Scan(&rep).Error
+	return rep, err
+}
+
+func updateAssociateReputation(tx *gorm.DB, associateID uuid.UUID) error {
+	rep, err := ReputationFor(tx, ReviewOfAssociate, "associate_id = ?", associateID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Model(&AssociateProfile{}).
+		Where("associate_id = ?", associateID).
+		Updates(map[string]interface{}{
+			"rating_count":         rep.Count,
+			"rating_average":       rep.Overall,
+			"rating_quality":       rep.Quality,
+			"rating_communication": rep.Communication,
+			"rating_timeliness":    rep.Timeliness,
+		}).Error
+}
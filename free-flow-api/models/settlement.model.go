@@ -26,6 +26,7 @@ type AssociateSettlement struct {
 	Status         string `json:"status" gorm:"default:'pending'"` // pending | partially_settled | settled
 
 	SettledAt *time.Time `json:"settled_at"`
+	PayableAt *time.Time `json:"payable_at"` // set once the task's work has been accepted
 
 	Project   Project   `json:"-" gorm:"foreignKey:ProjectID"`
 	Task      Task      `json:"-" gorm:"foreignKey:TaskID"`
@@ -39,3 +40,18 @@ func (u *AssociateSettlement) BeforeCreate(tx *gorm.DB) (err error) {
 	}
 	return nil
 }
+
+// AfterSave moves the task to paid once the associate has been settled in full
+func (u *AssociateSettlement) AfterSave(tx *gorm.DB) (err error) {
+	if u.Status != "settled" {
+		return nil
+	}
+	return PayTaskIfSettled(tx, u.TaskID)
+}
+
+// MarkSettlementPayable flags the settlement for a task as due, once
+func MarkSettlementPayable(tx *gorm.DB, taskID uuid.UUID, at time.Time) error {
+	return tx.Model(&AssociateSettlement{}).
+		Where("task_id = ? AND payable_at IS NULL", taskID).
+		UpdateColumn("payable_at", at).Error
+}
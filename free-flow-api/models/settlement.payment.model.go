@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SettlementPayment is one payment towards an associate settlement. SettledAmount on the settlement
+// is the running total; these rows say when each part of it was paid. Corrections are negative.
+type SettlementPayment struct {
+	ID        uuid.UUID `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+
+	SettlementID uuid.UUID `json:"settlement_id" gorm:"not null;index"`
+	AssociateID  uuid.UUID `json:"associate_id" gorm:"not null;index"`
+	UserID       uuid.UUID `json:"user_id" gorm:"not null;index"`
+
+	Amount         int64     `json:"amount" gorm:"not null"`
+	Method         string    `json:"method"`
+	TransactionRef string    `json:"transaction_ref"`
+	PaidAt         time.Time `json:"paid_at" gorm:"not null;index"`
+}
+
+func (p *SettlementPayment) BeforeCreate(tx *gorm.DB) (err error) {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
+// RecordSettlementPayment logs a change of amount paid on a settlement; a zero amount is skipped
+func RecordSettlementPayment(tx *gorm.DB, s AssociateSettlement, amount int64, at time.Time) error {
+	if amount == 0 {
+		return nil
+	}
+	return tx.Create(&SettlementPayment{
+		SettlementID:   s.ID,
+		AssociateID:    s.AssociateID,
+		UserID:         s.UserID,
+		Amount:         amount,
+		Method:         s.Method,
+		TransactionRef: s.TransactionRef,
+		PaidAt:         at,
+	}).Error
+}
@@ -0,0 +1,462 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Records whose status follows a transition graph
+const (
+	StatusEntityTask         = "task"
+	StatusEntityProject      = "project"
+	StatusEntityProjectPhase = "project_phase"
+	StatusEntityMilestone    = "milestone"
+	StatusEntityInvoice      = "invoice"
+)
+
+// Invoice statuses
+const (
+	InvoiceStatusDraft     = "draft"
+	InvoiceStatusSent      = "sent"
+	InvoiceStatusPaid      = "paid"
+	InvoiceStatusOverdue   = "overdue"
+	InvoiceStatusCancelled = "cancelled"
+)
+
+// Milestone statuses
+const (
+	MilestoneNotStarted = "not_started"
+	MilestoneInProgress = "in_progress"
+	MilestoneCompleted  = "completed"
+	MilestoneDelayed    = "delayed"
+	MilestoneCancelled  = "cancelled"
+)
+
+// PrincipalSystem marks changes made by the app itself, e.g. a task paid by its settlement
+const PrincipalSystem = "system"
+
+// CompletedTaskStatuses are the statuses a finished task can be in
+var CompletedTaskStatuses = []TaskStatus{TaskStatusDone, TaskStatusPaid}
+
+// statusTransitions lists, per record type, where each status may move next.
+// A status missing from the map is unknown; one mapped to nothing is final.
+var statusTransitions = map[string]map[string][]string{
+	StatusEntityTask: {
+		string(TaskStatusTodo):       {string(TaskStatusInProgress), string(TaskStatusReview), string(TaskStatusDone)},
+		string(TaskStatusInProgress): {string(TaskStatusTodo), string(TaskStatusReview), string(TaskStatusDone)},
+		string(TaskStatusReview):     {string(TaskStatusInProgress), string(TaskStatusDone)},
+		string(TaskStatusDone):       {string(TaskStatusInProgress), string(TaskStatusPaid)},
+		string(TaskStatusPaid):       {},
+	},
+	StatusEntityProject: {
+		string(ProjectStatusInquiry):   {string(ProjectStatusProposal), string(ProjectStatusActive), string(ProjectStatusCancelled)},
+		string(ProjectStatusProposal):  {string(ProjectStatusInquiry), string(ProjectStatusActive), string(ProjectStatusCancelled)},
+		string(ProjectStatusActive):    {string(ProjectStatusReview), string(ProjectStatusCompleted), string(ProjectStatusCancelled)},
+		string(ProjectStatusReview):    {string(ProjectStatusActive), string(ProjectStatusCompleted), string(ProjectStatusCancelled)},
+		string(ProjectStatusCompleted): {string(ProjectStatusActive), string(ProjectStatusPaid)},
+		string(ProjectStatusPaid):      {},
+		string(ProjectStatusCancelled): {string(ProjectStatusInquiry)},
+	},
+	// phases move forward one step at a time (design is optional) and can step back for rework
+	StatusEntityProjectPhase: {
+		string(PhaseDiscovery):   {string(PhaseDesign), string(PhaseDevelopment)},
+		string(PhaseDesign):      {string(PhaseDiscovery), string(PhaseDevelopment)},
+		string(PhaseDevelopment): {string(PhaseDesign), string(PhaseReview)},
+		string(PhaseReview):      {string(PhaseDevelopment), string(PhaseDelivery)},
+		string(PhaseDelivery):    {string(PhaseReview), string(PhasePayment)},
+		string(PhasePayment):     {string(PhaseDelivery)},
+	},
+	StatusEntityMilestone: {
+		MilestoneNotStarted: {MilestoneInProgress, MilestoneDelayed, MilestoneCompleted, MilestoneCancelled},
+		MilestoneInProgress: {MilestoneNotStarted, MilestoneDelayed, MilestoneCompleted, MilestoneCancelled},
+		MilestoneDelayed:    {MilestoneInProgress, MilestoneCompleted, MilestoneCancelled},
+		MilestoneCompleted:  {MilestoneInProgress},
+		MilestoneCancelled:  {MilestoneNotStarted},
+	},
+	// a payment can settle an invoice before it was marked sent
+	StatusEntityInvoice: {
+		InvoiceStatusDraft:     {InvoiceStatusSent, InvoiceStatusPaid, InvoiceStatusCancelled},
+		InvoiceStatusSent:      {InvoiceStatusPaid, InvoiceStatusOverdue, InvoiceStatusCancelled},
+		InvoiceStatusOverdue:   {InvoiceStatusSent, InvoiceStatusPaid, InvoiceStatusCancelled},
+		InvoiceStatusPaid:      {},
+		InvoiceStatusCancelled: {},
+	},
+}
+
+// initialStatuses are the statuses a record may be created in; later ones are only reached through
+// the transition functions, which run their side effects
+var initialStatuses = map[string][]string{
+	StatusEntityMilestone: {MilestoneNotStarted, MilestoneInProgress},
+	StatusEntityInvoice:   {InvoiceStatusDraft, InvoiceStatusSent},
+}
+
+// ErrStaleStatus is returned when a record's status changed between reading it and moving it
+var ErrStaleStatus = errors.New("status was changed by someone else, reload and try again")
+
+// TransitionError is returned for a status change the graph doesn't allow
+type TransitionError struct {
+	Entity  string
+	From    string
+	To      string
+	Allowed []string
+}
+
+func (e *TransitionError) Error() string {
+	label := strings.ReplaceAll(e.Entity, "_", " ")
+	if _, known := statusTransitions[e.Entity][e.To]; !known {
+		return fmt.Sprintf("unknown %s status %q", label, e.To)
+	}
+	if e.From == "" {
+		return fmt.Sprintf("a new %s cannot start as %s (allowed: %s)", label, e.To, strings.Join(e.Allowed, ", "))
+	}
+	if len(e.Allowed) == 0 {
+		return fmt.Sprintf("%s is %s and can no longer change", label, e.From)
+	}
+	return fmt.Sprintf("cannot move %s from %s to %s (allowed: %s)", label, e.From, e.To, strings.Join(e.Allowed, ", "))
+}
+
+// IsStatus reports whether status is a known state for the record type
+func IsStatus(entity, status string) bool {
+	_, ok := statusTransitions[entity][status]
+	return ok
+}
+
+// CheckTransition validates moving a record from one status to another; staying put is always allowed
+func CheckTransition(entity, from, to string) error {
+	graph := statusTransitions[entity]
+	if _, ok := graph[to]; !ok {
+		return &TransitionError{Entity: entity, From: from, To: to}
+	}
+	if from == to {
+		return nil
+	}
+	next, ok := graph[from]
+	if !ok {
+		// legacy rows can hold values the graph doesn't know; let them move anywhere valid
+		return nil
+	}
+	for _, s := range next {
+		if s == to {
+			return nil
+		}
+	}
+	allowed := append([]string{}, next...)
+	sort.Strings(allowed)
+	return &TransitionError{Entity: entity, From: from, To: to, Allowed: allowed}
+}
+
+// CheckInitialStatus validates the status a new record is created in
+func CheckInitialStatus(entity, status string) error {
+	if _, ok := statusTransitions[entity][status]; !ok {
+		return &TransitionError{Entity: entity, To: status}
+	}
+	allowed, restricted := initialStatuses[entity]
+	if !restricted {
+		return nil
+	}
+	for _, s := range allowed {
+		if s == status {
+			return nil
+		}
+	}
+	return &TransitionError{Entity: entity, To: status, Allowed: allowed}
+}
+
+// applyTransition writes a status change only if the record is still in the status it was read in,
+// so two concurrent requests can't both move it from the same state
+func applyTransition(tx *gorm.DB, record interface{}, column, from string, updates map[string]interface{}) error {
+	query := tx.Model(record).Where(column+" = ?", from)
+	if from == "" {
+		query = tx.Model(record).Where(column + " IS NULL OR " + column + " = ''")
+	}
+	result := query.Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrStaleStatus
+	}
+	return nil
+}
+
+// StatusActor is who made a status change
+type StatusActor struct {
+	Type string // "user", "associate", "system"
+	ID   uuid.UUID
+}
+
+var SystemActor = StatusActor{Type: PrincipalSystem}
+
+// StatusChange is an append-only record of a record moving between statuses
+type StatusChange struct {
+	ID        uuid.UUID `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+
+	ProjectID  uuid.UUID `json:"project_id" gorm:"not null;index"`
+	EntityType string    `json:"entity_type" gorm:"not null;index:idx_status_change_entity"`
+	EntityID   uuid.UUID `json:"entity_id" gorm:"not null;index:idx_status_change_entity"`
+
+	FromStatus string `json:"from_status"`
+	ToStatus   string `json:"to_status" gorm:"not null"`
+
+	ActorType string     `json:"actor_type" gorm:"not null"`
+	ActorID   *uuid.UUID `json:"actor_id"`
+}
+
+func (s *StatusChange) BeforeCreate(tx *gorm.DB) (err error) {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+// RecordStatusChange appends a row to the status history; unchanged statuses are skipped
+func RecordStatusChange(tx *gorm.DB, entity string, entityID, projectID uuid.UUID, from, to string, actor StatusActor) error {
+	if from == to {
+		return nil
+	}
+	change := StatusChange{
+		ProjectID:  projectID,
+		EntityType: entity,
+		EntityID:   entityID,
+		FromStatus: from,
+		ToStatus:   to,
+		ActorType:  actor.Type,
+	}
+	if actor.ID != uuid.Nil {
+		actorID := actor.ID
+		change.ActorID = &actorID
+	}
+	return tx.Create(&change).Error
+}
+
+// TransitionTask moves a task to a new status and runs what goes with it: timestamps,
+// stopping timers, flagging or paying the associate's settlement, and notifying the associate.
+// TaskStatusPaid is only reached through a settled settlement.
+func TransitionTask(tx *gorm.DB, task *Task, to TaskStatus, actor StatusActor) error {
+	if task.Status == to {
+		return nil
+	}
+	if err := CheckTransition(StatusEntityTask, string(task.Status), string(to)); err != nil {
+		return err
+	}
+	return applyTaskStatus(tx, task, to, actor)
+}
+
+// applyTaskStatus moves a task to a new status with the dates, history and side effects that go
+// with it. Callers check the transition graph first, except roll-ups deriving a parent's status.
+func applyTaskStatus(tx *gorm.DB, task *Task, to TaskStatus, actor StatusActor) error {
+	from := task.Status
+	now := time.Now()
+	updates := map[string]interface{}{"status": to}
+	switch to {
+	case TaskStatusInProgress:
+		if task.StartDate == nil {
+			updates["start_date"] = now
+		}
+		updates["completed_at"] = nil
+	case TaskStatusTodo, TaskStatusReview:
+		updates["completed_at"] = nil
+	case TaskStatusDone:
+		updates["completed_at"] = now
+	}
+	if err := applyTransition(tx, task, "status", string(from), updates); err != nil {
+		return err
+	}
+	if err := RecordStatusChange(tx, StatusEntityTask, task.ID, task.ProjectID, string(from), string(to), actor); err != nil {
+		return err
+	}
+
+	switch {
+	case to == TaskStatusDone:
+		// actual hours come from time entries; close any timers still running
+		if err := StopRunningTimers(tx, task.ID, now); err != nil {
+			return err
+		}
+		if err := MarkSettlementPayable(tx, task.ID, now); err != nil {
+			return err
+		}
+		// the associate may already have been paid in full
+		return PayTaskIfSettled(tx, task.ID)
+	case to == TaskStatusPaid:
+		return notifyTaskAssociate(tx, task, "Payment settled for "+task.Title)
+	case from == TaskStatusDone:
+		return notifyTaskAssociate(tx, task, task.Title+" was reopened")
+	}
+	return nil
+}
+
+// PayTaskIfSettled moves a finished task to paid once its associate settlement is fully settled
+func PayTaskIfSettled(tx *gorm.DB, taskID uuid.UUID) error {
+	if taskID == uuid.Nil {
+		return nil
+	}
+	var task Task
+	if err := tx.First(&task, "id = ?", taskID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if task.Status != TaskStatusDone {
+		return nil
+	}
+
+	var open int64
+	if err := tx.Model(&AssociateSettlement{}).
+		Where("task_id = ? AND status <> ?", taskID, "settled").
+		Count(&open).Error; err != nil {
+		return err
+	}
+	var settled int64
+	if err := tx.Model(&AssociateSettlement{}).
+		Where("task_id = ? AND status = ?", taskID, "settled").
+		Count(&settled).Error; err != nil {
+		return err
+	}
+	if open > 0 || settled == 0 {
+		return nil
+	}
+	return TransitionTask(tx, &task, TaskStatusPaid, SystemActor)
+}
+
+func notifyTaskAssociate(tx *gorm.DB, task *Task, title string) error {
+	if task.AssignedToAssociate == nil {
+		return nil
+	}
+	taskID := task.ID
+	return tx.Create(&Notification{
+		RecipientType: PrincipalAssociate,
+		RecipientID:   *task.AssignedToAssociate,
+		Kind:          NotificationStatusChanged,
+		Title:         title,
+		TargetType:    StatusEntityTask,
+		TargetID:      &taskID,
+	}).Error
+}
+
+// TransitionProject moves a project to a new status, keeping its start and completion dates in step.
+// Associates with work on a cancelled project are told about it.
+func TransitionProject(tx *gorm.DB, project *Project, to ProjectStatus, actor StatusActor) error {
+	from := project.Status
+	if from == to {
+		return nil
+	}
+	if err := CheckTransition(StatusEntityProject, string(from), string(to)); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{"status": to}
+	switch to {
+	case ProjectStatusActive:
+		if project.StartDate == nil {
+			updates["start_date"] = now
+		}
+		updates["completed_at"] = nil
+	case ProjectStatusCompleted:
+		updates["completed_at"] = now
+	}
+	if err := applyTransition(tx, project, "status", string(from), updates); err != nil {
+		return err
+	}
+	if err := RecordStatusChange(tx, StatusEntityProject, project.ID, project.ID, string(from), string(to), actor); err != nil {
+		return err
+	}
+
+	if to != ProjectStatusCancelled {
+		return nil
+	}
+	var associateIDs []uuid.UUID
+	if err := tx.Model(&Task{}).
+		Where("project_id = ? AND assigned_to_associate IS NOT NULL", project.ID).
+		Distinct().
+		Pluck("assigned_to_associate", &associateIDs).Error; err != nil {
+		return err
+	}
+	for _, associateID := range associateIDs {
+		projectID := project.ID
+		if err := tx.Create(&Notification{
+			RecipientType: PrincipalAssociate,
+			RecipientID:   associateID,
+			Kind:          NotificationStatusChanged,
+			Title:         project.Name + " was cancelled",
+			TargetType:    StatusEntityProject,
+			TargetID:      &projectID,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TransitionProjectPhase moves a project to another phase
+func TransitionProjectPhase(tx *gorm.DB, project *Project, to ProjectPhase, actor StatusActor) error {
+	from := project.CurrentPhase
+	if from == to {
+		return nil
+	}
+	if err := CheckTransition(StatusEntityProjectPhase, string(from), string(to)); err != nil {
+		return err
+	}
+	if err := applyTransition(tx, project, "current_phase", string(from), map[string]interface{}{"current_phase": to}); err != nil {
+		return err
+	}
+	return RecordStatusChange(tx, StatusEntityProjectPhase, project.ID, project.ID, string(from), string(to), actor)
+}
+
+// TransitionMilestone moves a milestone to a new status, keeping its start and completion dates in step
+func TransitionMilestone(tx *gorm.DB, milestone *Milestone, to string, actor StatusActor) error {
+	from := milestone.Status
+	if from == to {
+		return nil
+	}
+	if err := CheckTransition(StatusEntityMilestone, from, to); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{"status": to}
+	switch to {
+	case MilestoneInProgress:
+		if milestone.StartDate == nil {
+			updates["start_date"] = now
+		}
+		updates["completed_date"] = nil
+	case MilestoneCompleted:
+		updates["completed_date"] = now
+	default:
+		updates["completed_date"] = nil
+	}
+	if err := applyTransition(tx, milestone, "status", from, updates); err != nil {
+		return err
+	}
+	return RecordStatusChange(tx, StatusEntityMilestone, milestone.ID, milestone.ProjectID, from, to, actor)
+}
+
+// TransitionInvoice moves an invoice to a new status. Paying stamps the paid date; the
+// invoice's AfterSave hook keeps the milestone's billing status and billed work in step.
+func TransitionInvoice(tx *gorm.DB, invoice *Invoice, to string, actor StatusActor) error {
+	from := invoice.Status
+	if from == to {
+		return nil
+	}
+	if err := CheckTransition(StatusEntityInvoice, from, to); err != nil {
+		return err
+	}
+
+	updates := map[string]interface{}{"status": to}
+	if to == InvoiceStatusPaid && invoice.PaidDate == nil {
+		updates["paid_date"] = time.Now()
+	}
+	if err := applyTransition(tx, invoice, "status", from, updates); err != nil {
+		return err
+	}
+	return RecordStatusChange(tx, StatusEntityInvoice, invoice.ID, invoice.ProjectID, from, to, actor)
+}
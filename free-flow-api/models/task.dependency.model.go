@@ -0,0 +1,75 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrDependencyCycle = errors.New("dependency would create a cycle")
+
+// TaskDependency is a finish-to-start link: the successor can start once the predecessor
+// has finished, plus an optional lag in working hours.
+type TaskDependency struct {
+	ID        uuid.UUID      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	ProjectID     uuid.UUID `json:"project_id" gorm:"not null;index"`
+	PredecessorID uuid.UUID `json:"predecessor_id" gorm:"not null;index"`
+	SuccessorID   uuid.UUID `json:"successor_id" gorm:"not null;index"`
+	LagHours      float64   `json:"lag_hours" gorm:"default:0"`
+
+	Predecessor *Task `json:"predecessor,omitempty" gorm:"foreignKey:PredecessorID"`
+	Successor   *Task `json:"successor,omitempty" gorm:"foreignKey:SuccessorID"`
+}
+
+func (d *TaskDependency) BeforeCreate(tx *gorm.DB) (err error) {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return nil
+}
+
+// DependencyCreatesCycle reports whether linking predecessor -> successor would close a loop,
+// i.e. whether the predecessor is already reachable from the successor.
+func DependencyCreatesCycle(tx *gorm.DB, projectID, predecessorID, successorID uuid.UUID) (bool, error) {
+	if predecessorID == successorID {
+		return true, nil
+	}
+
+	var deps []TaskDependency
+	if err := tx.Where("project_id = ?", projectID).Find(&deps).Error; err != nil {
+		return false, err
+	}
+
+	next := make(map[uuid.UUID][]uuid.UUID)
+	for _, d := range deps {
+		next[d.PredecessorID] = append(next[d.PredecessorID], d.SuccessorID)
+	}
+
+	seen := map[uuid.UUID]bool{successorID: true}
+	stack := []uuid.UUID{successorID}
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if id == predecessorID {
+			return true, nil
+		}
+		for _, n := range next[id] {
+			if !seen[n] {
+				seen[n] = true
+				stack = append(stack, n)
+			}
+		}
+	}
+	return false, nil
+}
+
+// deleteTaskDependencies drops every link to or from a removed task
+func deleteTaskDependencies(tx *gorm.DB, taskID uuid.UUID) error {
+	return tx.Where("predecessor_id = ? OR successor_id = ?", taskID, taskID).Delete(&TaskDependency{}).Error
+}
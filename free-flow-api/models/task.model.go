@@ -1,6 +1,7 @@
 package models
 
 import (
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
@@ -26,6 +27,7 @@ type Task struct {
 
 	MilestoneID *uuid.UUID `json:"milestone_id" gorm:"index"`
 	ProjectID   uuid.UUID  `json:"project_id" gorm:"not null"`
+	ParentID    *uuid.UUID `json:"parent_id" gorm:"index"` // set on subtasks
 	Title       string     `json:"title" gorm:"not null"`
 
 	Description string     `json:"description"`
@@ -49,10 +51,12 @@ type Task struct {
 	CreatedBy           uuid.UUID  `json:"created_by" gorm:"not null"`
 
 	// Relationships
-	Milestone  *Milestone `json:"milestone,omitempty" gorm:"foreignKey:MilestoneID"`
-	Project    Project    `json:"project" gorm:"foreignKey:ProjectID"`
-	Associate  *Associate `json:"assigned_associate" gorm:"foreignKey:AssignedToAssociate"`
-	Freelancer *User      `json:"freelancer" gorm:"foreignKey:CreatedBy"`
+	Milestone  *Milestone      `json:"milestone,omitempty" gorm:"foreignKey:MilestoneID"`
+	Project    Project         `json:"project" gorm:"foreignKey:ProjectID"`
+	Associate  *Associate      `json:"assigned_associate" gorm:"foreignKey:AssignedToAssociate"`
+	Freelancer *User           `json:"freelancer" gorm:"foreignKey:CreatedBy"`
+	Subtasks   []Task          `json:"subtasks,omitempty" gorm:"foreignKey:ParentID"`
+	Checklist  []ChecklistItem `json:"checklist,omitempty" gorm:"foreignKey:TaskID"`
 }
 
 func (u *Task) BeforeCreate(tx *gorm.DB) (err error) {
@@ -63,6 +67,11 @@ func (u *Task) BeforeCreate(tx *gorm.DB) (err error) {
 }
 
 func (t *Task) AfterSave(tx *gorm.DB) (err error) {
+	if t.ParentID != nil {
+		if err := RollUpTask(tx, *t.ParentID); err != nil {
+			return err
+		}
+	}
 	if err := updateProjectStats(tx, t.ProjectID); err != nil {
 		return err
 	}
@@ -75,6 +84,20 @@ func (t *Task) AfterSave(tx *gorm.DB) (err error) {
 }
 
 func (t *Task) AfterDelete(tx *gorm.DB) (err error) {
+	if err := deleteTaskDependencies(tx, t.ID); err != nil {
+		return err
+	}
+	if err := deleteSubtasks(tx, t.ID); err != nil {
+		return err
+	}
+	if err := tx.Where("task_id = ?", t.ID).Delete(&ChecklistItem{}).Error; err != nil {
+		return err
+	}
+	if t.ParentID != nil {
+		if err := RollUpTask(tx, *t.ParentID); err != nil {
+			return err
+		}
+	}
 	if err := updateProjectStats(tx, t.ProjectID); err != nil {
 		return err
 	}
@@ -86,11 +109,15 @@ func (t *Task) AfterDelete(tx *gorm.DB) (err error) {
 	return nil
 }
 
+// leafTasks limits stats to tasks without subtasks, since a parent's figures are rolled up from its children
+const leafTasks = "NOT EXISTS (SELECT 1 FROM tasks AS sub WHERE sub.parent_id = tasks.id AND sub.deleted_at IS NULL)"
+
 func updateProjectStats(tx *gorm.DB, projectID uuid.UUID) error {
 	// 1. Calculate total task value
 	var totalValue float64
 	if err := tx.Model(&Task{}).
 		Where("project_id = ?", projectID).
+		Where(leafTasks).
 		Select("COALESCE(SUM(task_value), 0)").
 		Scan(&totalValue).Error; err != nil {
 		return err
@@ -100,14 +127,16 @@ func updateProjectStats(tx *gorm.DB, projectID uuid.UUID) error {
 	var totalTasks int64
 	if err := tx.Model(&Task{}).
 		Where("project_id = ?", projectID).
+		Where(leafTasks).
 		Count(&totalTasks).Error; err != nil {
 		return err
 	}
 
-	// 3. Count completed tasks (assuming "done" is the completed status)
+	// 3. Count completed tasks; paid tasks are done tasks whose associate has been settled
 	var doneTasks int64
 	if err := tx.Model(&Task{}).
-		Where("project_id = ? AND status = ?", projectID, "done").
+		Where("project_id = ? AND status IN ?", projectID, CompletedTaskStatuses).
+		Where(leafTasks).
 		Count(&doneTasks).Error; err != nil {
 		return err
 	}
@@ -131,12 +160,14 @@ func updateMilestoneStats(tx *gorm.DB, milestoneID uuid.UUID) error {
 
 	if err := tx.Model(&Task{}).
 		Where("milestone_id = ?", milestoneID).
+		Where(leafTasks).
 		Count(&totalTasks).Error; err != nil {
 		return err
 	}
 
 	if err := tx.Model(&Task{}).
-		Where("milestone_id = ? AND status = ?", milestoneID, "done").
+		Where("milestone_id = ? AND status IN ?", milestoneID, CompletedTaskStatuses).
+		Where(leafTasks).
 		Count(&completedTasks).Error; err != nil {
 		return err
 	}
@@ -154,3 +185,86 @@ func updateMilestoneStats(tx *gorm.DB, milestoneID uuid.UUID) error {
 			"completed_tasks": completedTasks,
 		}).Error
 }
+
+// RollUpTask derives a parent task's hours, value and status from its subtasks, then
+// carries on up the hierarchy. Hours and value are written directly so the parent's own hooks
+// don't fire; a status change goes through applyTaskStatus like any other task's.
+func RollUpTask(tx *gorm.DB, parentID uuid.UUID) error {
+	var parent Task
+	if err := tx.First(&parent, "id = ?", parentID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	var children []Task
+	if err := tx.Where("parent_id = ?", parentID).Find(&children).Error; err != nil {
+		return err
+	}
+	if len(children) == 0 {
+		return nil
+	}
+
+	var hours, value float64
+	hasValue := false
+	finished, started := 0, 0
+	for _, ch := range children {
+		hours += ch.EstimatedHours
+		if ch.TaskValue != nil {
+			value += *ch.TaskValue
+			hasValue = true
+		}
+		switch ch.Status {
+		case TaskStatusDone, TaskStatusPaid:
+			finished++
+		case TaskStatusInProgress, TaskStatusReview:
+			started++
+		}
+	}
+
+	updates := map[string]interface{}{"estimated_hours": hours}
+	if hasValue {
+		updates["task_value"] = value
+	}
+	if err := tx.Model(&Task{}).Where("id = ?", parent.ID).UpdateColumns(updates).Error; err != nil {
+		return err
+	}
+
+	// a paid parent has been settled and stays that way
+	if parent.Status != TaskStatusPaid {
+		status := TaskStatusTodo
+		switch {
+		case finished == len(children):
+			status = TaskStatusDone
+		case finished > 0 || started > 0:
+			status = TaskStatusInProgress
+		}
+		// derived, so applied without the transition graph but with the same side effects,
+		// e.g. a finished parent's settlement becomes payable; its save hook rolls up further
+		if status != parent.Status {
+			return applyTaskStatus(tx, &parent, status, SystemActor)
+		}
+	}
+
+	if parent.ParentID != nil {
+		return RollUpTask(tx, *parent.ParentID)
+	}
+	return nil
+}
+
+// deleteSubtasks removes a task's children one by one so their own hooks clean up below them
+func deleteSubtasks(tx *gorm.DB, parentID uuid.UUID) error {
+	var children []Task
+	if err := tx.Where("parent_id = ?", parentID).Find(&children).Error; err != nil {
+		return err
+	}
+	for i := range children {
+		// the parent is going away, so there is nothing to roll up into
+		children[i].ParentID = nil
+		if err := tx.Delete(&children[i]).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
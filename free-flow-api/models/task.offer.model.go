@@ -0,0 +1,76 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	OfferModeFirstAccept = "first_accept" // first associate to accept is assigned
+	OfferModeBid         = "bid"          // freelancer picks from submitted bids
+)
+
+// TaskOffer broadcasts a task and its contract to a shortlist of associates
+type TaskOffer struct {
+	ID        uuid.UUID      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	TaskID     uuid.UUID `json:"task_id" gorm:"not null;index"`
+	ProjectID  uuid.UUID `json:"project_id" gorm:"not null"`
+	ContractID uuid.UUID `json:"contract_id" gorm:"not null"`
+	UserID     uuid.UUID `json:"user_id" gorm:"not null"`
+
+	Mode    string `json:"mode" gorm:"default:'first_accept'"` // "first_accept", "bid"
+	Status  string `json:"status" gorm:"default:'open'"`       // "open", "awarded", "cancelled"
+	Message string `json:"message"`
+
+	AwardedTo    *uuid.UUID `json:"awarded_to"` // associate id
+	AwardedBidID *uuid.UUID `json:"awarded_bid_id"`
+	AwardedAt    *time.Time `json:"awarded_at"`
+
+	Task    Task       `json:"-" gorm:"foreignKey:TaskID"`
+	Invites []Invite   `json:"invites,omitempty" gorm:"foreignKey:OfferID"`
+	Bids    []OfferBid `json:"bids,omitempty" gorm:"foreignKey:OfferID"`
+}
+
+// OfferBid is an associate's response to an offer, either at the listed terms or a counter-offer
+type OfferBid struct {
+	ID        uuid.UUID `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	OfferID     uuid.UUID `json:"offer_id" gorm:"not null;uniqueIndex:idx_offer_bid_invite"`
+	InviteID    uuid.UUID `json:"invite_id" gorm:"not null;uniqueIndex:idx_offer_bid_invite"`
+	AssociateID uuid.UUID `json:"associate_id" gorm:"not null"`
+
+	Price          *float64   `json:"price"` // nil accepts the listed task value
+	EstimatedHours *float64   `json:"estimated_hours"`
+	DeliveryDate   *time.Time `json:"delivery_date"`
+	Message        string     `json:"message"`
+	Status         string     `json:"status" gorm:"default:'submitted'"` // "submitted", "won", "lost"
+
+	Associate Associate `json:"associate" gorm:"foreignKey:AssociateID"`
+}
+
+func (o *TaskOffer) BeforeCreate(tx *gorm.DB) (err error) {
+	if o.ID == uuid.Nil {
+		o.ID = uuid.New()
+	}
+	return nil
+}
+
+func (b *OfferBid) BeforeCreate(tx *gorm.DB) (err error) {
+	if b.ID == uuid.Nil {
+		b.ID = uuid.New()
+	}
+	return nil
+}
+
+// IsCounter reports whether the bid changes the listed terms
+func (b OfferBid) IsCounter() bool {
+	return b.Price != nil || b.EstimatedHours != nil || b.DeliveryDate != nil
+}
@@ -0,0 +1,111 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrTimeEntryRange = errors.New("time entry must end after it starts")
+
+// TimeEntry is a span of work on a task, either timed live or entered by hand.
+// Entries without EndedAt are running timers and do not count towards the task's hours yet.
+type TimeEntry struct {
+	ID        uuid.UUID      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	TaskID    uuid.UUID `json:"task_id" gorm:"not null;index"`
+	ProjectID uuid.UUID `json:"project_id" gorm:"not null;index"`
+
+	// Owner is whoever did the work: the freelancer or an associate
+	OwnerType string    `json:"owner_type" gorm:"not null"` // "user", "associate"
+	OwnerID   uuid.UUID `json:"owner_id" gorm:"not null;index"`
+
+	StartedAt time.Time  `json:"started_at" gorm:"not null;index"`
+	EndedAt   *time.Time `json:"ended_at"`
+	Hours     float64    `json:"hours" gorm:"default:0"`
+
+	Manual   bool   `json:"manual" gorm:"default:false"`
+	Billable bool   `json:"billable" gorm:"default:true"`
+	Notes    string `json:"notes"`
+
+	// Set once the entry has been put on an invoice
+	InvoiceID *uuid.UUID `json:"invoice_id" gorm:"index"`
+	BilledAt  *time.Time `json:"billed_at"`
+
+	Task *Task `json:"task,omitempty" gorm:"foreignKey:TaskID"`
+}
+
+func (e *TimeEntry) BeforeCreate(tx *gorm.DB) (err error) {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+// BeforeSave derives Hours from the start and end of a finished entry
+func (e *TimeEntry) BeforeSave(tx *gorm.DB) (err error) {
+	if e.EndedAt == nil {
+		e.Hours = 0
+		return nil
+	}
+	if !e.EndedAt.After(e.StartedAt) {
+		return ErrTimeEntryRange
+	}
+	e.Hours = e.EndedAt.Sub(e.StartedAt).Hours()
+	return nil
+}
+
+func (e *TimeEntry) AfterSave(tx *gorm.DB) (err error) {
+	return syncTaskActualHours(tx, e.TaskID)
+}
+
+func (e *TimeEntry) AfterDelete(tx *gorm.DB) (err error) {
+	return syncTaskActualHours(tx, e.TaskID)
+}
+
+// IsBilled reports whether the entry is already on an invoice
+func (e *TimeEntry) IsBilled() bool {
+	return e.InvoiceID != nil
+}
+
+// IsRunning reports whether the entry is a live timer
+func (e *TimeEntry) IsRunning() bool {
+	return e.EndedAt == nil
+}
+
+// syncTaskActualHours sets the task's ActualHours to the sum of its finished time entries
+func syncTaskActualHours(tx *gorm.DB, taskID uuid.UUID) error {
+	var hours float64
+	if err := tx.Model(&TimeEntry{}).
+		Where("task_id = ? AND ended_at IS NOT NULL", taskID).
+		Select("COALESCE(SUM(hours), 0)").
+		Scan(&hours).Error; err != nil {
+		return err
+	}
+
+	return tx.Model(&Task{}).Where("id = ?", taskID).UpdateColumn("actual_hours", hours).Error
+}
+
+// StopRunningTimers ends every running timer on a task, e.g. when the task is completed
+func StopRunningTimers(tx *gorm.DB, taskID uuid.UUID, at time.Time) error {
+	var running []TimeEntry
+	if err := tx.Where("task_id = ? AND ended_at IS NULL", taskID).Find(&running).Error; err != nil {
+		return err
+	}
+	for i := range running {
+		end := at
+		if !end.After(running[i].StartedAt) {
+			end = running[i].StartedAt.Add(time.Second)
+		}
+		running[i].EndedAt = &end
+		if err := tx.Save(&running[i]).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -14,8 +14,12 @@ type User struct {
 	LastName  string    `json:"lastname" gorm:"size:100;not null"`
 	Email     string    `json:"email" gorm:"size:255;uniqueIndex;not null"`
 	Password  string    `json:"-" gorm:"size:255;not null"`
+	IsAdmin   bool      `json:"is_admin" gorm:"default:false"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated-at"`
+
+	SuspendedAt     *time.Time `json:"suspended_at"`
+	SuspendedReason *string    `json:"suspended_reason"`
 }
 
 func (u *User) BeforeCreate(tx *gorm.DB) (err error) {
@@ -0,0 +1,135 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	VerificationBasic    = "basic"
+	VerificationAdvanced = "advanced"
+	VerificationKYC      = "kyc"
+
+	// MarketplaceVerificationLevel is required before an associate can work on the marketplace
+	MarketplaceVerificationLevel = VerificationAdvanced
+	// PayoutVerificationLevel is required before settlements can be paid out to an associate
+	PayoutVerificationLevel = VerificationKYC
+)
+
+var verificationRanks = map[string]int{
+	VerificationBasic:    0,
+	VerificationAdvanced: 1,
+	VerificationKYC:      2,
+}
+
+// requiredDocuments lists the document kinds needed to reach each level
+var requiredDocuments = map[string][]string{
+	VerificationAdvanced: {"id_front"},
+	VerificationKYC:      {"id_front", "selfie", "proof_of_address"},
+}
+
+// VerificationRequest is an associate's submission to move up a verification level
+type VerificationRequest struct {
+	ID        uuid.UUID      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	AssociateID    uuid.UUID `json:"associate_id" gorm:"not null;index"`
+	RequestedLevel string    `json:"requested_level" gorm:"not null"` // "advanced", "kyc"
+	Status         string    `json:"status" gorm:"default:'pending'"` // "pending", "approved", "rejected"
+
+	// Identity
+	DocumentType   string `json:"document_type"` // "passport", "national_id", "drivers_license"
+	DocumentNumber string `json:"-" audit:"-"`
+	DocumentLast4  string `json:"document_number_last4" gorm:"-"`
+
+	// Payout details
+	PayoutMethod        string `json:"payout_method"` // "bank", "mobile_money", "paypal"
+	PayoutProvider      string `json:"payout_provider"`
+	PayoutAccountName   string `json:"payout_account_name"`
+	PayoutAccountNumber string `json:"-" audit:"-"`
+	PayoutAccountLast4  string `json:"payout_account_last4" gorm:"-"`
+
+	// Review
+	ReviewedBy  *uuid.UUID `json:"reviewed_by"`
+	ReviewedAt  *time.Time `json:"reviewed_at"`
+	ReviewNotes *string    `json:"review_notes"`
+
+	Documents []VerificationDocument `json:"documents" gorm:"foreignKey:RequestID;constraint:OnDelete:CASCADE"`
+	Associate Associate              `json:"-" gorm:"foreignKey:AssociateID"`
+}
+
+// VerificationDocument is an uploaded file attached to a verification request
+type VerificationDocument struct {
+	ID        uuid.UUID `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+
+	RequestID uuid.UUID `json:"request_id" gorm:"not null;index"`
+	Kind      string    `json:"kind"` // "id_front", "id_back", "selfie", "proof_of_address"
+	FileName  string    `json:"file_name"`
+	Path      string    `json:"-"`
+	MimeType  string    `json:"mime_type"`
+	Size      int64     `json:"size"`
+}
+
+func (r *VerificationRequest) BeforeCreate(tx *gorm.DB) (err error) {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+// AfterFind masks the document and account numbers down to what responses may show
+func (r *VerificationRequest) AfterFind(tx *gorm.DB) (err error) {
+	r.DocumentLast4 = Last4(r.DocumentNumber)
+	r.PayoutAccountLast4 = Last4(r.PayoutAccountNumber)
+	return nil
+}
+
+func (r *VerificationRequest) AfterSave(tx *gorm.DB) (err error) {
+	return r.AfterFind(tx)
+}
+
+func (d *VerificationDocument) BeforeCreate(tx *gorm.DB) (err error) {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return nil
+}
+
+// Last4 keeps the last four characters of an identifier for display
+func Last4(number string) string {
+	runes := []rune(strings.TrimSpace(number))
+	if len(runes) <= 4 {
+		return string(runes)
+	}
+	return string(runes[len(runes)-4:])
+}
+
+// NextVerificationLevel returns the level after the given one, or "" at the top
+func NextVerificationLevel(level string) string {
+	switch level {
+	case "", VerificationBasic:
+		return VerificationAdvanced
+	case VerificationAdvanced:
+		return VerificationKYC
+	}
+	return ""
+}
+
+// RequiredDocuments lists the document kinds needed for a level
+func RequiredDocuments(level string) []string {
+	return requiredDocuments[level]
+}
+
+// HasVerificationLevel reports whether the profile is verified at or above the level
+func (p AssociateProfile) HasVerificationLevel(level string) bool {
+	if verificationRanks[level] == 0 {
+		return true
+	}
+	return p.IsVerified && verificationRanks[p.VerificationLevel] >= verificationRanks[level]
+}
@@ -0,0 +1,40 @@
+package routes
+
+import (
+	"free-flow-api/controllers"
+	"free-flow-api/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterAdminRouter(rg *gin.RouterGroup) {
+	admin := rg.Group("/admin")
+	admin.Use(middleware.VerifyToken(), middleware.VerifyAdmin())
+	{
+		admin.GET("/metrics", controllers.GetSystemMetrics)
+		admin.GET("/audit", controllers.GetAdminAuditLog)
+		admin.POST("/impersonate", controllers.Impersonate)
+
+		// accounts
+		admin.GET("/users", controllers.SearchUsers)
+		admin.GET("/users/:id", controllers.GetAdminUser)
+		admin.POST("/users/:id/suspend", controllers.SuspendUser)
+		admin.POST("/users/:id/unsuspend", controllers.UnsuspendUser)
+		admin.GET("/associates", controllers.SearchAssociates)
+		admin.GET("/associates/:id", controllers.GetAdminAssociate)
+		admin.POST("/associates/:id/suspend", controllers.SuspendAssociate)
+		admin.POST("/associates/:id/unsuspend", controllers.UnsuspendAssociate)
+		admin.GET("/invites/:id", controllers.GetAdminInvite)
+
+		// marketplace
+		admin.GET("/marketplace", controllers.GetMarketplaceQueue)
+		admin.PUT("/marketplace/:id/status", controllers.UpdateMarketplaceStatus)
+
+		// verification
+		admin.GET("/verification", controllers.GetVerificationQueue)
+		admin.GET("/verification/:id", controllers.GetVerificationRequest)
+		admin.POST("/verification/:id/approve", controllers.ApproveVerification)
+		admin.POST("/verification/:id/reject", controllers.RejectVerification)
+		admin.GET("/verification/document/:id", controllers.GetVerificationDocument)
+	}
+}
@@ -14,6 +14,7 @@ func RegisterAssociateRouter(rg *gin.RouterGroup) {
 		associate.POST("/", controllers.NewAssociate)
 		associate.GET("/", controllers.GetAllAssociates)
 		associate.GET("/u", controllers.GetAllAssociatesByUserID)
+		associate.POST("/match", controllers.MatchAssociates)
 		associate.GET("/:id", controllers.GetAssociateByID)
 		associate.DELETE("/:id", controllers.DeleteAssociate)
 		associate.PUT("/:id", controllers.UpdateAssociate)
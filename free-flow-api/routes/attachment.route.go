@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"free-flow-api/controllers"
+	"free-flow-api/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterAttachmentRouter(rg *gin.RouterGroup) {
+	attachments := rg.Group("/attachments")
+	attachments.Use(middleware.VerifyToken())
+	{
+		attachments.POST("/", controllers.UploadAttachment)
+		attachments.GET("/", controllers.GetAttachments)
+		attachments.GET("/:id/url", controllers.GetAttachmentURL)
+		attachments.DELETE("/:id", controllers.DeleteAttachment)
+	}
+
+	// signed download links carry their own authorisation
+	rg.GET("/files/:token", controllers.DownloadAttachment)
+}
@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"free-flow-api/controllers"
+	"free-flow-api/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterAuditRouter(rg *gin.RouterGroup) {
+	audit := rg.Group("/audit")
+	audit.Use(middleware.VerifyToken())
+	{
+		audit.GET("/:recordType/:id", controllers.GetRecordHistory)
+	}
+}
@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"free-flow-api/controllers"
+	"free-flow-api/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterBillingRouter(rg *gin.RouterGroup) {
+	billing := rg.Group("/billing")
+	billing.Use(middleware.VerifyToken())
+	{
+		billing.GET("/rates", controllers.GetBillingRates)
+		billing.PUT("/rates", controllers.SetBillingRate)
+		billing.DELETE("/rates/:id", controllers.DeleteBillingRate)
+		billing.GET("/unbilled", controllers.GetUnbilledWork)
+		billing.POST("/invoices", controllers.GenerateBillingInvoice)
+	}
+}
@@ -0,0 +1,39 @@
+package routes
+
+import (
+	"free-flow-api/controllers"
+	"free-flow-api/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterCommentRouter(rg *gin.RouterGroup) {
+	comments := rg.Group("/comments")
+	comments.Use(middleware.VerifyToken())
+	{
+		comments.GET("/", controllers.GetComments)
+		comments.POST("/", controllers.CreateComment)
+		comments.PUT("/:id", controllers.UpdateComment)
+		comments.DELETE("/:id", controllers.DeleteComment)
+		comments.GET("/:id/history", controllers.GetCommentHistory)
+	}
+
+	notifications := rg.Group("/notifications")
+	notifications.Use(middleware.VerifyToken())
+	{
+		notifications.GET("/", controllers.GetNotifications)
+		notifications.POST("/read", controllers.MarkAllNotificationsRead)
+		notifications.POST("/:id/read", controllers.MarkNotificationRead)
+	}
+
+	// client discussion link
+	client := rg.Group("/client/project/:token/comments")
+	client.Use(middleware.VerifyClient())
+	{
+		client.GET("/", controllers.GetComments)
+		client.POST("/", controllers.CreateComment)
+		client.PUT("/:id", controllers.UpdateComment)
+		client.DELETE("/:id", controllers.DeleteComment)
+		client.GET("/:id/history", controllers.GetCommentHistory)
+	}
+}
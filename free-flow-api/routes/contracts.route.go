@@ -18,5 +18,35 @@ func RegisterContractRouter(rg *gin.RouterGroup) {
 		contract.GET("/p/:project_id", controllers.GetContractsByProjectID)
 		contract.PUT("/:id", controllers.UpdateContract)
 		contract.DELETE("/:id", controllers.DeleteContract)
+		contract.POST("/:id/amend", controllers.AmendContract)
+		contract.GET("/:id/versions", controllers.GetContractVersions)
+		contract.GET("/:id/versions/:version", controllers.GetContractVersion)
+		contract.GET("/:id/diff", controllers.DiffContractVersions)
+		contract.GET("/:id/signatures", controllers.GetContractSignatures)
+	}
+
+	// public so third parties can check a signature; it only returns hashes
+	signature := rg.Group("/signature")
+	{
+		signature.GET("/:id/verify", controllers.VerifyContractSignature)
+	}
+
+	// the certificate carries the signer's IP, user agent and the full contract text
+	certificate := rg.Group("/signature")
+	certificate.Use(middleware.VerifyToken())
+	{
+		certificate.GET("/:id/certificate", controllers.GetSignatureCertificate)
+	}
+
+	template := rg.Group("/contract-template")
+	template.Use(middleware.VerifyToken())
+	{
+		template.POST("/", controllers.CreateContractTemplate)
+		template.GET("/", controllers.GetContractTemplates)
+		template.GET("/placeholders", controllers.GetContractTemplatePlaceholders)
+		template.GET("/:id", controllers.GetContractTemplateByID)
+		template.PUT("/:id", controllers.UpdateContractTemplate)
+		template.DELETE("/:id", controllers.DeleteContractTemplate)
+		template.POST("/:id/instantiate", controllers.InstantiateContractTemplate)
 	}
 }
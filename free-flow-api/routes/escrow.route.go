@@ -0,0 +1,29 @@
+package routes
+
+import (
+	"free-flow-api/controllers"
+	"free-flow-api/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterEscrowRouter(rg *gin.RouterGroup) {
+	escrow := rg.Group("/escrow")
+	escrow.Use(middleware.VerifyToken())
+	{
+		escrow.GET("/milestone/:id", controllers.GetMilestoneEscrow)
+		escrow.POST("/milestone/:id/fund", controllers.FundMilestoneEscrow)
+		escrow.POST("/milestone/:id/release", controllers.ReleaseMilestoneEscrow)
+		escrow.POST("/milestone/:id/refund", controllers.RefundMilestoneEscrow)
+	}
+
+	// service-to-service access for the SafeCollab service
+	safecollab := rg.Group("/safecollab/escrow")
+	safecollab.Use(middleware.VerifySafeCollabKey())
+	{
+		safecollab.GET("/milestone/:id", controllers.GetMilestoneEscrow)
+		safecollab.POST("/milestone/:id/fund", controllers.FundMilestoneEscrow)
+		safecollab.POST("/milestone/:id/release", controllers.ReleaseMilestoneEscrow)
+		safecollab.POST("/milestone/:id/refund", controllers.RefundMilestoneEscrow)
+	}
+}
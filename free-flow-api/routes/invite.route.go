@@ -13,6 +13,14 @@ func RegisterInviteRouter(rg *gin.RouterGroup) {
 	{
 		invite.GET("/:token", controllers.GetInviteDetails)
 		invite.POST("/response/:token", controllers.InviteResponse)
+		invite.POST("/bid/:token", controllers.SubmitOfferBid)
+	}
+
+	manage := rg.Group("/invite")
+	manage.Use(middleware.VerifyToken())
+	{
+		manage.POST("/:id/resend", controllers.ResendInvite)
+		manage.POST("/:id/revoke", controllers.RevokeInvite)
 	}
 
 	user_view := rg.Group("/project/:id")
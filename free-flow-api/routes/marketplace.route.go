@@ -0,0 +1,30 @@
+package routes
+
+import (
+	"free-flow-api/controllers"
+	"free-flow-api/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterMarketplaceRouter(rg *gin.RouterGroup) {
+	marketplace := rg.Group("/marketplace")
+	marketplace.Use(middleware.VerifyToken())
+	{
+		// freelancer
+		marketplace.POST("/posting", controllers.CreateJobPosting)
+		marketplace.GET("/posting", controllers.GetMyJobPostings)
+		marketplace.GET("/posting/:id", controllers.GetJobPostingByID)
+		marketplace.PUT("/posting/:id", controllers.UpdateJobPosting)
+		marketplace.POST("/posting/:id/close", controllers.CloseJobPosting)
+		marketplace.POST("/application/:id/accept", controllers.AcceptJobApplication)
+		marketplace.POST("/application/:id/reject", controllers.RejectJobApplication)
+
+		// associate
+		marketplace.POST("/join", controllers.JoinMarketplace)
+		marketplace.GET("/feed", controllers.GetMarketplaceFeed)
+		marketplace.POST("/posting/:id/apply", controllers.ApplyToJobPosting)
+		marketplace.GET("/application", controllers.GetMyJobApplications)
+		marketplace.POST("/application/:id/withdraw", controllers.WithdrawJobApplication)
+	}
+}
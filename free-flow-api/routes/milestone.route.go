@@ -19,4 +19,11 @@ func RegisterMilestoneRouter(rg *gin.RouterGroup) {
 		milestone.DELETE("/:id", controllers.DeleteMilestone)
 		milestone.PUT("/:id/add", controllers.AddTasksToMilestone)
 	}
+
+	// approval is the client's call, made through their project link
+	client := rg.Group("/client/project/:token/milestones")
+	client.Use(middleware.VerifyClient())
+	{
+		client.PUT("/:id/approve", controllers.ClientApproveMilestone)
+	}
 }
@@ -18,5 +18,13 @@ func RegisterProjectRouter(rg *gin.RouterGroup) {
 		project.GET("/:id", controllers.GetProjectByID)
 		project.PUT("/:id", controllers.UpdateProject)
 		project.DELETE("/:id", controllers.DeleteProject)
+		project.POST("/:id/deposit", controllers.CreateProjectDeposit)
+		project.GET("/:id/deposits", controllers.GetProjectDeposits)
+		project.GET("/:id/schedule", controllers.GetProjectSchedule)
+		project.POST("/:id/schedule", controllers.ApplyProjectSchedule)
+		project.POST("/:id/client-link", controllers.CreateClientLink)
+		project.DELETE("/:id/client-link", controllers.RevokeClientLink)
+		project.GET("/:id/status-history", controllers.GetProjectStatusHistory)
+		project.GET("/:id/activity", controllers.GetProjectActivity)
 	}
 }
@@ -0,0 +1,30 @@
+package routes
+
+import (
+	"free-flow-api/controllers"
+	"free-flow-api/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterQuoteRouter(rg *gin.RouterGroup) {
+	quote := rg.Group("/quote")
+	quote.Use(middleware.VerifyToken())
+	{
+		quote.POST("/", controllers.CreateQuote)
+		quote.GET("/u", controllers.GetQuotesByUserID)
+		quote.GET("/:id", controllers.GetQuoteByID)
+		quote.PUT("/:id", controllers.UpdateQuote)
+		quote.DELETE("/:id", controllers.DeleteQuote)
+		quote.POST("/:id/send", controllers.SendQuote)
+		quote.POST("/:id/convert", controllers.ConvertQuote)
+	}
+
+	// client acceptance link
+	client := rg.Group("/client/quote")
+	client.Use(middleware.VerifyQuote())
+	{
+		client.GET("/:token", controllers.GetPublicQuote)
+		client.POST("/response/:token", controllers.RespondToQuote)
+	}
+}
@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"free-flow-api/controllers"
+	"free-flow-api/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterReviewRouter(rg *gin.RouterGroup) {
+	review := rg.Group("/review")
+	review.Use(middleware.VerifyToken())
+	{
+		review.POST("/task/:id", controllers.SubmitTaskReview)
+		review.GET("/task/:id", controllers.GetTaskReviews)
+		review.GET("/associate/:id", controllers.GetAssociateReviews)
+		review.GET("/freelancer/:id", controllers.GetFreelancerReviews)
+	}
+}
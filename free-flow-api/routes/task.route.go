@@ -17,6 +17,28 @@ func RegisterTaskRouter(rg *gin.RouterGroup) {
 		task.GET("p/:id", controllers.GetAllTasksByProjectID)
 		task.PUT("/:id", controllers.UpdateTask)
 		task.DELETE("/:id", controllers.DeleteTask)
+		task.POST("/:id/offer", controllers.CreateTaskOffer)
+		task.GET("/:id/offers", controllers.GetTaskOffers)
+		task.POST("/:id/dependencies", controllers.AddTaskDependency)
+		task.GET("/:id/dependencies", controllers.GetTaskDependencies)
+		task.DELETE("/:id/dependencies/:dependencyId", controllers.DeleteTaskDependency)
+		task.GET("/:id/subtasks", controllers.GetSubtasks)
+		task.GET("/:id/checklist", controllers.GetTaskChecklist)
+		task.POST("/:id/checklist", controllers.AddChecklistItem)
+		task.PUT("/:id/checklist/:itemId", controllers.UpdateChecklistItem)
+		task.DELETE("/:id/checklist/:itemId", controllers.DeleteChecklistItem)
+		task.POST("/:id/deliverables", controllers.SubmitDeliverable)
+		task.GET("/:id/deliverables", controllers.GetTaskDeliverables)
+		task.POST("/:id/deliverables/:deliverableId/approve", controllers.ApproveDeliverable)
+		task.POST("/:id/deliverables/:deliverableId/request-changes", controllers.RequestDeliverableChanges)
+	}
+
+	offer := rg.Group("/offer")
+	offer.Use(middleware.VerifyToken())
+	{
+		offer.GET("/:id", controllers.GetTaskOffer)
+		offer.POST("/:id/award", controllers.AwardTaskOffer)
+		offer.POST("/:id/cancel", controllers.CancelTaskOffer)
 	}
 
 	associate := rg.Group("/associate")
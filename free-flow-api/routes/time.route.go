@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"free-flow-api/controllers"
+	"free-flow-api/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterTimeRouter(rg *gin.RouterGroup) {
+	entries := rg.Group("/time")
+	entries.Use(middleware.VerifyToken())
+	{
+		entries.POST("/start", controllers.StartTimer)
+		entries.POST("/stop", controllers.StopTimer)
+		entries.GET("/running", controllers.GetRunningTimer)
+		entries.GET("/timesheet", controllers.GetWeeklyTimesheet)
+		entries.GET("/task/:id", controllers.GetTaskTimeEntries)
+		entries.POST("/", controllers.CreateManualTimeEntry)
+		entries.GET("/", controllers.GetTimeEntries)
+		entries.PUT("/:id", controllers.UpdateTimeEntry)
+		entries.DELETE("/:id", controllers.DeleteTimeEntry)
+	}
+}
@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"free-flow-api/controllers"
+	"free-flow-api/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterVerificationRouter(rg *gin.RouterGroup) {
+	verification := rg.Group("/associate/verification")
+	verification.Use(middleware.VerifyToken())
+	{
+		verification.POST("/", controllers.SubmitVerification)
+		verification.GET("/", controllers.GetMyVerification)
+	}
+}
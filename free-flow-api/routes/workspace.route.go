@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"free-flow-api/controllers"
+	"free-flow-api/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterWorkspaceRouter(rg *gin.RouterGroup) {
+	me := rg.Group("/associate/me")
+	me.Use(middleware.VerifyToken())
+	{
+		me.GET("/", controllers.GetMyAssociateProfile)
+		me.PUT("/", controllers.UpdateMyAssociateProfile)
+		me.PUT("/password", controllers.ChangeAssociatePassword)
+		me.POST("/photo", controllers.UploadAssociatePhoto)
+		me.GET("/contracts", controllers.GetMyContracts)
+		me.GET("/invites", controllers.GetMyInvites)
+		me.GET("/earnings", controllers.GetMyEarnings)
+		me.GET("/earnings/settlements", controllers.GetMyEarningsSettlements)
+		me.GET("/earnings/trends", controllers.GetMyEarningsTrends)
+		me.GET("/earnings/statement", controllers.GetMyEarningsStatement)
+	}
+
+	// public so it can be used directly in <img> tags
+	rg.GET("/associate/photo/:id", controllers.GetAssociatePhoto)
+}
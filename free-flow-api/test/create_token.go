@@ -20,7 +20,7 @@ func main() {
 	// fmt.Println(token)
 
 	// generate invite token
-	token, err := config.GenerateInviteToken("8446539b-33c9-432d-87c1-e71621ab5d32", "7f13de47-aa3d-4339-aaef-8cef4333b4be", "0cf21213-495c-4a4d-842e-84fd37b29c2d", "5b941f2a-2faf-49e9-9a9e-e6bf5c9f92ed", 72*time.Hour)
+	token, err := config.GenerateInviteToken("8446539b-33c9-432d-87c1-e71621ab5d32", "7f13de47-aa3d-4339-aaef-8cef4333b4be", "0cf21213-495c-4a4d-842e-84fd37b29c2d", "5b941f2a-2faf-49e9-9a9e-e6bf5c9f92ed", "", time.Now().Add(72*time.Hour))
 	if err != nil {
 		fmt.Print(err.Error())
 	}
@@ -0,0 +1,470 @@
+package utils
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"free-flow-api/models"
+	"reflect"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	auditSnapshotKey = "audit:before"
+	auditBatchSize   = 500 // rows read or written per audit query; bulk writes are paged through
+	auditRedacted    = "[redacted]"
+)
+
+// tables that are logs themselves
+var auditSkipTables = map[string]bool{
+	"audit_logs":     true,
+	"status_changes": true,
+	"admin_actions":  true,
+}
+
+// columns that change on their own and never make an update worth recording
+var auditNoiseColumns = map[string]bool{
+	"created_at":       true,
+	"updated_at":       true,
+	"last_activity_at": true,
+}
+
+// parents a row's project can be looked up through when it has no project_id of its own
+var auditProjectParents = []struct{ column, table string }{
+	{"task_id", "tasks"},
+	{"invoice_id", "invoices"},
+	{"milestone_id", "milestones"},
+}
+
+// RegisterAuditCallbacks records every create, update and delete made through db in the audit log.
+// Who made the change is read from the statement's context, so handlers should pass their
+// *gin.Context with db.WithContext(c) on writes; writes without one are attributed to the system.
+func RegisterAuditCallbacks(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Create().After("gorm:create").Register("audit:create", auditCreate); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("audit:snapshot_update", auditSnapshot); err != nil {
+		return err
+	}
+	if err := cb.Update().After("gorm:update").Register("audit:update", auditUpdate); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("gorm:delete").Register("audit:snapshot_delete", auditSnapshot); err != nil {
+		return err
+	}
+	return cb.Delete().After("gorm:delete").Register("audit:delete", auditDelete)
+}
+
+func auditable(db *gorm.DB) bool {
+	stmt := db.Statement
+	return db.Error == nil && !db.DryRun && stmt.Schema != nil && stmt.Table != "" && !auditSkipTables[stmt.Table]
+}
+
+// auditSession is a fresh query on the statement's connection, so it runs inside the same transaction
+func auditSession(db *gorm.DB) *gorm.DB {
+	return db.Session(&gorm.Session{NewDB: true, SkipHooks: true})
+}
+
+func auditCreate(db *gorm.DB) {
+	if !auditable(db) || db.RowsAffected == 0 {
+		return
+	}
+	stmt := db.Statement
+	redacted := auditRedactedColumns(stmt.Schema)
+
+	var records []auditRecord
+	auditEachRecord(stmt.ReflectValue, func(rv reflect.Value) {
+		row := make(map[string]interface{})
+		changes := models.AuditChanges{}
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName == "" || auditNoiseColumns[field.DBName] || field.DBName == "deleted_at" {
+				continue
+			}
+			value, zero := field.ValueOf(stmt.Context, rv)
+			row[field.DBName] = auditValue(value)
+			if zero {
+				continue
+			}
+			change := models.AuditChange{After: row[field.DBName]}
+			if redacted[field.DBName] {
+				change.After = auditRedacted
+			}
+			changes[field.DBName] = change
+		}
+		records = append(records, auditRecord{row, changes})
+	})
+	auditSave(db, models.AuditCreate, records)
+}
+
+// auditSnapshot loads the rows a statement is about to change, before it changes them
+func auditSnapshot(db *gorm.DB) {
+	if !auditable(db) {
+		return
+	}
+	rows, err := auditRows(db, auditConditions(db))
+	if err != nil {
+		db.AddError(err)
+		return
+	}
+	db.InstanceSet(auditSnapshotKey, rows)
+}
+
+func auditUpdate(db *gorm.DB) {
+	before, ok := auditSnapshotRows(db)
+	if !ok || db.RowsAffected == 0 || len(before) == 0 {
+		return
+	}
+	stmt := db.Statement
+	pk := stmt.Schema.PrioritizedPrimaryField
+	if pk == nil {
+		return
+	}
+
+	afterByID := make(map[string]map[string]interface{}, len(before))
+	for start := 0; start < len(before); start += auditBatchSize {
+		batch := before[start:min(start+auditBatchSize, len(before))]
+		ids := make([]interface{}, 0, len(batch))
+		for _, row := range batch {
+			ids = append(ids, row[pk.DBName])
+		}
+		after, err := auditRows(db, []clause.Expression{clause.IN{Column: clause.Column{Table: stmt.Table, Name: pk.DBName}, Values: ids}})
+		if err != nil {
+			db.AddError(err)
+			return
+		}
+		for _, row := range after {
+			afterByID[fmt.Sprint(row[pk.DBName])] = row
+		}
+	}
+
+	redacted := auditRedactedColumns(stmt.Schema)
+	var records []auditRecord
+	for _, old := range before {
+		updated, ok := afterByID[fmt.Sprint(old[pk.DBName])]
+		if !ok {
+			continue
+		}
+		changes := models.AuditChanges{}
+		for column, value := range updated {
+			if auditNoiseColumns[column] || reflect.DeepEqual(old[column], value) {
+				continue
+			}
+			change := models.AuditChange{Before: old[column], After: value}
+			if redacted[column] {
+				change = models.AuditChange{Before: auditRedacted, After: auditRedacted}
+			}
+			changes[column] = change
+		}
+		if len(changes) == 0 {
+			continue
+		}
+		records = append(records, auditRecord{updated, changes})
+	}
+	auditSave(db, models.AuditUpdate, records)
+}
+
+func auditDelete(db *gorm.DB) {
+	before, ok := auditSnapshotRows(db)
+	if !ok || db.RowsAffected == 0 || len(before) == 0 {
+		return
+	}
+	redacted := auditRedactedColumns(db.Statement.Schema)
+
+	records := make([]auditRecord, 0, len(before))
+	for _, row := range before {
+		changes := models.AuditChanges{}
+		for column, value := range row {
+			if value == nil || auditNoiseColumns[column] || column == "deleted_at" {
+				continue
+			}
+			if redacted[column] {
+				value = auditRedacted
+			}
+			changes[column] = models.AuditChange{Before: value}
+		}
+		records = append(records, auditRecord{row, changes})
+	}
+	auditSave(db, models.AuditDelete, records)
+}
+
+func auditSnapshotRows(db *gorm.DB) ([]map[string]interface{}, bool) {
+	if !auditable(db) {
+		return nil, false
+	}
+	value, ok := db.InstanceGet(auditSnapshotKey)
+	if !ok {
+		return nil, false
+	}
+	rows, ok := value.([]map[string]interface{})
+	return rows, ok
+}
+
+// auditConditions rebuilds the statement's WHERE, plus the primary keys GORM adds from the model later on
+func auditConditions(db *gorm.DB) []clause.Expression {
+	stmt := db.Statement
+	var exprs []clause.Expression
+	if c, ok := stmt.Clauses["WHERE"]; ok {
+		if where, ok := c.Expression.(clause.Where); ok {
+			exprs = append(exprs, where.Exprs...)
+		}
+	}
+
+	pkValue := stmt.ReflectValue
+	if stmt.Model != nil && stmt.Model != stmt.Dest {
+		pkValue = reflect.ValueOf(stmt.Model)
+	}
+	if len(stmt.Schema.PrimaryFields) > 0 && pkValue.IsValid() {
+		_, identities := schema.GetIdentityFieldValuesMap(stmt.Context, reflect.Indirect(pkValue), stmt.Schema.PrimaryFields)
+		column, values := schema.ToQueryValues(stmt.Table, stmt.Schema.PrimaryFieldDBNames, identities)
+		if len(values) > 0 {
+			exprs = append(exprs, clause.IN{Column: column, Values: values})
+		}
+	}
+	return exprs
+}
+
+// auditRows reads the current state of every row matching exprs, a page at a time, normalised
+// for comparison and JSON
+func auditRows(db *gorm.DB, exprs []clause.Expression) ([]map[string]interface{}, error) {
+	if len(exprs) == 0 {
+		// GORM refuses unconditioned writes anyway; don't snapshot a whole table
+		return nil, nil
+	}
+	stmt := db.Statement
+	var rows []map[string]interface{}
+	for offset := 0; ; offset += auditBatchSize {
+		query := auditSession(db).Table(stmt.Table).Clauses(clause.Where{Exprs: exprs})
+		if !stmt.Unscoped && stmt.Schema.LookUpField("deleted_at") != nil {
+			query = query.Where(clause.Eq{Column: clause.Column{Table: stmt.Table, Name: "deleted_at"}, Value: nil})
+		}
+		if pk := stmt.Schema.PrioritizedPrimaryField; pk != nil {
+			query = query.Order(clause.OrderByColumn{Column: clause.Column{Table: stmt.Table, Name: pk.DBName}})
+		}
+
+		var page []map[string]interface{}
+		if err := query.Limit(auditBatchSize).Offset(offset).Find(&page).Error; err != nil {
+			return nil, err
+		}
+		for _, row := range page {
+			for column, value := range row {
+				row[column] = auditValue(value)
+			}
+		}
+		rows = append(rows, page...)
+		if len(page) < auditBatchSize {
+			return rows, nil
+		}
+	}
+}
+
+// auditRecord is one row's changes, waiting to be stamped and written
+type auditRecord struct {
+	row     map[string]interface{}
+	changes models.AuditChanges
+}
+
+// auditSave writes the statement's records stamped with who made the change and from where.
+// The actor and the rows' projects are resolved once for the whole statement, not per row.
+func auditSave(db *gorm.DB, action string, records []auditRecord) {
+	if len(records) == 0 {
+		return
+	}
+	stmt := db.Statement
+	ctx := stmt.Context
+	actorType, actorID := auditActor(db, ctx)
+	impersonatorID := auditUUID(ctx.Value("impersonatorID"))
+	requestID, _ := ctx.Value("requestID").(string)
+	ipAddress, _ := ctx.Value("clientIP").(string)
+
+	rows := make([]map[string]interface{}, len(records))
+	for i, r := range records {
+		rows[i] = r.row
+	}
+	projects, err := auditProjects(db, rows)
+	if err != nil {
+		db.AddError(err)
+		return
+	}
+
+	entries := make([]models.AuditLog, 0, len(records))
+	for i, r := range records {
+		entry := models.AuditLog{
+			ID:             uuid.New(), // hooks are skipped on audit writes
+			Action:         action,
+			RecordType:     stmt.Table,
+			ProjectID:      projects[i],
+			ActorType:      actorType,
+			ActorID:        actorID,
+			ImpersonatorID: impersonatorID,
+			RequestID:      requestID,
+			IPAddress:      ipAddress,
+			Changes:        r.changes,
+		}
+		if pk := stmt.Schema.PrioritizedPrimaryField; pk != nil {
+			entry.RecordID = auditUUID(r.row[pk.DBName])
+		}
+		entries = append(entries, entry)
+	}
+	db.AddError(auditSession(db).CreateInBatches(&entries, auditBatchSize).Error)
+}
+
+// auditActor works out the principal from the keys the auth middleware leaves on the request
+func auditActor(db *gorm.DB, ctx context.Context) (string, *uuid.UUID) {
+	if id := auditUUID(ctx.Value("userID")); id != nil {
+		// freelancer and associate tokens carry the same claim, so look the id up
+		var users int64
+		auditSession(db).Table("users").Where("id = ?", *id).Count(&users)
+		if users > 0 {
+			return models.PrincipalUser, id
+		}
+		return models.PrincipalAssociate, id
+	}
+	for _, key := range []string{"associateID", "associate_id"} {
+		if id := auditUUID(ctx.Value(key)); id != nil {
+			return models.PrincipalAssociate, id
+		}
+	}
+	if id := auditUUID(ctx.Value("entity_id")); id != nil {
+		return models.PrincipalClient, id
+	}
+	if quote, _ := ctx.Value("quote_id").(string); quote != "" {
+		return models.PrincipalClient, nil
+	}
+	if service, _ := ctx.Value("service").(string); service != "" {
+		return models.PrincipalService, nil
+	}
+	return models.PrincipalSystem, nil
+}
+
+// auditProjects finds the project each row belongs to, directly or through its task, invoice or
+// milestone. Parents are looked up a batch at a time, one query per parent table.
+func auditProjects(db *gorm.DB, rows []map[string]interface{}) ([]*uuid.UUID, error) {
+	projects := make([]*uuid.UUID, len(rows))
+	for i, row := range rows {
+		if db.Statement.Table == "projects" {
+			projects[i] = auditUUID(row["id"])
+		} else {
+			projects[i] = auditUUID(row["project_id"])
+		}
+	}
+
+	for _, parent := range auditProjectParents {
+		var ids []uuid.UUID
+		seen := make(map[uuid.UUID]bool)
+		for i, row := range rows {
+			if projects[i] != nil {
+				continue
+			}
+			if id := auditUUID(row[parent.column]); id != nil && !seen[*id] {
+				seen[*id] = true
+				ids = append(ids, *id)
+			}
+		}
+
+		found := make(map[uuid.UUID]uuid.UUID, len(ids))
+		for start := 0; start < len(ids); start += auditBatchSize {
+			var links []struct {
+				ID        uuid.UUID
+				ProjectID uuid.UUID
+			}
+			if err := auditSession(db).Table(parent.table).
+				Select("id", "project_id").
+				Where("id IN ? AND project_id IS NOT NULL", ids[start:min(start+auditBatchSize, len(ids))]).
+				Scan(&links).Error; err != nil {
+				return nil, err
+			}
+			for _, link := range links {
+				found[link.ID] = link.ProjectID
+			}
+		}
+
+		for i, row := range rows {
+			if projects[i] != nil {
+				continue
+			}
+			if id := auditUUID(row[parent.column]); id != nil {
+				if projectID, ok := found[*id]; ok {
+					projects[i] = &projectID
+				}
+			}
+		}
+	}
+	return projects, nil
+}
+
+// auditRedactedColumns are the columns whose values stay out of the log: those tagged audit:"-"
+// (credentials, account and document numbers) and those hidden from API responses
+func auditRedactedColumns(s *schema.Schema) map[string]bool {
+	redacted := make(map[string]bool)
+	for _, field := range s.Fields {
+		if field.DBName == "" || field.DBName == "deleted_at" {
+			continue
+		}
+		if field.Tag.Get("audit") == "-" || field.Tag.Get("json") == "-" {
+			redacted[field.DBName] = true
+		}
+	}
+	return redacted
+}
+
+func auditEachRecord(rv reflect.Value, fn func(reflect.Value)) {
+	rv = reflect.Indirect(rv)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			fn(reflect.Indirect(rv.Index(i)))
+		}
+	case reflect.Struct:
+		fn(rv)
+	}
+}
+
+// auditValue normalises a model field or scanned column so both compare and marshal the same way
+func auditValue(value interface{}) interface{} {
+	rv := reflect.ValueOf(value)
+	for rv.IsValid() && rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return nil
+	}
+	value = rv.Interface()
+
+	if valuer, ok := value.(driver.Valuer); ok {
+		v, err := valuer.Value()
+		if err != nil {
+			return nil
+		}
+		value = v
+	}
+	switch v := value.(type) {
+	case []byte:
+		return string(v)
+	case [16]byte:
+		return uuid.UUID(v).String()
+	}
+	return value
+}
+
+func auditUUID(value interface{}) *uuid.UUID {
+	var id uuid.UUID
+	var err error
+	switch v := auditValue(value).(type) {
+	case string:
+		id, err = uuid.Parse(v)
+	default:
+		return nil
+	}
+	if err != nil || id == uuid.Nil {
+		return nil
+	}
+	return &id
+}
@@ -7,13 +7,14 @@ import (
 	"github.com/google/uuid"
 )
 
+// suspended accounts fail every check below
 func IsAuthenticated(userID string) bool {
 	parsed, err := uuid.Parse(userID)
 	if err != nil {
 		return false
 	}
 	var user models.User
-	if err := config.DB.First(&user, "id = ?", parsed).Error; err != nil {
+	if err := config.DB.First(&user, "id = ? AND suspended_at IS NULL", parsed).Error; err != nil {
 		return false
 	}
 	return true
@@ -25,7 +26,19 @@ func IsAssociateAuthenticated(associateID string) bool {
 		return false
 	}
 	var associate models.AssociateProfile
-	if err := config.DB.First(&associate, "associate_id = ?", parsed).Error; err != nil {
+	if err := config.DB.First(&associate, "associate_id = ? AND suspended_at IS NULL", parsed).Error; err != nil {
+		return false
+	}
+	return true
+}
+
+func IsAdmin(userID string) bool {
+	parsed, err := uuid.Parse(userID)
+	if err != nil {
+		return false
+	}
+	var user models.User
+	if err := config.DB.First(&user, "id = ? AND is_admin = ? AND suspended_at IS NULL", parsed, true).Error; err != nil {
 		return false
 	}
 	return true
@@ -3,6 +3,7 @@ package utils
 import (
 	"fmt"
 	"free-flow-api/config"
+	"free-flow-api/models"
 
 	"github.com/golang-jwt/jwt/v5"
 )
@@ -25,3 +26,72 @@ func VerifyInviteToken(tokenString string) (*config.InviteClaims, error) {
 
 	return claims, nil
 }
+
+func VerifyQuoteToken(tokenString string) (*config.QuoteClaims, error) {
+	secret := []byte(config.GetEnv("JWT_SECRET"))
+
+	token, err := jwt.ParseWithClaims(tokenString, &config.QuoteClaims{}, func(token *jwt.Token) (interface{}, error) {
+		return secret, nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid token: %v", err)
+	}
+
+	claims, ok := token.Claims.(*config.QuoteClaims)
+	if !ok || !token.Valid || claims.Subject != "quote" {
+		return nil, fmt.Errorf("invalid or expired token")
+	}
+
+	return claims, nil
+}
+
+func VerifyClientToken(tokenString string) (*config.ClientClaims, error) {
+	secret := []byte(config.GetEnv("JWT_SECRET"))
+
+	token, err := jwt.ParseWithClaims(tokenString, &config.ClientClaims{}, func(token *jwt.Token) (interface{}, error) {
+		return secret, nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid token: %v", err)
+	}
+
+	claims, ok := token.Claims.(*config.ClientClaims)
+	if !ok || !token.Valid || claims.Subject != "client" {
+		return nil, fmt.Errorf("invalid or expired token")
+	}
+
+	return claims, nil
+}
+
+func VerifyFileToken(tokenString string) (*config.FileClaims, error) {
+	secret := []byte(config.GetEnv("JWT_SECRET"))
+
+	token, err := jwt.ParseWithClaims(tokenString, &config.FileClaims{}, func(token *jwt.Token) (interface{}, error) {
+		return secret, nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid token: %v", err)
+	}
+
+	claims, ok := token.Claims.(*config.FileClaims)
+	if !ok || !token.Valid || claims.Subject != "file" {
+		return nil, fmt.Errorf("invalid or expired token")
+	}
+
+	return claims, nil
+}
+
+// IssueInviteToken signs a link for the invite using its current token id and expiry
+func IssueInviteToken(invite models.Invite) (string, error) {
+	return config.GenerateInviteToken(
+		invite.ID.String(),
+		invite.AssociateID.String(),
+		invite.ContractID.String(),
+		invite.TaskID.String(),
+		invite.TokenID,
+		invite.ExpiresAt,
+	)
+}
@@ -0,0 +1,22 @@
+package utils
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// GenerateInvoiceNumber builds a short human readable invoice number e.g. INV-FF-1A2B3C4D
+func GenerateInvoiceNumber() string {
+	return documentNumber("INV-FF-")
+}
+
+// GenerateQuoteNumber builds a short human readable quote number e.g. QUO-FF-1A2B3C4D
+func GenerateQuoteNumber() string {
+	return documentNumber("QUO-FF-")
+}
+
+func documentNumber(prefix string) string {
+	id := uuid.New().String()
+	return strings.ToUpper(prefix + id[:8])
+}
@@ -0,0 +1,120 @@
+package utils
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"free-flow-api/config"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrUploadInfected = errors.New("file failed the virus scan")
+
+// Scan results recorded against stored files
+const (
+	ScanClean   = "clean"
+	ScanSkipped = "skipped" // no scanner configured
+)
+
+// VirusScanner inspects file contents before they are stored. Scan returns ErrUploadInfected
+// for a detection and any other error when the scan itself could not run.
+type VirusScanner interface {
+	Scan(r io.Reader) error
+}
+
+var (
+	scannerOnce    sync.Once
+	currentScanner VirusScanner
+)
+
+// UploadScanner returns the configured scanner: clamd when CLAMD_ADDR is set, otherwise none
+func UploadScanner() VirusScanner {
+	scannerOnce.Do(func() {
+		if addr := config.GetEnvOrDefault("CLAMD_ADDR", ""); addr != "" {
+			currentScanner = &ClamdScanner{Addr: addr, Timeout: time.Minute}
+		}
+	})
+	return currentScanner
+}
+
+// SetUploadScanner installs a scanner hook, or removes it with nil
+func SetUploadScanner(s VirusScanner) {
+	scannerOnce.Do(func() {})
+	currentScanner = s
+}
+
+// ScanUpload runs the configured scanner and reports the status to record
+func ScanUpload(r io.Reader) (string, error) {
+	scanner := UploadScanner()
+	if scanner == nil {
+		return ScanSkipped, nil
+	}
+	if err := scanner.Scan(r); err != nil {
+		return "", err
+	}
+	return ScanClean, nil
+}
+
+// ClamdScanner streams files to a clamd daemon over TCP using INSTREAM
+type ClamdScanner struct {
+	Addr    string
+	Timeout time.Duration
+}
+
+func (s *ClamdScanner) Scan(r io.Reader) error {
+	conn, err := net.DialTimeout("tcp", s.Addr, 10*time.Second)
+	if err != nil {
+		return fmt.Errorf("virus scanner unavailable: %w", err)
+	}
+	defer conn.Close()
+	if s.Timeout > 0 {
+		conn.SetDeadline(time.Now().Add(s.Timeout))
+	}
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return err
+	}
+	buf := make([]byte, 32<<10)
+	size := make([]byte, 4)
+	for {
+		n, readErr := r.Read(buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size, uint32(n))
+			if _, err := conn.Write(size); err != nil {
+				return err
+			}
+			if _, err := conn.Write(buf[:n]); err != nil {
+				return err
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+	binary.BigEndian.PutUint32(size, 0)
+	if _, err := conn.Write(size); err != nil {
+		return err
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && err != io.EOF {
+		return err
+	}
+	reply = strings.TrimRight(reply, "\x00\n")
+	switch {
+	case strings.HasSuffix(reply, "OK"):
+		return nil
+	case strings.HasSuffix(reply, "FOUND"):
+		return ErrUploadInfected
+	default:
+		return fmt.Errorf("virus scanner error: %s", reply)
+	}
+}
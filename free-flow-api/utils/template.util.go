@@ -0,0 +1,31 @@
+package utils
+
+import (
+	"regexp"
+	"sort"
+)
+
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([a-zA-Z_.]+)\s*\}\}`)
+
+// RenderTemplate replaces {{key}} placeholders with their values.
+// Unknown placeholders are left untouched and reported back so callers can surface them.
+func RenderTemplate(text string, vars map[string]string) (string, []string) {
+	missing := map[string]bool{}
+
+	out := placeholderPattern.ReplaceAllStringFunc(text, func(match string) string {
+		key := placeholderPattern.FindStringSubmatch(match)[1]
+		if v, ok := vars[key]; ok {
+			return v
+		}
+		missing[key] = true
+		return match
+	})
+
+	keys := make([]string, 0, len(missing))
+	for k := range missing {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return out, keys
+}
@@ -0,0 +1,148 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"free-flow-api/config"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const MaxUploadSize = 10 << 20 // 10MB
+
+var ErrUploadTooLarge = errors.New("file exceeds the 10MB limit")
+var ErrUploadType = errors.New("file type is not allowed")
+
+// StoredFile describes an upload written to FileStorage
+type StoredFile struct {
+	Key         string
+	ContentType string
+	Size        int64
+	Checksum    string // sha256, hex
+	ScanStatus  string
+}
+
+// UploadDir is where the local storage backend writes files, relative to the working directory by default
+func UploadDir() string {
+	return config.GetEnvOrDefault("UPLOAD_DIR", "uploads")
+}
+
+// SaveUpload validates an uploaded file against the allowed MIME types and stores it under subdir.
+// It returns the storage key and the detected MIME type.
+func SaveUpload(file *multipart.FileHeader, subdir string, allowed []string) (string, string, error) {
+	stored, err := StoreUpload(file, subdir, allowed, MaxUploadSize)
+	if err != nil {
+		return "", "", err
+	}
+	return stored.Key, stored.ContentType, nil
+}
+
+// StoreUpload checks size and sniffed MIME type, runs the virus scan hook and writes the file
+// to FileStorage under subdir with a random name.
+func StoreUpload(file *multipart.FileHeader, subdir string, allowed []string, maxSize int64) (*StoredFile, error) {
+	if file.Size > maxSize {
+		if maxSize == MaxUploadSize {
+			return nil, ErrUploadTooLarge
+		}
+		return nil, fmt.Errorf("%w: file exceeds the %dMB limit", ErrUploadTooLarge, maxSize>>20)
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	// sniff the content rather than trusting the client's header
+	head := make([]byte, 512)
+	n, _ := src.Read(head)
+	mimeType := http.DetectContentType(head[:n])
+	if i := strings.Index(mimeType, ";"); i >= 0 {
+		mimeType = mimeType[:i]
+	}
+
+	ok := false
+	for _, a := range allowed {
+		if a == mimeType {
+			ok = true
+			break
+		}
+	}
+	if !ok {
+		return nil, ErrUploadType
+	}
+
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	status, err := ScanUpload(src)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	hash := sha256.New()
+	key := path.Join(filepath.ToSlash(subdir), uuid.NewString()+strings.ToLower(filepath.Ext(file.Filename)))
+	if err := FileStorage().Put(key, io.TeeReader(src, hash), file.Size, mimeType); err != nil {
+		return nil, err
+	}
+
+	return &StoredFile{
+		Key:         key,
+		ContentType: mimeType,
+		Size:        file.Size,
+		Checksum:    hex.EncodeToString(hash.Sum(nil)),
+		ScanStatus:  status,
+	}, nil
+}
+
+// IsUploadRejected reports whether err is the file's fault rather than a storage failure
+func IsUploadRejected(err error) bool {
+	return errors.Is(err, ErrUploadTooLarge) || errors.Is(err, ErrUploadType) || errors.Is(err, ErrUploadInfected)
+}
+
+// RemoveUpload deletes a stored file; a file that is already gone is not an error
+func RemoveUpload(key string) error {
+	if key == "" {
+		return nil
+	}
+	return FileStorage().Delete(key)
+}
+
+// ServeUpload streams a stored file to the client. With a filename the browser is told to download it.
+func ServeUpload(c *gin.Context, key, contentType, filename string) {
+	file, err := FileStorage().Open(key)
+	if err != nil {
+		if errors.Is(err, ErrStorageNotFound) {
+			SendErrorResponse(c, http.StatusNotFound, "file is missing")
+			return
+		}
+		SendErrorResponse(c, http.StatusInternalServerError, "failed to read file")
+		return
+	}
+	defer file.Close()
+
+	if contentType == "" {
+		contentType = mime.TypeByExtension(path.Ext(key))
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	headers := map[string]string{"X-Content-Type-Options": "nosniff"}
+	if filename != "" {
+		headers["Content-Disposition"] = "inline; filename=\"" + strings.ReplaceAll(filename, "\"", "") + "\""
+	}
+	c.DataFromReader(http.StatusOK, -1, contentType, file, headers)
+}